@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	redisStorage "github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/redis"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/config"
+	infraRedis "github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/redis"
+)
+
+// runKeys executa os subcomandos de manutenção das chaves no storage
+func runKeys(args []string, stdout, stderr io.Writer) int {
+	if args[0] != "migrate" {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	flags := flag.NewFlagSet("ratelimiter keys migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dryRun := flags.Bool("dry-run", false, "apenas conta as chaves que seriam migradas")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	return migrateTokenKeys(*dryRun, stdout, stderr)
+}
+
+// migrateTokenKeys renomeia as chaves de tokens em texto puro para o digest HMAC (TOKEN_KEY_SECRET)
+func migrateTokenKeys(dryRun bool, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitInvalid
+	}
+
+	switch {
+	case cfg.StorageBackend != "redis":
+		fmt.Fprintln(stderr, "error: keys migrate requires STORAGE_BACKEND=redis")
+		return exitInvalid
+	case len(cfg.RedisShardAddrs) > 0:
+		// O nó de cada chave muda junto com o nome; as chaves antigas apenas expiram
+		fmt.Fprintln(stderr, "error: keys migrate does not support REDIS_SHARD_ADDRS")
+		return exitInvalid
+	case cfg.TokenKeySecret == "":
+		fmt.Fprintln(stderr, "error: TOKEN_KEY_SECRET is required")
+		return exitInvalid
+	}
+
+	client, err := infraRedis.NewClient(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitInvalid
+	}
+	storage := redisStorage.NewRedisStorage(client)
+	defer storage.Close()
+	// Mesmo client: o hash de configurações de token (TOKEN_CONFIG_SOURCE=redis) também guarda tokens
+	tokenStore := redisStorage.NewRedisTokenConfigStore(client).WithTokenHasher(cfg.TokenHasher())
+
+	migrated, err := storage.MigrateTokenKeys(context.Background(), cfg.TokenHasher(), dryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v (%d key(s) migrated)\n", err, migrated)
+		return exitInvalid
+	}
+	migratedConfigs, err := tokenStore.MigrateTokens(context.Background(), dryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v (%d key(s) and %d token config(s) migrated)\n", err, migrated, migratedConfigs)
+		return exitInvalid
+	}
+
+	if dryRun {
+		fmt.Fprintf(stdout, "%d key(s) and %d token config(s) would be migrated\n", migrated, migratedConfigs)
+		return exitOK
+	}
+	fmt.Fprintf(stdout, "%d key(s) and %d token config(s) migrated\n", migrated, migratedConfigs)
+	return exitOK
+}
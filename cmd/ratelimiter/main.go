@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/config"
+)
+
+// Códigos de saída
+const (
+	exitOK      = 0
+	exitInvalid = 1 // Configuração inválida
+	exitUsage   = 2 // Uso incorreto da CLI
+)
+
+const usage = `Usage:
+  ratelimiter config validate [-policy FILE]   valida a configuração e lista todos os problemas
+  ratelimiter config print [-policy FILE]      mostra a configuração efetiva (segredos ocultos)
+  ratelimiter keys migrate [-dry-run]          troca tokens em texto puro pelo digest HMAC nas chaves do Redis
+
+A configuração é lida exatamente como no servidor: .env, variáveis de ambiente e POLICY_FILE.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executa a CLI e retorna o código de saída
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 2 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "config":
+		return runConfig(args[1:], stdout, stderr)
+	case "keys":
+		return runKeys(args[1:], stdout, stderr)
+	default:
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+}
+
+// runConfig executa os subcomandos de config
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	command := args[0]
+	flags := flag.NewFlagSet("ratelimiter config "+command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	policyFile := flags.String("policy", "", "arquivo de política (sobrescreve POLICY_FILE)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	if *policyFile != "" {
+		os.Setenv("POLICY_FILE", *policyFile)
+	}
+
+	switch command {
+	case "validate":
+		return validateConfig(stdout, stderr)
+	case "print":
+		return printConfig(stdout, stderr)
+	default:
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+}
+
+// validateConfig reporta todos os erros e avisos da configuração
+func validateConfig(stdout, stderr io.Writer) int {
+	cfg, err := config.Inspect()
+	printWarnings(stderr, cfg)
+
+	if err != nil {
+		problems := splitErrors(err)
+		for _, problem := range problems {
+			fmt.Fprintf(stderr, "error: %s\n", problem)
+		}
+		fmt.Fprintf(stderr, "configuration is invalid: %d problem(s)\n", len(problems))
+		return exitInvalid
+	}
+
+	fmt.Fprintf(stdout, "configuration is valid: %d token(s), %d route(s), %d warning(s)\n",
+		len(cfg.TokenConfigs), len(cfg.Routes), len(cfg.Warnings))
+	return exitOK
+}
+
+// printConfig mostra a configuração efetiva (após merge de .env, env e arquivo de política)
+func printConfig(stdout, stderr io.Writer) int {
+	cfg, err := config.Inspect()
+	printWarnings(stderr, cfg)
+
+	if err != nil {
+		for _, problem := range splitErrors(err) {
+			fmt.Fprintf(stderr, "error: %s\n", problem)
+		}
+		return exitInvalid
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newEffectiveConfig(cfg.Redacted())); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitInvalid
+	}
+	return exitOK
+}
+
+// printWarnings mostra os avisos (ex: tokens ignorados por configuração inválida)
+func printWarnings(w io.Writer, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
+
+// splitErrors separa os problemas agrupados com errors.Join
+func splitErrors(err error) []string {
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	for _, e := range joined.Unwrap() {
+		problems = append(problems, splitErrors(e)...)
+	}
+	return problems
+}
+
+// effectiveConfig é a representação legível da configuração para o config print
+type effectiveConfig struct {
+	Server      serverView           `json:"server"`
+	Storage     string               `json:"storage_backend"`
+	Redis       *redisView           `json:"redis,omitempty"`
+	IP          limitView            `json:"ip"`
+	Tokens      map[string]limitView `json:"tokens"`
+	TokenSource *tokenSourceView     `json:"token_config_source,omitempty"`
+	Unknown     unknownTokensView    `json:"unknown_tokens"`
+	Escalation  *escalationView      `json:"block_escalation,omitempty"`
+	Queue       *queueView           `json:"queue,omitempty"`
+	Audit       *auditView           `json:"audit_log,omitempty"`
+	Events      *eventsView          `json:"events,omitempty"`
+	RLS         *rlsView             `json:"envoy_rls,omitempty"`
+	Proxy       *proxyView           `json:"reverse_proxy,omitempty"`
+	PolicyFile  string               `json:"policy_file,omitempty"`
+	Routes      []routeView          `json:"routes,omitempty"`
+	Allow       accessListView       `json:"allow"`
+	Deny        accessListView       `json:"deny"`
+	Warnings    []string             `json:"warnings,omitempty"`
+}
+
+type serverView struct {
+	Port        int    `json:"port"`
+	AdminPort   int    `json:"admin_port"`
+	AdminToken  string `json:"admin_token,omitempty"`
+	MetricsPort int    `json:"metrics_port"`
+	MetricsScan string `json:"metrics_blocked_keys_interval"`
+	DecisionAPI int    `json:"decision_api_port"`
+	DecisionKey string `json:"decision_api_token,omitempty"`
+	ForwardAuth int    `json:"forward_auth_port"`
+	Traces      string `json:"traces_exporter"`
+	LogLevel    string `json:"log_level"`
+	TokenSecret string `json:"token_key_secret,omitempty"`
+}
+
+type redisView struct {
+	Addrs           []string `json:"addrs"`
+	ShardAddrs      []string `json:"shard_addrs,omitempty"`
+	MasterName      string   `json:"master_name,omitempty"`
+	ClusterMode     bool     `json:"cluster_mode"`
+	Username        string   `json:"username,omitempty"`
+	Password        string   `json:"password,omitempty"`
+	DB              int      `json:"db"`
+	TLSEnabled      bool     `json:"tls_enabled"`
+	PoolSize        int      `json:"pool_size"`
+	MinIdleConns    int      `json:"min_idle_conns"`
+	DialTimeout     string   `json:"dial_timeout"`
+	ReadTimeout     string   `json:"read_timeout"`
+	WriteTimeout    string   `json:"write_timeout"`
+	ShardHealth     string   `json:"shard_health_interval,omitempty"`
+	ShardFailureMax int      `json:"shard_failure_threshold,omitempty"`
+}
+
+type limitView struct {
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	BlockTime string `json:"block_time"`
+}
+
+type tokenSourceView struct {
+	Source         string `json:"source"`
+	File           string `json:"file,omitempty"`
+	ResyncInterval string `json:"resync_interval"`
+}
+
+type unknownTokensView struct {
+	Policy     string          `json:"policy"`
+	Limit      *limitView      `json:"limit,omitempty"`
+	BruteForce *bruteForceView `json:"brute_force,omitempty"`
+}
+
+type escalationView struct {
+	Factor       float64 `json:"factor"`
+	MaxBlockTime string  `json:"max_block_time"`
+	Decay        string  `json:"decay"`
+}
+
+type queueView struct {
+	MaxDelay  string `json:"max_delay"`
+	MaxPerKey int    `json:"max_per_key"`
+}
+
+type auditView struct {
+	Destination string `json:"destination"`
+	File        string `json:"file,omitempty"`
+	Stream      string `json:"stream,omitempty"`
+	MaxLen      int64  `json:"max_len,omitempty"`
+}
+
+type eventsView struct {
+	Webhook      *webhookView `json:"webhook,omitempty"`
+	RedisChannel string       `json:"redis_channel,omitempty"`
+	Log          bool         `json:"log"`
+}
+
+type rlsView struct {
+	Port        int               `json:"port"`
+	Domain      string            `json:"domain,omitempty"`
+	Descriptors map[string]string `json:"descriptor_keys"`
+}
+
+type proxyView struct {
+	DefaultUpstream string         `json:"default_upstream,omitempty"`
+	Routes          []upstreamView `json:"routes,omitempty"`
+	PreserveHost    bool           `json:"preserve_host"`
+	DialTimeout     string         `json:"dial_timeout"`
+	ResponseTimeout string         `json:"response_timeout"`
+}
+
+type upstreamView struct {
+	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+type webhookView struct {
+	URL         string `json:"url"`
+	Secret      string `json:"secret,omitempty"`
+	QueueSize   int    `json:"queue_size"`
+	MaxAttempts int    `json:"max_attempts"`
+	Timeout     string `json:"timeout"`
+	Backoff     string `json:"backoff"`
+}
+
+type bruteForceView struct {
+	MaxDistinct int    `json:"max_distinct"`
+	Window      string `json:"window"`
+	BlockTime   string `json:"block_time"`
+}
+
+type routeView struct {
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Methods []string `json:"methods,omitempty"`
+	limitView
+}
+
+type accessListView struct {
+	IPs    []string `json:"ips"`
+	Tokens []string `json:"tokens"`
+}
+
+// newEffectiveConfig monta a visão legível a partir da configuração (já redigida)
+func newEffectiveConfig(cfg *config.Config) effectiveConfig {
+	view := effectiveConfig{
+		Server: serverView{
+			Port:        cfg.ServerPort,
+			AdminPort:   cfg.AdminPort,
+			AdminToken:  cfg.AdminToken,
+			MetricsPort: cfg.MetricsPort,
+			MetricsScan: cfg.MetricsBlockedKeysInterval.String(),
+			DecisionAPI: cfg.DecisionAPIPort,
+			ForwardAuth: cfg.ForwardAuthPort,
+			DecisionKey: cfg.DecisionAPIToken,
+			Traces:      cfg.TracesExporter,
+			LogLevel:    cfg.LogLevel.String(),
+			TokenSecret: cfg.TokenKeySecret,
+		},
+		Storage:    cfg.StorageBackend,
+		IP:         limitView{Limit: cfg.IPLimit, Window: cfg.IPWindow.String(), BlockTime: cfg.IPBlockTime.String()},
+		Tokens:     make(map[string]limitView, len(cfg.TokenConfigs)),
+		PolicyFile: cfg.PolicyFile,
+		Allow:      newAccessListView(cfg.AllowList),
+		Deny:       newAccessListView(cfg.DenyList),
+		Warnings:   cfg.Warnings,
+	}
+
+	if cfg.StorageBackend == "redis" {
+		view.Redis = &redisView{
+			Addrs:        cfg.GetRedisAddrs(),
+			ShardAddrs:   cfg.RedisShardAddrs,
+			MasterName:   cfg.RedisMasterName,
+			ClusterMode:  cfg.RedisClusterMode,
+			Username:     cfg.RedisUsername,
+			Password:     cfg.RedisPassword,
+			DB:           cfg.RedisDB,
+			TLSEnabled:   cfg.RedisTLSEnabled,
+			PoolSize:     cfg.RedisPoolSize,
+			MinIdleConns: cfg.RedisMinIdleConns,
+			DialTimeout:  cfg.RedisDialTimeout.String(),
+			ReadTimeout:  cfg.RedisReadTimeout.String(),
+			WriteTimeout: cfg.RedisWriteTimeout.String(),
+		}
+		if len(cfg.RedisShardAddrs) > 0 {
+			view.Redis.Addrs = nil
+			view.Redis.ShardHealth = cfg.RedisShardHealthInterval.String()
+			view.Redis.ShardFailureMax = cfg.RedisShardFailureThreshold
+		}
+	}
+
+	view.Unknown.Policy = cfg.UnknownTokenPolicy
+	if cfg.UnknownTokenPolicy == "limit" {
+		view.Unknown.Limit = &limitView{
+			Limit:     cfg.UnknownTokenLimit,
+			Window:    cfg.UnknownTokenWindow.String(),
+			BlockTime: cfg.UnknownTokenBlockTime.String(),
+		}
+	}
+	if cfg.InvalidTokenMaxDistinct > 0 {
+		view.Unknown.BruteForce = &bruteForceView{
+			MaxDistinct: cfg.InvalidTokenMaxDistinct,
+			Window:      cfg.InvalidTokenWindow.String(),
+			BlockTime:   cfg.InvalidTokenBlockTime.String(),
+		}
+	}
+
+	if escalation := cfg.BlockEscalation(); escalation.Enabled() {
+		view.Escalation = &escalationView{
+			Factor:       escalation.Factor,
+			MaxBlockTime: escalation.MaxBlockTime.String(),
+			Decay:        escalation.Decay.String(),
+		}
+	}
+
+	if cfg.QueueEnabled() {
+		view.Queue = &queueView{MaxDelay: cfg.QueueMaxDelay.String(), MaxPerKey: cfg.QueueMaxPerKey}
+	}
+
+	switch cfg.AuditLog {
+	case "redis":
+		view.Audit = &auditView{Destination: cfg.AuditLog, Stream: cfg.AuditLogStream, MaxLen: cfg.AuditLogMaxLen}
+	case "file":
+		view.Audit = &auditView{Destination: cfg.AuditLog, File: cfg.AuditLogFile}
+	}
+
+	if cfg.EventWebhookURL != "" || cfg.EventRedisChannel != "" || cfg.EventLog {
+		view.Events = &eventsView{RedisChannel: cfg.EventRedisChannel, Log: cfg.EventLog}
+		if cfg.EventWebhookURL != "" {
+			view.Events.Webhook = &webhookView{
+				URL:         cfg.EventWebhookURL,
+				Secret:      cfg.EventWebhookSecret,
+				QueueSize:   cfg.EventWebhookQueueSize,
+				MaxAttempts: cfg.EventWebhookMaxAttempts,
+				Timeout:     cfg.EventWebhookTimeout.String(),
+				Backoff:     cfg.EventWebhookBackoff.String(),
+			}
+		}
+	}
+
+	if cfg.RLSPort > 0 {
+		view.RLS = &rlsView{
+			Port:   cfg.RLSPort,
+			Domain: cfg.RLSDomain,
+			Descriptors: map[string]string{
+				"ip":     cfg.RLSDescriptorIP,
+				"token":  cfg.RLSDescriptorToken,
+				"method": cfg.RLSDescriptorMethod,
+				"path":   cfg.RLSDescriptorPath,
+			},
+		}
+	}
+
+	if cfg.ProxyEnabled() {
+		view.Proxy = &proxyView{
+			DefaultUpstream: cfg.UpstreamURL,
+			PreserveHost:    cfg.UpstreamPreserveHost,
+			DialTimeout:     cfg.UpstreamDialTimeout.String(),
+			ResponseTimeout: cfg.UpstreamResponseTimeout.String(),
+		}
+		for _, route := range cfg.UpstreamRoutes {
+			view.Proxy.Routes = append(view.Proxy.Routes, upstreamView{Path: route.Path, URL: route.URL})
+		}
+	}
+
+	for token, tokenCfg := range cfg.TokenConfigs {
+		view.Tokens[token] = limitView{Limit: tokenCfg.Limit, Window: tokenCfg.Window.String(), BlockTime: tokenCfg.BlockTime.String()}
+	}
+
+	if cfg.TokenConfigSource != "" {
+		view.TokenSource = &tokenSourceView{
+			Source:         cfg.TokenConfigSource,
+			File:           cfg.TokenConfigFile,
+			ResyncInterval: cfg.TokenConfigResyncInterval.String(),
+		}
+	}
+
+	for _, route := range cfg.Routes {
+		view.Routes = append(view.Routes, routeView{
+			Name:      route.Name,
+			Path:      route.Path,
+			Methods:   route.Methods,
+			limitView: limitView{Limit: route.Limit, Window: route.Window.String(), BlockTime: route.BlockTime.String()},
+		})
+	}
+
+	return view
+}
+
+// newAccessListView converte a lista para strings ordenadas
+func newAccessListView(list config.AccessList) accessListView {
+	view := accessListView{IPs: []string{}, Tokens: []string{}}
+	for _, prefix := range list.IPs {
+		view.IPs = append(view.IPs, prefix.String())
+	}
+	view.Tokens = append(view.Tokens, list.Tokens...)
+	sort.Strings(view.Tokens)
+	return view
+}
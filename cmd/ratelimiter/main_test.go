@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/config"
+)
+
+func TestRun_ConfigValidate_ValidConfig(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("IP_RATE_LIMIT", "10")
+	t.Setenv("IP_RATE_WINDOW", "1s")
+	t.Setenv("TOKEN_KEY_SECRET", "0123456789abcdef0123456789abcdef")
+	t.Setenv("TOKEN_PARTNER", "partner-key")
+	t.Setenv("TOKEN_PARTNER_WINDOW", "1s")
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"config", "validate"}, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout.String(), "configuration is valid: 0 token(s), 0 route(s), 1 warning(s)")
+	assert.Contains(t, stderr.String(), "warning: TOKEN_PARTNER skipped")
+}
+
+func TestRun_ConfigValidate_ReportsAllProblems(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "")
+	t.Setenv("REDIS_HOST", "")
+	t.Setenv("IP_RATE_LIMIT", "")
+	t.Setenv("IP_RATE_WINDOW", "1s")
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"config", "validate"}, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitInvalid, code)
+	assert.Contains(t, stderr.String(), "error: SERVER_PORT is required")
+	assert.Contains(t, stderr.String(), "error: REDIS_HOST, REDIS_ADDRS or REDIS_SHARD_ADDRS is required")
+	assert.Contains(t, stderr.String(), "error: IP_RATE_LIMIT must be positive")
+	assert.Contains(t, stderr.String(), "configuration is invalid: 3 problem(s)")
+}
+
+func TestRun_ConfigPrint_RedactsSecrets(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("ADMIN_PORT", "9090")
+	t.Setenv("ADMIN_TOKEN", "admin-s3cret")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("REDIS_PASSWORD", "redis-s3cret")
+	t.Setenv("IP_RATE_LIMIT", "10")
+	t.Setenv("IP_RATE_WINDOW", "1s")
+	t.Setenv("TOKEN_PARTNER", "partner-key")
+	t.Setenv("TOKEN_PARTNER_LIMIT", "100")
+	t.Setenv("TOKEN_PARTNER_WINDOW", "1s")
+	t.Setenv("TOKEN_KEY_SECRET", "hmac-s3cret-0123456789abcdef0123")
+	t.Setenv("EVENT_WEBHOOK_URL", "https://abuse.example.com/hooks")
+	t.Setenv("EVENT_WEBHOOK_SECRET", "webhook-s3cret")
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"config", "print"}, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	out := stdout.String()
+	assert.NotContains(t, out, "admin-s3cret")
+	assert.NotContains(t, out, "redis-s3cret")
+	assert.NotContains(t, out, "partner-key")
+	assert.NotContains(t, out, "hmac-s3cret")
+	assert.NotContains(t, out, "webhook-s3cret")
+	assert.Contains(t, out, "https://abuse.example.com/hooks")
+	assert.Contains(t, out, config.RedactedValue)
+	assert.Contains(t, out, config.RedactToken("partner-key"))
+	assert.Contains(t, out, `"window": "1s"`)
+}
+
+func TestRun_InvalidUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, exitUsage, run([]string{"config"}, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"config", "explode"}, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"keys", "explode"}, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"explode", "validate"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Usage:")
+}
+
+func TestRun_KeysMigrate_RequiresTokenKeySecret(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("IP_RATE_LIMIT", "10")
+	t.Setenv("IP_RATE_WINDOW", "1s")
+	t.Setenv("TOKEN_KEY_SECRET", "")
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"keys", "migrate", "-dry-run"}, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitInvalid, code)
+	assert.Contains(t, stderr.String(), "error: TOKEN_KEY_SECRET is required")
+}
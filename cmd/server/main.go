@@ -2,29 +2,67 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/audit"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/events"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/grpc/rls"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/handler"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/proxy"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/metrics"
+	fileAdapter "github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/file"
+	memoryAdapter "github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
 	redisAdapter "github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/redis"
+	shardedAdapter "github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/sharded"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/tracing"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/config"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/logger"
 	infraRedis "github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/redis"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/telemetry"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/tokenconfig"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/detect_token_abuse"
 	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
 )
 
 // configAdapter adapta config.Config para implementar middleware.Config
+// Tokens gerenciados em runtime (cache) têm prioridade sobre os definidos por env
 type configAdapter struct {
 	*config.Config
+	tokens *tokenconfig.Cache
+	hasher entity.TokenHasher
+}
+
+// TokenKey aplica o HMAC de TOKEN_KEY_SECRET (fixado na inicialização) à API key
+func (c *configAdapter) TokenKey(apiKey string) entity.LimiterKey {
+	return c.hasher.Key(apiKey)
 }
 
 func (c *configAdapter) GetTokenConfig(token string) (middleware.TokenConfig, bool) {
+	if c.tokens != nil {
+		if cfg, exists := c.tokens.Lookup(token); exists {
+			return middleware.TokenConfig{
+				Limit:     cfg.Limit,
+				Window:    cfg.Window,
+				BlockTime: cfg.BlockTime,
+			}, true
+		}
+	}
+
 	cfg, exists := c.Config.GetTokenConfig(token)
 	if !exists {
 		return middleware.TokenConfig{}, false
@@ -36,9 +74,226 @@ func (c *configAdapter) GetTokenConfig(token string) (middleware.TokenConfig, bo
 	}, true
 }
 
+func (c *configAdapter) GetRouteConfig(method, path string) (middleware.RouteConfig, bool) {
+	route, exists := c.Config.MatchRoute(method, path)
+	if !exists {
+		return middleware.RouteConfig{}, false
+	}
+	return middleware.RouteConfig{
+		Name:      route.Name,
+		Limit:     route.Limit,
+		Window:    route.Window,
+		BlockTime: route.BlockTime,
+	}, true
+}
+
+// GetUnknownTokenPolicy monta a política de API keys desconhecidas (UNKNOWN_TOKEN_* e INVALID_TOKEN_*)
+func (c *configAdapter) GetUnknownTokenPolicy() middleware.UnknownTokenPolicy {
+	return middleware.UnknownTokenPolicy{
+		Mode:           middleware.UnknownTokenMode(c.Config.UnknownTokenPolicy),
+		Limit:          c.Config.UnknownTokenLimit,
+		Window:         c.Config.UnknownTokenWindow,
+		BlockTime:      c.Config.UnknownTokenBlockTime,
+		MaxDistinct:    c.Config.InvalidTokenMaxDistinct,
+		DistinctWindow: c.Config.InvalidTokenWindow,
+		AbuseBlockTime: c.Config.InvalidTokenBlockTime,
+	}
+}
+
+// CheckAccess consulta as listas do arquivo de política (deny tem prioridade)
+func (c *configAdapter) CheckAccess(ip, apiKey string) middleware.Access {
+	switch {
+	case c.Config.IsDenied(ip, apiKey):
+		return middleware.AccessDeny
+	case c.Config.IsAllowed(ip, apiKey):
+		return middleware.AccessAllow
+	default:
+		return middleware.AccessDefault
+	}
+}
+
+// reloadableConfig expõe a configuração atual do Reloader para o middleware
+// Cada requisição usa um snapshot, então um reload não afeta requisições em andamento
+// O segredo do HMAC não acompanha o reload: trocá-lo descartaria os buckets existentes
+type reloadableConfig struct {
+	reloader *config.Reloader
+	tokens   *tokenconfig.Cache
+	hasher   entity.TokenHasher
+}
+
+func (c *reloadableConfig) Snapshot() middleware.Config {
+	return &configAdapter{Config: c.reloader.Current(), tokens: c.tokens, hasher: c.hasher}
+}
+
+func (c *reloadableConfig) GetIPLimit() int {
+	return c.Snapshot().GetIPLimit()
+}
+
+func (c *reloadableConfig) GetIPWindow() time.Duration {
+	return c.Snapshot().GetIPWindow()
+}
+
+func (c *reloadableConfig) GetIPBlockTime() time.Duration {
+	return c.Snapshot().GetIPBlockTime()
+}
+
+func (c *reloadableConfig) GetTokenConfig(token string) (middleware.TokenConfig, bool) {
+	return c.Snapshot().GetTokenConfig(token)
+}
+
+func (c *reloadableConfig) GetRouteConfig(method, path string) (middleware.RouteConfig, bool) {
+	return c.Snapshot().GetRouteConfig(method, path)
+}
+
+func (c *reloadableConfig) CheckAccess(ip, apiKey string) middleware.Access {
+	return c.Snapshot().CheckAccess(ip, apiKey)
+}
+
+func (c *reloadableConfig) GetUnknownTokenPolicy() middleware.UnknownTokenPolicy {
+	return c.Snapshot().GetUnknownTokenPolicy()
+}
+
+func (c *reloadableConfig) TokenKey(apiKey string) entity.LimiterKey {
+	return c.hasher.Key(apiKey)
+}
+
+// newStorage cria o storage conforme a configuração:
+// memória (STORAGE_BACKEND=memory), sharding entre nós Redis independentes (REDIS_SHARD_ADDRS)
+// ou um único client Redis (nó, Sentinel ou Cluster)
+func newStorage(cfg *config.Config) (repository.Storage, error) {
+	if cfg.StorageBackend == "memory" {
+		return memoryAdapter.NewMemoryStorage(), nil
+	}
+
+	if len(cfg.RedisShardAddrs) == 0 {
+		redisClient, err := infraRedis.NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return redisAdapter.NewRedisStorage(redisClient), nil
+	}
+
+	clients, err := infraRedis.NewShardClients(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]shardedAdapter.Shard, 0, len(clients))
+	for addr, client := range clients {
+		shards = append(shards, shardedAdapter.Shard{
+			Name:    addr,
+			Storage: redisAdapter.NewRedisStorage(client),
+		})
+	}
+	return shardedAdapter.NewShardedStorage(shards, cfg.RedisShardFailureThreshold)
+}
+
+// newAuditLog cria a trilha de auditoria de bloqueios/desbloqueios (AUDIT_LOG)
+// Retorna nil quando o recurso está desabilitado
+func newAuditLog(cfg *config.Config) (repository.AuditLog, error) {
+	switch cfg.AuditLog {
+	case "redis":
+		// Client dedicado: no modo sharded o stream fica em REDIS_HOST
+		redisClient, err := infraRedis.NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return redisAdapter.NewRedisAuditLog(redisClient, cfg.AuditLogStream, cfg.AuditLogMaxLen), nil
+	case "file":
+		return fileAdapter.NewAuditLog(cfg.AuditLogFile)
+	default:
+		return nil, nil
+	}
+}
+
+// newEventPublishers cria os sinks de notificação de bloqueios (EVENT_*)
+// O webhook é retornado separado porque precisa ser iniciado e drenado no shutdown
+func newEventPublishers(cfg *config.Config, logger *slog.Logger) (events.MultiPublisher, *events.WebhookPublisher, error) {
+	var (
+		publishers events.MultiPublisher
+		webhook    *events.WebhookPublisher
+	)
+
+	if cfg.EventWebhookURL != "" {
+		webhook = events.NewWebhookPublisher(events.WebhookConfig{
+			URL:         cfg.EventWebhookURL,
+			Secret:      cfg.EventWebhookSecret,
+			QueueSize:   cfg.EventWebhookQueueSize,
+			MaxAttempts: cfg.EventWebhookMaxAttempts,
+			Timeout:     cfg.EventWebhookTimeout,
+			Backoff:     cfg.EventWebhookBackoff,
+		}, logger)
+		publishers = append(publishers, webhook)
+	}
+	if cfg.EventRedisChannel != "" {
+		// Client dedicado: no modo sharded o canal fica em REDIS_HOST
+		redisClient, err := infraRedis.NewClient(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		publishers = append(publishers, events.NewRedisPublisher(redisClient, cfg.EventRedisChannel))
+	}
+	if cfg.EventLog {
+		publishers = append(publishers, events.NewLogPublisher(logger))
+	}
+	return publishers, webhook, nil
+}
+
+// newTokenConfigStore cria o store de configurações de token em runtime (TOKEN_CONFIG_SOURCE)
+// Retorna nil quando o recurso está desabilitado
+func newTokenConfigStore(cfg *config.Config) (repository.TokenConfigStore, error) {
+	switch cfg.TokenConfigSource {
+	case "redis":
+		// Client dedicado: no modo sharded as configurações ficam em REDIS_HOST
+		redisClient, err := infraRedis.NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return redisAdapter.NewRedisTokenConfigStore(redisClient).WithTokenHasher(cfg.TokenHasher()), nil
+	case "file":
+		return fileAdapter.NewTokenConfigStore(cfg.TokenConfigFile), nil
+	default:
+		return nil, nil
+	}
+}
+
+// newReverseProxy monta o proxy a partir de UPSTREAM_URL e UPSTREAM_ROUTES (já validadas no config.Load)
+func newReverseProxy(cfg *config.Config, logger *slog.Logger) *proxy.ReverseProxy {
+	var defaultTarget *url.URL
+	if cfg.UpstreamURL != "" {
+		defaultTarget, _ = url.Parse(cfg.UpstreamURL)
+	}
+
+	reverseProxy := proxy.NewReverseProxy(defaultTarget, logger).
+		WithPreserveHost(cfg.UpstreamPreserveHost).
+		WithTimeouts(cfg.UpstreamDialTimeout, cfg.UpstreamResponseTimeout)
+	for _, route := range cfg.UpstreamRoutes {
+		target, _ := url.Parse(route.URL)
+		reverseProxy.WithRoute(route.Path, target)
+	}
+	return reverseProxy
+}
+
+// shutdownGRPC aguarda as chamadas em andamento (GracefulStop) até o fim do contexto
+// e então encerra as restantes
+func shutdownGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
 func main() {
 	// 1. Setup logger
-	logger := logger.New()
+	logLevel := new(slog.LevelVar)
+	logger := logger.New(logLevel)
 	logger.Info("Starting Rate Limiter")
 
 	// 2. Carrega configuração
@@ -47,61 +302,193 @@ func main() {
 		logger.Error("Failed to load config", "error", err)
 		os.Exit(1)
 	}
+	logLevel.Set(cfg.LogLevel)
+	for _, warning := range cfg.Warnings {
+		logger.Warn("Configuration warning", "warning", warning)
+	}
+	redisAddrs := cfg.GetRedisAddrs()
+	if len(cfg.RedisShardAddrs) > 0 {
+		redisAddrs = cfg.RedisShardAddrs
+	}
 	logger.Info("Configuration loaded",
 		"port", cfg.ServerPort,
-		"redis", fmt.Sprintf("%s:%d", cfg.RedisHost, cfg.RedisPort),
+		"redis", strings.Join(redisAddrs, ","),
 		"ip_limit", cfg.IPLimit,
 		"tokens_configured", len(cfg.TokenConfigs),
+		"routes_configured", len(cfg.Routes),
+		"policy_file", cfg.PolicyFile,
 	)
 
-	// 3. Conecta Redis
-	redisClient, err := infraRedis.NewClient(cfg)
+	// Tracing OpenTelemetry (OTEL_TRACES_EXPORTER) e propagação W3C
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.TracesExporter, os.Stdout)
+	if err != nil {
+		logger.Error("Failed to setup tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", "error", err)
+		}
+	}()
+	logger.Info("Tracing initialized", "exporter", cfg.TracesExporter)
+
+	// 3. Conecta Redis e monta o storage
+	storage, err := newStorage(cfg)
 	if err != nil {
 		logger.Error("Failed to connect to Redis", "error", err)
 		os.Exit(1)
 	}
-	defer redisClient.Close()
-	logger.Info("Connected to Redis")
+	defer storage.Close()
+	logger.Info("Storage connected", "backend", cfg.StorageBackend, "shards", len(cfg.RedisShardAddrs))
 
 	// 4. Monta camadas (Dependency Injection)
 
 	// Storage layer
-	storage := redisAdapter.NewRedisStorage(redisClient)
+	if sharded, ok := storage.(*shardedAdapter.ShardedStorage); ok {
+		healthCtx, stopHealthCheck := context.WithCancel(context.Background())
+		defer stopHealthCheck()
+		sharded.StartHealthCheck(healthCtx, cfg.RedisShardHealthInterval)
+	}
+
+	// Métricas: decorators em volta do storage e do use case
+	rateLimiterMetrics := metrics.New()
+	storage = rateLimiterMetrics.InstrumentStorage(storage)
+
+	// Auditoria: decorator que grava bloqueios e desbloqueios (depois das métricas)
+	auditLog, err := newAuditLog(cfg)
+	if err != nil {
+		logger.Error("Failed to create audit log", "error", err)
+		os.Exit(1)
+	}
+	if auditLog != nil {
+		if closer, ok := auditLog.(io.Closer); ok {
+			defer closer.Close()
+		}
+		storage = audit.NewAuditedStorage(storage, auditLog, logger)
+		logger.Info("Audit log initialized", "destination", cfg.AuditLog)
+	}
 	logger.Info("Storage layer initialized")
 
+	// Token configs em runtime
+	tokenStore, err := newTokenConfigStore(cfg)
+	if err != nil {
+		logger.Error("Failed to create token config store", "error", err)
+		os.Exit(1)
+	}
+	var tokenCache *tokenconfig.Cache
+	if tokenStore != nil {
+		if closer, ok := tokenStore.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		tokenCtx, stopTokenCache := context.WithCancel(context.Background())
+		defer stopTokenCache()
+
+		tokenCache = tokenconfig.NewCache(tokenStore, cfg.TokenConfigResyncInterval, logger).WithTokenHasher(cfg.TokenHasher())
+		if err := tokenCache.Start(tokenCtx); err != nil {
+			logger.Error("Failed to load token configs", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Token config store initialized", "source", cfg.TokenConfigSource, "tokens", tokenCache.Len())
+	}
+
+	// Notificações de bloqueio/desbloqueio (webhook, Redis pub/sub, log)
+	publishers, webhook, err := newEventPublishers(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to create event publishers", "error", err)
+		os.Exit(1)
+	}
+	var eventPublisher repository.EventPublisher
+	if len(publishers) > 0 {
+		eventPublisher = publishers
+		for _, publisher := range publishers {
+			if closer, ok := publisher.(io.Closer); ok {
+				defer closer.Close()
+			}
+		}
+		logger.Info("Event publishers initialized", "sinks", len(publishers))
+	}
+	webhookCtx, stopWebhook := context.WithCancel(context.Background())
+	defer stopWebhook()
+	if webhook != nil {
+		webhook.Start(webhookCtx)
+	}
+
 	// Use case layer
-	checkRateLimitUC := check_rate_limit.NewUseCase(storage)
+	// BLOCK_ESCALATION_* e QUEUE_* são fixados na inicialização (o use case é criado uma vez)
+	baseUC := check_rate_limit.NewUseCase(storage, logger).
+		WithBlockEscalation(cfg.BlockEscalation()).
+		WithEventPublisher(eventPublisher)
+	checkRateLimitUC := rateLimiterMetrics.InstrumentUseCase(tracing.NewTracedUseCase(baseUC))
+
+	// Só o middleware HTTP enfileira: API de decisão, forward auth e gRPC respondem na hora
+	middlewareUC := checkRateLimitUC
+	if cfg.QueueEnabled() {
+		middlewareUC = rateLimiterMetrics.InstrumentUseCase(tracing.NewTracedUseCase(
+			check_rate_limit.NewQueue(baseUC, cfg.QueueMaxDelay, cfg.QueueMaxPerKey),
+		))
+		logger.Info("Request queueing enabled", "max_delay", cfg.QueueMaxDelay, "max_per_key", cfg.QueueMaxPerKey)
+	}
 	logger.Info("Use case layer initialized")
 
+	// Hot reload do .env e do arquivo de política (fsnotify + SIGHUP)
+	reloader := config.NewReloader(cfg, config.Load, logger).WithLogLevel(logLevel)
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	if err := reloader.Watch(reloadCtx); err != nil {
+		logger.Warn("Config hot reload disabled", "error", err)
+	}
+	rateLimiterMetrics.RegisterConfigReloads(func() metrics.ReloadStats {
+		stats := reloader.Stats()
+		return metrics.ReloadStats{Successes: stats.Successes, Failures: stats.Failures}
+	})
+
 	// Middleware layer
-	cfgAdapter := &configAdapter{Config: cfg}
-	rateLimiterMW := middleware.NewRateLimiterMiddleware(checkRateLimitUC, cfgAdapter)
+	cfgAdapter := &reloadableConfig{reloader: reloader, tokens: tokenCache, hasher: cfg.TokenHasher()}
+	// A detecção de força bruta segue INVALID_TOKEN_MAX_DISTINCT a cada requisição (recarregável)
+	tokenAbuseUC := detect_token_abuse.NewUseCase(storage, logger).WithEventPublisher(eventPublisher)
+	rateLimiterMW := middleware.NewRateLimiterMiddleware(middlewareUC, cfgAdapter, logger).
+		WithTokenAbuseDetector(tokenAbuseUC)
 	logger.Info("Middleware layer initialized")
 
 	// 5. Setup HTTP Router
-	r := chi.NewRouter()
+	router := chi.NewRouter()
 
-	// Aplica rate limiter globalmente
-	r.Use(rateLimiterMW.Handle)
-
-	// Rotas
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+	// Health check local e sem rate limiting nos dois modos (usado pelo HEALTHCHECK do container)
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Rate Limiter is running"))
-	})
+	// Aplica rate limiter em todas as demais rotas
+	r := router.With(rateLimiterMW.Handle)
 
 	// 6. HTTP Server
 	srv := &http.Server{
-		Addr:         ":" + strconv.Itoa(cfg.ServerPort),
-		Handler:      r,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:        ":" + strconv.Itoa(cfg.ServerPort),
+		Handler:     router,
+		IdleTimeout: 60 * time.Second,
+	}
+
+	if cfg.ProxyEnabled() {
+		// Modo proxy reverso: todas as requisições liberadas são encaminhadas ao upstream
+		r.Handle("/*", newReverseProxy(cfg, logger))
+
+		// Sem ReadTimeout/WriteTimeout: cortariam uploads, streaming e websockets;
+		// o tempo de resposta do upstream é limitado por UPSTREAM_RESPONSE_TIMEOUT
+		srv.ReadHeaderTimeout = 10 * time.Second
+		logger.Info("Reverse proxy mode enabled", "default_upstream", cfg.Redacted().UpstreamURL, "routes", len(cfg.UpstreamRoutes))
+	} else {
+		// Rotas
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Rate Limiter is running"))
+		})
+
+		srv.ReadTimeout = 10 * time.Second
+		srv.WriteTimeout = 10 * time.Second
 	}
 
 	// 7. Start server em goroutine
@@ -113,7 +500,122 @@ func main() {
 		}
 	}()
 
-	// 8. Graceful shutdown
+	// 8. Admin API em porta separada (sem rate limiting)
+	var adminSrv *http.Server
+	if cfg.AdminPort > 0 {
+		adminHandler := handler.NewAdminHandler(storage, tokenStore, auditLog, cfg.AdminToken, cfg.TokenHasher(), logger).
+			WithEventPublisher(eventPublisher)
+		adminSrv = &http.Server{
+			Addr:         ":" + strconv.Itoa(cfg.AdminPort),
+			Handler:      adminHandler.Routes(),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Admin server starting", "port", cfg.AdminPort)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Admin server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// 9. API de decisão (POST /v1/check) em porta separada: o rate limiter como serviço central/sidecar
+	var decisionSrv *http.Server
+	if cfg.DecisionAPIPort > 0 {
+		decisionHandler := handler.NewDecisionHandler(checkRateLimitUC, storage, cfgAdapter, cfg.DecisionAPIToken, logger)
+		decisionSrv = &http.Server{
+			Addr:         ":" + strconv.Itoa(cfg.DecisionAPIPort),
+			Handler:      decisionHandler.Routes(),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Decision API starting", "port", cfg.DecisionAPIPort)
+			if err := decisionSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Decision API error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// 10. Forward auth (nginx auth_request / Traefik forwardAuth) em porta separada
+	var forwardAuthSrv *http.Server
+	if cfg.ForwardAuthPort > 0 {
+		forwardAuthHandler := handler.NewForwardAuthHandler(checkRateLimitUC, storage, cfgAdapter, logger)
+		forwardAuthSrv = &http.Server{
+			Addr:         ":" + strconv.Itoa(cfg.ForwardAuthPort),
+			Handler:      forwardAuthHandler.Routes(),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Forward auth starting", "port", cfg.ForwardAuthPort)
+			if err := forwardAuthSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Forward auth error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// 11. Rate Limit Service do Envoy (gRPC) em porta separada
+	var rlsSrv *grpc.Server
+	if cfg.RLSPort > 0 {
+		listener, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.RLSPort))
+		if err != nil {
+			logger.Error("Failed to listen for Envoy RLS", "port", cfg.RLSPort, "error", err)
+			os.Exit(1)
+		}
+		rlsSrv = grpc.NewServer()
+		rls.NewService(checkRateLimitUC, storage, cfgAdapter, rls.DescriptorKeys{
+			IP:     cfg.RLSDescriptorIP,
+			Token:  cfg.RLSDescriptorToken,
+			Method: cfg.RLSDescriptorMethod,
+			Path:   cfg.RLSDescriptorPath,
+		}, logger).WithDomain(cfg.RLSDomain).Register(rlsSrv)
+
+		go func() {
+			logger.Info("Envoy RLS starting", "port", cfg.RLSPort, "domain", cfg.RLSDomain)
+			if err := rlsSrv.Serve(listener); err != nil {
+				logger.Error("Envoy RLS error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// 12. Métricas Prometheus em porta separada (sem rate limiting)
+	var metricsSrv *http.Server
+	if cfg.MetricsPort > 0 {
+		refreshCtx, stopRefresh := context.WithCancel(context.Background())
+		defer stopRefresh()
+		rateLimiterMetrics.StartBlockedKeysRefresh(refreshCtx, cfg.MetricsBlockedKeysInterval)
+
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", rateLimiterMetrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:         ":" + strconv.Itoa(cfg.MetricsPort),
+			Handler:      metricsMux,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Metrics server starting", "port", cfg.MetricsPort)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Metrics server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// 13. Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -126,6 +628,35 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown", "error", err)
 	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			logger.Error("Admin server forced to shutdown", "error", err)
+		}
+	}
+	if decisionSrv != nil {
+		if err := decisionSrv.Shutdown(ctx); err != nil {
+			logger.Error("Decision API forced to shutdown", "error", err)
+		}
+	}
+	if forwardAuthSrv != nil {
+		if err := forwardAuthSrv.Shutdown(ctx); err != nil {
+			logger.Error("Forward auth forced to shutdown", "error", err)
+		}
+	}
+	if rlsSrv != nil {
+		shutdownGRPC(ctx, rlsSrv)
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			logger.Error("Metrics server forced to shutdown", "error", err)
+		}
+	}
+	// Entrega os eventos que ainda estão na fila do webhook
+	if webhook != nil {
+		if err := webhook.Close(ctx); err != nil {
+			logger.Error("Webhook events dropped on shutdown", "error", err)
+		}
+	}
 
 	logger.Info("Rate Limiter stopped")
 }
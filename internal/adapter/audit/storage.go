@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// actorKey é a chave do contexto que identifica quem executou a operação (ex: "admin:alice")
+type actorKey struct{}
+
+// WithActor registra no contexto quem está executando a operação
+// Usado nos eventos de desbloqueio, que não carregam BlockInfo
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext retorna quem está executando a operação ("" quando não informado)
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// AuditedStorage decora um repository.Storage gravando cada bloqueio e desbloqueio no audit log
+// As demais operações são repassadas sem alteração. Bloqueios que expiram pelo TTL não geram evento
+type AuditedStorage struct {
+	repository.Storage
+	log    repository.AuditLog
+	logger *slog.Logger
+}
+
+// NewAuditedStorage decora o storage
+// Falhas ao gravar no audit log são registradas no logger e não interrompem a operação:
+// o rate limiting não pode depender da disponibilidade da trilha de auditoria
+func NewAuditedStorage(storage repository.Storage, log repository.AuditLog, logger *slog.Logger) *AuditedStorage {
+	return &AuditedStorage{
+		Storage: storage,
+		log:     log,
+		logger:  logger,
+	}
+}
+
+// SetBlock implementa o método da interface Storage e grava o evento de bloqueio
+func (s *AuditedStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration, info entity.BlockInfo) error {
+	if err := s.Storage.SetBlock(ctx, key, blockTime, info); err != nil {
+		return err
+	}
+
+	s.append(ctx, entity.AuditEvent{
+		Action:   entity.AuditActionBlock,
+		Key:      key,
+		Time:     time.Now(),
+		Duration: blockTime,
+		Info:     info,
+		Actor:    ActorFromContext(ctx),
+	})
+	return nil
+}
+
+// Unblock implementa o método da interface Storage e grava o evento de desbloqueio
+func (s *AuditedStorage) Unblock(ctx context.Context, key entity.LimiterKey) error {
+	if err := s.Storage.Unblock(ctx, key); err != nil {
+		return err
+	}
+
+	s.append(ctx, entity.AuditEvent{
+		Action: entity.AuditActionUnblock,
+		Key:    key,
+		Time:   time.Now(),
+		Actor:  ActorFromContext(ctx),
+	})
+	return nil
+}
+
+// append grava o evento; erros são apenas registrados
+func (s *AuditedStorage) append(ctx context.Context, event entity.AuditEvent) {
+	if err := s.log.Append(ctx, event); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to append audit event",
+			"action", event.Action,
+			"key", event.Key,
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// fakeAuditLog guarda os eventos em memória e pode simular falha na escrita
+type fakeAuditLog struct {
+	events []entity.AuditEvent
+	err    error
+}
+
+func (f *fakeAuditLog) Append(ctx context.Context, event entity.AuditEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeAuditLog) Recent(ctx context.Context, limit int) ([]entity.AuditEvent, error) {
+	return f.events, nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestAuditedStorage_RecordsBlockAndUnblock(t *testing.T) {
+	// Arrange
+	log := &fakeAuditLog{}
+	storage := NewAuditedStorage(memory.NewMemoryStorage(), log, discardLogger())
+	key := entity.NewIPKey("10.0.0.1")
+	info := entity.BlockInfo{Reason: entity.BlockReasonRateExceeded, Source: entity.BlockSourceRateLimiter, Limit: 10}
+	ctx := context.Background()
+
+	// Act
+	require.NoError(t, storage.SetBlock(ctx, key, time.Minute, info))
+	require.NoError(t, storage.Unblock(WithActor(ctx, "admin:alice"), key))
+
+	// Assert
+	require.Len(t, log.events, 2)
+	assert.Equal(t, entity.AuditActionBlock, log.events[0].Action)
+	assert.Equal(t, key, log.events[0].Key)
+	assert.Equal(t, time.Minute, log.events[0].Duration)
+	assert.Equal(t, info, log.events[0].Info)
+	assert.Equal(t, entity.AuditActionUnblock, log.events[1].Action)
+	assert.Equal(t, "admin:alice", log.events[1].Actor)
+}
+
+func TestAuditedStorage_AuditFailure_DoesNotFailTheBlock(t *testing.T) {
+	// Arrange
+	storage := NewAuditedStorage(memory.NewMemoryStorage(), &fakeAuditLog{err: errors.New("disk full")}, discardLogger())
+	key := entity.NewIPKey("10.0.0.1")
+	ctx := context.Background()
+
+	// Act
+	err := storage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{})
+
+	// Assert
+	require.NoError(t, err)
+	blocked, err := storage.IsBlocked(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, blocked)
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// LogPublisher implementa repository.EventPublisher registrando cada evento no logger
+// Útil para coletores de log (ex: Loki, CloudWatch) que já alimentam os alertas
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+// NewLogPublisher cria o publisher sobre o logger informado
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+// Publish implementa o método da interface EventPublisher
+func (p *LogPublisher) Publish(ctx context.Context, event entity.AuditEvent) error {
+	payload := NewPayload(event)
+	p.logger.InfoContext(ctx, "Rate limiter event",
+		"event_id", payload.ID,
+		"type", payload.Type,
+		"key", payload.Key,
+		"duration_seconds", payload.DurationSeconds,
+		"reason", payload.Reason,
+		"source", payload.Source,
+		"policy", payload.Policy,
+		"limit", payload.Limit,
+		"strikes", payload.Strikes,
+		"actor", payload.Actor,
+	)
+	return nil
+}
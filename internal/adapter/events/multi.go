@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// MultiPublisher repassa cada evento para todos os sinks configurados
+type MultiPublisher []repository.EventPublisher
+
+// Publish implementa o método da interface EventPublisher
+// Todos os sinks recebem o evento mesmo que algum falhe; os erros são agrupados
+func (m MultiPublisher) Publish(ctx context.Context, event entity.AuditEvent) error {
+	var errs []error
+	for _, publisher := range m {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
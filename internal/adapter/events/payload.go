@@ -0,0 +1,75 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// Tipos de evento enviados aos sinks
+const (
+	TypeKeyBlocked   = "key.blocked"
+	TypeKeyUnblocked = "key.unblocked"
+)
+
+// Payload é a representação JSON de um evento, igual em todos os sinks
+// A chave é enviada já redigida (entity.LimiterKey.Redacted): tokens em texto puro nunca saem do processo
+type Payload struct {
+	ID              string    `json:"id"` // Único por evento: permite descartar entregas repetidas (retries)
+	Type            string    `json:"type"`
+	Time            time.Time `json:"time"`
+	Key             string    `json:"key"`
+	KeyType         string    `json:"key_type"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	Source          string    `json:"source,omitempty"`
+	Policy          string    `json:"policy,omitempty"`
+	Limit           int       `json:"limit,omitempty"`
+	Window          string    `json:"window,omitempty"`
+	Strikes         int       `json:"strikes,omitempty"`
+	Note            string    `json:"note,omitempty"`
+	Actor           string    `json:"actor,omitempty"`
+}
+
+// NewPayload converte o evento de domínio para o formato publicado
+func NewPayload(event entity.AuditEvent) Payload {
+	payload := Payload{
+		ID:              newEventID(),
+		Type:            TypeKeyBlocked,
+		Time:            event.Time.UTC(),
+		Key:             event.Key.Redacted(),
+		KeyType:         string(event.Key.Type),
+		DurationSeconds: event.Duration.Seconds(),
+		Reason:          string(event.Info.Reason),
+		Source:          event.Info.Source,
+		Policy:          event.Info.Policy,
+		Limit:           event.Info.Limit,
+		Strikes:         event.Info.Strikes,
+		Note:            event.Info.Note,
+		Actor:           event.Actor,
+	}
+	if event.Action == entity.AuditActionUnblock {
+		payload.Type = TypeKeyUnblocked
+	}
+	if event.Info.Window > 0 {
+		payload.Window = event.Info.Window.String()
+	}
+	return payload
+}
+
+// encodePayload serializa o evento para envio
+func encodePayload(event entity.AuditEvent) (Payload, []byte, error) {
+	payload := NewPayload(event)
+	body, err := json.Marshal(payload)
+	return payload, body, err
+}
+
+// newEventID gera um identificador aleatório de 128 bits
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// RedisPublisher implementa repository.EventPublisher via Redis pub/sub (PUBLISH do JSON do evento)
+// Pub/sub não guarda mensagens: só recebe quem estiver inscrito no momento (para histórico use AUDIT_LOG)
+type RedisPublisher struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// NewRedisPublisher cria o publisher sobre o canal informado
+func NewRedisPublisher(client redis.UniversalClient, channel string) *RedisPublisher {
+	return &RedisPublisher{
+		client:  client,
+		channel: channel,
+	}
+}
+
+// Close fecha a conexão com o Redis
+func (p *RedisPublisher) Close() error {
+	return p.client.Close()
+}
+
+// Publish implementa o método da interface EventPublisher
+func (p *RedisPublisher) Publish(ctx context.Context, event entity.AuditEvent) error {
+	_, body, err := encodePayload(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	if err := p.client.Publish(ctx, p.channel, body).Err(); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// Headers enviados em cada entrega do webhook
+const (
+	HeaderEvent     = "X-RateLimiter-Event"
+	HeaderTimestamp = "X-RateLimiter-Timestamp"
+	HeaderSignature = "X-RateLimiter-Signature"
+)
+
+// maxBackoff limita a espera entre tentativas
+const maxBackoff = 30 * time.Second
+
+var (
+	// ErrQueueFull é retornado quando a fila do webhook está cheia (o evento é descartado)
+	ErrQueueFull = errors.New("webhook queue is full")
+	// ErrPublisherClosed é retornado após Close
+	ErrPublisherClosed = errors.New("webhook publisher is closed")
+)
+
+// WebhookConfig configura o WebhookPublisher
+type WebhookConfig struct {
+	URL         string
+	Secret      string        // Chave do HMAC-SHA256 enviado em X-RateLimiter-Signature ("" não assina)
+	QueueSize   int           // Eventos aguardando entrega; com a fila cheia novos eventos são descartados
+	MaxAttempts int           // Tentativas por evento (1 = sem retry)
+	Timeout     time.Duration // Timeout de cada tentativa
+	Backoff     time.Duration // Espera antes do primeiro retry; dobra a cada tentativa (até 30s)
+}
+
+// delivery é um evento serializado aguardando entrega
+type delivery struct {
+	eventType string
+	body      []byte
+}
+
+// WebhookPublisher implementa repository.EventPublisher enviando cada evento via POST para uma URL
+// Publish apenas enfileira (não bloqueia a requisição); um worker entrega em ordem,
+// com retry e backoff exponencial para erros de rede, 408, 429 e 5xx
+type WebhookPublisher struct {
+	cfg    WebhookConfig
+	client *http.Client
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan delivery
+	done   chan struct{}
+}
+
+// NewWebhookPublisher cria o publisher; a entrega começa com Start
+func NewWebhookPublisher(cfg WebhookConfig, logger *slog.Logger) *WebhookPublisher {
+	return &WebhookPublisher{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: logger,
+		queue:  make(chan delivery, cfg.QueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Publish implementa o método da interface EventPublisher
+func (p *WebhookPublisher) Publish(ctx context.Context, event entity.AuditEvent) error {
+	payload, body, err := encodePayload(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPublisherClosed
+	}
+
+	select {
+	case p.queue <- delivery{eventType: payload.Type, body: body}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Start inicia o worker de entrega
+// Cancelar o contexto interrompe a entrega em andamento (inclusive a espera entre tentativas)
+func (p *WebhookPublisher) Start(ctx context.Context) {
+	go func() {
+		defer close(p.done)
+		for d := range p.queue {
+			p.deliver(ctx, d)
+		}
+	}()
+}
+
+// Close para de aceitar eventos e aguarda a entrega dos que estão na fila (até o fim do contexto)
+func (p *WebhookPublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook queue not drained: %w", ctx.Err())
+	}
+}
+
+// deliver envia o evento, repetindo enquanto o erro for temporário
+func (p *WebhookPublisher) deliver(ctx context.Context, d delivery) {
+	backoff := p.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := p.send(ctx, d)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= p.cfg.MaxAttempts {
+			p.logger.Error("Webhook delivery failed", "event", d.eventType, "attempts", attempt, "error", err)
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// send faz uma tentativa de entrega e informa se vale a pena repetir em caso de erro
+func (p *WebhookPublisher) send(ctx context.Context, d delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.eventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if p.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(p.cfg.Secret, timestamp, d.body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout,
+		resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+}
+
+// Sign calcula a assinatura enviada em X-RateLimiter-Signature: "sha256=" + HMAC-SHA256(secret, timestamp + "." + body)
+// O receptor deve recalcular e comparar em tempo constante, rejeitando timestamps antigos (replay)
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
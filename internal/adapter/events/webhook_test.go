@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+const testSecret = "webhook-s3cret"
+
+// receivedRequest é uma entrega recebida pelo servidor de teste
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver é um endpoint de webhook que responde com os status informados, em ordem
+// (o último status se repete nas requisições seguintes)
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	status := r.statuses[min(len(r.requests), len(r.statuses))-1]
+	r.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+// newTestPublisher cria o publisher apontando para o receiver (o worker é iniciado pelo teste)
+func newTestPublisher(t *testing.T, rcv *receiver, queueSize int) *WebhookPublisher {
+	server := httptest.NewServer(rcv)
+	t.Cleanup(server.Close)
+
+	return NewWebhookPublisher(WebhookConfig{
+		URL:         server.URL,
+		Secret:      testSecret,
+		QueueSize:   queueSize,
+		MaxAttempts: 3,
+		Timeout:     time.Second,
+		Backoff:     time.Millisecond,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// closePublisher drena a fila do publisher
+func closePublisher(t *testing.T, publisher *WebhookPublisher) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, publisher.Close(ctx))
+}
+
+func blockEvent(key entity.LimiterKey) entity.AuditEvent {
+	return entity.AuditEvent{
+		Action:   entity.AuditActionBlock,
+		Key:      key,
+		Time:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Duration: 10 * time.Minute,
+		Info: entity.BlockInfo{
+			Reason: entity.BlockReasonRateExceeded,
+			Source: entity.BlockSourceRateLimiter,
+			Policy: "token",
+			Limit:  100,
+			Window: time.Second,
+		},
+	}
+}
+
+func TestWebhookPublisher_DeliversSignedPayload(t *testing.T) {
+	// Arrange
+	rcv := &receiver{statuses: []int{http.StatusNoContent}}
+	publisher := newTestPublisher(t, rcv, 10)
+	publisher.Start(context.Background())
+
+	// Act
+	require.NoError(t, publisher.Publish(context.Background(), blockEvent(entity.NewTokenKey("partner-key"))))
+	closePublisher(t, publisher)
+
+	// Assert
+	requests := rcv.received()
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, TypeKeyBlocked, req.header.Get(HeaderEvent))
+	assert.Equal(t, Sign(testSecret, req.header.Get(HeaderTimestamp), req.body), req.header.Get(HeaderSignature))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	assert.NotEmpty(t, payload.ID)
+	assert.Equal(t, "token:"+entity.RedactToken("partner-key"), payload.Key)
+	assert.Equal(t, "token", payload.KeyType)
+	assert.Equal(t, 600.0, payload.DurationSeconds)
+	assert.Equal(t, "rate_exceeded", payload.Reason)
+	assert.Equal(t, 100, payload.Limit)
+	assert.Equal(t, "1s", payload.Window)
+	assert.NotContains(t, string(req.body), "partner-key")
+}
+
+func TestWebhookPublisher_RetriesTemporaryFailures(t *testing.T) {
+	// Arrange - falha duas vezes e depois aceita
+	rcv := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}}
+	publisher := newTestPublisher(t, rcv, 10)
+	publisher.Start(context.Background())
+
+	// Act
+	require.NoError(t, publisher.Publish(context.Background(), blockEvent(entity.NewIPKey("10.0.0.1"))))
+	closePublisher(t, publisher)
+
+	// Assert - o mesmo evento (mesmo id) é reenviado
+	requests := rcv.received()
+	require.Len(t, requests, 3)
+	assert.Equal(t, requests[0].body, requests[2].body)
+}
+
+func TestWebhookPublisher_StopsOnPermanentFailureOrMaxAttempts(t *testing.T) {
+	tests := map[string]struct {
+		status   int
+		expected int
+	}{
+		"client error is not retried": {status: http.StatusBadRequest, expected: 1},
+		"server error up to max":      {status: http.StatusInternalServerError, expected: 3},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			rcv := &receiver{statuses: []int{tt.status}}
+			publisher := newTestPublisher(t, rcv, 10)
+			publisher.Start(context.Background())
+
+			// Act
+			require.NoError(t, publisher.Publish(context.Background(), blockEvent(entity.NewIPKey("10.0.0.1"))))
+			closePublisher(t, publisher)
+
+			// Assert
+			assert.Len(t, rcv.received(), tt.expected)
+		})
+	}
+}
+
+func TestWebhookPublisher_FullQueue_DropsEvent(t *testing.T) {
+	// Arrange - worker não iniciado: a fila enche
+	rcv := &receiver{statuses: []int{http.StatusOK}}
+	publisher := newTestPublisher(t, rcv, 1)
+	event := blockEvent(entity.NewIPKey("10.0.0.1"))
+
+	// Act
+	first := publisher.Publish(context.Background(), event)
+	second := publisher.Publish(context.Background(), event)
+
+	// Assert
+	assert.NoError(t, first)
+	assert.ErrorIs(t, second, ErrQueueFull)
+
+	// Assert - Close entrega o que está na fila e rejeita novos eventos
+	publisher.Start(context.Background())
+	closePublisher(t, publisher)
+	assert.Len(t, rcv.received(), 1)
+	assert.ErrorIs(t, publisher.Publish(context.Background(), event), ErrPublisherClosed)
+}
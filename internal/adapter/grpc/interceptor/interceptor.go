@@ -0,0 +1,178 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// DefaultAPIKeyMetadata é a chave de metadata com a API key do cliente
+const DefaultAPIKeyMetadata = "api-key"
+
+// Trailers de rate limit enviados em toda chamada verificada
+const (
+	trailerRateLimitLimit     = "x-ratelimit-limit"
+	trailerRateLimitRemaining = "x-ratelimit-remaining"
+	trailerRetryAfter         = "retry-after"
+)
+
+// RateLimiter aplica o rate limiting em servidores gRPC (interceptors unary e stream)
+// As políticas são as mesmas do middleware HTTP (Rota > Token > IP): o método completo
+// ("/pkg.Service/Method") é casado com as rotas como um POST, então limites por método são
+// configurados em RATE_LIMIT_ROUTES (ex: "POST /orders.OrderService/*")
+type RateLimiter struct {
+	useCase     middleware.UseCase
+	storage     repository.Storage
+	config      middleware.Config
+	metadataKey string
+	logger      *slog.Logger
+}
+
+// NewRateLimiter cria os interceptors
+// useCase é o mesmo usado pelo middleware (métricas, tracing e eventos incluídos)
+// storage é consultado apenas para informar quanto tempo resta de um bloqueio já existente
+// config resolve as políticas ip, token e route (com snapshot por chamada quando recarregável)
+func NewRateLimiter(useCase middleware.UseCase, storage repository.Storage, config middleware.Config, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		useCase:     useCase,
+		storage:     storage,
+		config:      config,
+		metadataKey: DefaultAPIKeyMetadata,
+		logger:      logger,
+	}
+}
+
+// WithAPIKeyMetadata define a chave de metadata com a API key (padrão "api-key")
+func (l *RateLimiter) WithAPIKeyMetadata(key string) *RateLimiter {
+	l.metadataKey = key
+	return l
+}
+
+// Unary retorna o interceptor de chamadas unary
+func (l *RateLimiter) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		trailer, err := l.check(ctx, info.FullMethod)
+		if len(trailer) > 0 {
+			_ = grpc.SetTrailer(ctx, trailer)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream retorna o interceptor de streams
+// A verificação é feita uma vez, na abertura do stream (cada stream consome um token)
+func (l *RateLimiter) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		trailer, err := l.check(ss.Context(), info.FullMethod)
+		if len(trailer) > 0 {
+			ss.SetTrailer(trailer)
+		}
+		if err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// check aplica as allow/deny lists e o rate limiting à chamada
+// Retorna os trailers de rate limit e o erro gRPC quando a chamada deve ser rejeitada:
+// PermissionDenied (deny list), Unauthenticated (API key desconhecida com UnknownTokenReject),
+// ResourceExhausted (limite excedido, com RetryInfo) e Unavailable (falha do storage)
+func (l *RateLimiter) check(ctx context.Context, fullMethod string) (metadata.MD, error) {
+	cfg := l.snapshotConfig()
+	md, _ := metadata.FromIncomingContext(ctx)
+	ip := clientIP(ctx, md)
+	apiKey := firstValue(md, l.metadataKey)
+
+	// Allow/deny lists (deny tem prioridade sobre allow)
+	switch cfg.CheckAccess(ip, apiKey) {
+	case middleware.AccessDeny:
+		return nil, status.Error(codes.PermissionDenied, "access denied")
+	case middleware.AccessAllow:
+		return nil, nil
+	}
+
+	if apiKey != "" {
+		if _, known := cfg.GetTokenConfig(apiKey); !known && cfg.GetUnknownTokenPolicy().Mode == middleware.UnknownTokenReject {
+			return nil, status.Error(codes.Unauthenticated, middleware.InvalidAPIKeyMessage)
+		}
+	}
+
+	input := middleware.BuildRateLimitInput(cfg, http.MethodPost, fullMethod, ip, apiKey)
+	output, err := l.useCase.Execute(ctx, input)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "gRPC rate limit check failed", "method", fullMethod, "key", input.Key, "error", err)
+		return nil, status.Error(codes.Unavailable, "rate limit storage unavailable")
+	}
+
+	trailer := metadata.Pairs(
+		trailerRateLimitLimit, strconv.Itoa(input.Limit),
+		trailerRateLimitRemaining, strconv.Itoa(int(math.Floor(max(output.CurrentTokens, 0)))),
+	)
+	if output.Allowed {
+		return trailer, nil
+	}
+
+	_, retryAfter, err := middleware.RateLimitTimes(ctx, l.storage, input, output)
+	if err != nil {
+		l.logger.WarnContext(ctx, "Failed to get block TTL", "key", input.Key, "error", err)
+	}
+	trailer.Set(trailerRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	return trailer, resourceExhausted(output.Message, retryAfter)
+}
+
+// resourceExhausted monta o erro de limite excedido com errdetails.RetryInfo
+func resourceExhausted(message string, retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, message)
+	if retryAfter <= 0 {
+		return st.Err()
+	}
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// snapshotConfig retorna a configuração a ser usada durante toda a chamada
+func (l *RateLimiter) snapshotConfig() middleware.Config {
+	if snapshotter, ok := l.config.(middleware.ConfigSnapshotter); ok {
+		return snapshotter.Snapshot()
+	}
+	return l.config
+}
+
+// clientIP identifica o cliente pelos metadata de proxy (x-forwarded-for, x-real-ip) ou pelo peer
+func clientIP(ctx context.Context, md metadata.MD) string {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	return middleware.ClientIP(firstValue(md, "x-forwarded-for"), firstValue(md, "x-real-ip"), remoteAddr)
+}
+
+// firstValue retorna o primeiro valor de uma chave de metadata
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
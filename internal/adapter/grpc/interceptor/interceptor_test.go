@@ -0,0 +1,171 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// stubConfig é uma configuração fixa: IP 2 req/s, token "partner" 5 req/s,
+// o método Health/Watch 1 req/min e o IP 10.9.9.9 na deny list
+type stubConfig struct{}
+
+func (stubConfig) GetIPLimit() int                          { return 2 }
+func (stubConfig) GetIPWindow() time.Duration               { return time.Second }
+func (stubConfig) GetIPBlockTime() time.Duration            { return time.Minute }
+func (stubConfig) TokenKey(apiKey string) entity.LimiterKey { return entity.NewTokenKey(apiKey) }
+func (stubConfig) GetUnknownTokenPolicy() middleware.UnknownTokenPolicy {
+	return middleware.UnknownTokenPolicy{Mode: middleware.UnknownTokenAnonymous}
+}
+
+func (stubConfig) CheckAccess(ip, apiKey string) middleware.Access {
+	if ip == "10.9.9.9" {
+		return middleware.AccessDeny
+	}
+	return middleware.AccessDefault
+}
+
+func (stubConfig) GetTokenConfig(token string) (middleware.TokenConfig, bool) {
+	if token != "partner" {
+		return middleware.TokenConfig{}, false
+	}
+	return middleware.TokenConfig{Limit: 5, Window: time.Second, BlockTime: time.Minute}, true
+}
+
+func (stubConfig) GetRouteConfig(method, path string) (middleware.RouteConfig, bool) {
+	if method != http.MethodPost || path != healthpb.Health_Watch_FullMethodName {
+		return middleware.RouteConfig{}, false
+	}
+	return middleware.RouteConfig{Name: "health-watch", Limit: 1, Window: time.Minute, BlockTime: time.Minute}, true
+}
+
+// newTestClient sobe o serviço de health em um servidor gRPC em memória (bufconn) com os interceptors
+func newTestClient(t *testing.T) healthpb.HealthClient {
+	storage := memory.NewMemoryStorage()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limiter := NewRateLimiter(check_rate_limit.NewUseCase(storage, logger), storage, stubConfig{}, logger)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(limiter.Unary()),
+		grpc.StreamInterceptor(limiter.Stream()),
+	)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+// withClient adiciona os metadata de IP (e de API key, se informada) à chamada
+func withClient(ip, apiKey string) context.Context {
+	md := metadata.Pairs("x-forwarded-for", ip)
+	if apiKey != "" {
+		md.Set(DefaultAPIKeyMetadata, apiKey)
+	}
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+func TestRateLimiter_Unary_AllowsThenResourceExhausted(t *testing.T) {
+	// Arrange
+	client := newTestClient(t)
+	ctx := withClient("203.0.113.7", "")
+
+	// Act
+	var firstTrailer, rejectedTrailer metadata.MD
+	_, first := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Trailer(&firstTrailer))
+	_, _ = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	_, rejected := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Trailer(&rejectedTrailer))
+	_, otherIP := client.Check(withClient("203.0.113.8", ""), &healthpb.HealthCheckRequest{})
+
+	// Assert
+	require.NoError(t, first)
+	assert.Equal(t, []string{"2"}, firstTrailer.Get("x-ratelimit-limit"))
+	assert.Equal(t, []string{"1"}, firstTrailer.Get("x-ratelimit-remaining"))
+
+	st := status.Convert(rejected)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, check_rate_limit.RateLimitExceededMessage, st.Message())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, retryInfo.GetRetryDelay().AsDuration())
+	assert.Equal(t, []string{"60"}, rejectedTrailer.Get("retry-after"))
+
+	assert.NoError(t, otherIP, "cada IP tem o próprio bucket")
+}
+
+func TestRateLimiter_Unary_KeysAndAccessLists(t *testing.T) {
+	tests := map[string]struct {
+		ctx      context.Context
+		calls    int
+		expected codes.Code
+	}{
+		"token limit from api-key metadata": {ctx: withClient("203.0.113.7", "partner"), calls: 5, expected: codes.OK},
+		"deny list":                         {ctx: withClient("10.9.9.9", ""), calls: 1, expected: codes.PermissionDenied},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			client := newTestClient(t)
+
+			// Act
+			var err error
+			for i := 0; i < tt.calls; i++ {
+				_, err = client.Check(tt.ctx, &healthpb.HealthCheckRequest{})
+			}
+
+			// Assert
+			assert.Equal(t, tt.expected, status.Code(err))
+		})
+	}
+}
+
+func TestRateLimiter_Stream_UsesMethodPolicy(t *testing.T) {
+	// Arrange
+	client := newTestClient(t)
+	ctx, cancel := context.WithCancel(withClient("203.0.113.7", ""))
+	defer cancel()
+
+	// Act - Health/Watch tem limite próprio de 1 stream por minuto
+	first, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, firstErr := first.Recv()
+
+	second, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, secondErr := second.Recv()
+
+	// Assert
+	assert.NoError(t, firstErr)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(secondErr))
+}
@@ -0,0 +1,281 @@
+package rls
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// DescriptorKeys nomeia as entradas dos descriptors do Envoy usadas para montar a chave do rate limiter
+// Os nomes são os descriptor_key configurados nas rate_limits actions do Envoy
+type DescriptorKeys struct {
+	IP     string // IP do cliente (action remote_address gera "remote_address")
+	Token  string // API key (action request_headers com descriptor_key próprio)
+	Method string // Método HTTP, para casar com os limites por rota
+	Path   string // Path HTTP, para casar com os limites por rota
+}
+
+// DefaultDescriptorKeys retorna os nomes usados quando RLS_DESCRIPTOR_* não é definido
+func DefaultDescriptorKeys() DescriptorKeys {
+	return DescriptorKeys{
+		IP:     "remote_address",
+		Token:  "api_key",
+		Method: "method",
+		Path:   "path",
+	}
+}
+
+// Service implementa envoy.service.ratelimit.v3.RateLimitService sobre o check_rate_limit.UseCase
+// Cada descriptor vira uma verificação com as mesmas políticas do middleware (Rota > Token > IP);
+// a requisição é OVER_LIMIT se qualquer descriptor estiver acima do limite
+// Descriptors da mesma requisição que resolvem para a mesma chave consomem o bucket uma única vez
+type Service struct {
+	rlsv3.UnimplementedRateLimitServiceServer
+
+	useCase middleware.UseCase
+	storage repository.Storage
+	config  middleware.Config
+	keys    DescriptorKeys
+	domain  string
+	logger  *slog.Logger
+}
+
+// NewService cria o serviço
+// useCase é o mesmo usado pelo middleware (métricas, tracing e eventos incluídos)
+// storage é consultado apenas para informar quanto tempo resta de um bloqueio já existente
+// config resolve as políticas ip, token e route (com snapshot por requisição quando recarregável)
+func NewService(useCase middleware.UseCase, storage repository.Storage, config middleware.Config, keys DescriptorKeys, logger *slog.Logger) *Service {
+	return &Service{
+		useCase: useCase,
+		storage: storage,
+		config:  config,
+		keys:    keys,
+		logger:  logger,
+	}
+}
+
+// WithDomain restringe o serviço a um domínio do Envoy
+// Requisições de outros domínios recebem OK sem consumir tokens
+func (s *Service) WithDomain(domain string) *Service {
+	s.domain = domain
+	return s
+}
+
+// Register registra o serviço no servidor gRPC
+func (s *Service) Register(registrar grpc.ServiceRegistrar) {
+	rlsv3.RegisterRateLimitServiceServer(registrar, s)
+}
+
+// ShouldRateLimit implementa o método da interface RateLimitServiceServer
+// Erros do storage retornam Unavailable: o Envoy decide entre liberar ou negar (failure_mode_deny)
+func (s *Service) ShouldRateLimit(ctx context.Context, req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	if len(req.GetDescriptors()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "rate limit descriptor list must not be empty")
+	}
+
+	response := &rlsv3.RateLimitResponse{
+		OverallCode: rlsv3.RateLimitResponse_OK,
+		Statuses:    make([]*rlsv3.RateLimitResponse_DescriptorStatus, 0, len(req.GetDescriptors())),
+	}
+
+	if s.domain != "" && req.GetDomain() != s.domain {
+		for range req.GetDescriptors() {
+			response.Statuses = append(response.Statuses, okStatus())
+		}
+		return response, nil
+	}
+
+	cfg := s.snapshotConfig()
+	checked := make(map[entity.LimiterKey]*rlsv3.RateLimitResponse_DescriptorStatus, len(req.GetDescriptors()))
+	for _, descriptor := range req.GetDescriptors() {
+		descriptorStatus, err := s.check(ctx, cfg, descriptor, req.GetHitsAddend(), checked)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Envoy rate limit check failed", "domain", req.GetDomain(), "error", err)
+			return nil, status.Error(codes.Unavailable, "rate limit storage unavailable")
+		}
+		if descriptorStatus.GetCode() == rlsv3.RateLimitResponse_OVER_LIMIT {
+			response.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+		}
+		response.Statuses = append(response.Statuses, descriptorStatus)
+	}
+	return response, nil
+}
+
+// check verifica um descriptor
+// Descriptors sem IP nem token conhecido não correspondem a nenhuma política e não são limitados
+// checked guarda o resultado das chaves já verificadas nesta requisição, que não são consumidas de novo
+func (s *Service) check(ctx context.Context, cfg middleware.Config, descriptor *ratelimitv3.RateLimitDescriptor, requestHits uint32, checked map[entity.LimiterKey]*rlsv3.RateLimitResponse_DescriptorStatus) (*rlsv3.RateLimitResponse_DescriptorStatus, error) {
+	entries := make(map[string]string, len(descriptor.GetEntries()))
+	for _, entry := range descriptor.GetEntries() {
+		entries[entry.GetKey()] = entry.GetValue()
+	}
+	ip, apiKey := entries[s.keys.IP], entries[s.keys.Token]
+
+	// Allow/deny lists (deny tem prioridade sobre allow)
+	switch cfg.CheckAccess(ip, apiKey) {
+	case middleware.AccessDeny:
+		return overLimitStatus(), nil
+	case middleware.AccessAllow:
+		return okStatus(), nil
+	}
+
+	if apiKey != "" {
+		if _, known := cfg.GetTokenConfig(apiKey); !known && cfg.GetUnknownTokenPolicy().Mode == middleware.UnknownTokenReject {
+			return overLimitStatus(), nil
+		}
+	}
+
+	input := middleware.BuildRateLimitInput(cfg, entries[s.keys.Method], entries[s.keys.Path], ip, apiKey)
+	if !input.Key.IsValid() {
+		return okStatus(), nil
+	}
+
+	// O descriptor pode trazer o próprio limite (limit override das actions do Envoy)
+	override := descriptor.GetLimit()
+	window, overridden := unitDurations[override.GetUnit()]
+	overridden = overridden && override.GetRequestsPerUnit() > 0
+	if overridden {
+		input.Limit = int(override.GetRequestsPerUnit())
+		input.Window = window
+	}
+
+	// Entradas extras e override têm bucket próprio, sem gastar o bucket do middleware
+	if scope := s.descriptorScope(entries, override, overridden); scope != "" {
+		input.Key = input.Key.Scoped(scope)
+	}
+	if descriptorStatus, ok := checked[input.Key]; ok {
+		return descriptorStatus, nil
+	}
+
+	input.Cost = int(requestHits)
+	if hits := descriptor.GetHitsAddend(); hits != nil {
+		input.Cost = int(hits.GetValue())
+	}
+	if input.TokenCost() > input.Limit {
+		// Nunca caberia no bucket: rejeita sem consumir
+		return overLimitStatus(), nil
+	}
+
+	output, err := s.useCase.Execute(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptorStatus := &rlsv3.RateLimitResponse_DescriptorStatus{
+		Code:           rlsv3.RateLimitResponse_OK,
+		CurrentLimit:   currentLimit(input),
+		LimitRemaining: uint32(math.Floor(max(output.CurrentTokens, 0))),
+	}
+
+	var untilReset time.Duration
+	switch output.Decision() {
+	case check_rate_limit.DecisionBlocked:
+		descriptorStatus.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+		// O use case não informa quanto resta do bloqueio: consulta o TTL no storage
+		state, err := s.storage.GetKeyState(ctx, input.Key)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Failed to get block TTL", "key", input.Key, "error", err)
+		} else if state.Blocked {
+			untilReset = state.BlockTTL
+		}
+	case check_rate_limit.DecisionRejected:
+		descriptorStatus.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+		untilReset = max(output.BlockTime, input.RefillDuration(float64(input.TokenCost())-output.CurrentTokens))
+	default:
+		untilReset = input.RefillDuration(float64(input.Limit) - output.CurrentTokens)
+	}
+	descriptorStatus.DurationUntilReset = durationpb.New(untilReset)
+
+	checked[input.Key] = descriptorStatus
+	return descriptorStatus, nil
+}
+
+// descriptorScope identifica o bucket de um descriptor com entradas além de IP, token, método e path
+// ou com limit override; retorna "" quando o descriptor usa o mesmo bucket do middleware
+// Os valores são escapados para que o escopo nunca contenha o separador das chaves
+func (s *Service) descriptorScope(entries map[string]string, override *ratelimitv3.RateLimitDescriptor_RateLimitOverride, overridden bool) string {
+	var parts []string
+	for key, value := range entries {
+		switch key {
+		case s.keys.IP, s.keys.Token, s.keys.Method, s.keys.Path:
+			continue
+		}
+		parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+	}
+	slices.Sort(parts)
+	if overridden {
+		parts = append(parts, fmt.Sprintf("limit=%d/%s", override.GetRequestsPerUnit(), strings.ToLower(override.GetUnit().String())))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "envoy:" + strings.Join(parts, ",")
+}
+
+// snapshotConfig retorna a configuração a ser usada durante toda a requisição
+func (s *Service) snapshotConfig() middleware.Config {
+	if snapshotter, ok := s.config.(middleware.ConfigSnapshotter); ok {
+		return snapshotter.Snapshot()
+	}
+	return s.config
+}
+
+// unitDurations converte as unidades de limit override do Envoy para a janela do bucket
+var unitDurations = map[typev3.RateLimitUnit]time.Duration{
+	typev3.RateLimitUnit_SECOND: time.Second,
+	typev3.RateLimitUnit_MINUTE: time.Minute,
+	typev3.RateLimitUnit_HOUR:   time.Hour,
+	typev3.RateLimitUnit_DAY:    24 * time.Hour,
+}
+
+// responseUnits são as unidades informadas em current_limit, da menor para a maior
+var responseUnits = []struct {
+	window time.Duration
+	unit   rlsv3.RateLimitResponse_RateLimit_Unit
+}{
+	{time.Second, rlsv3.RateLimitResponse_RateLimit_SECOND},
+	{time.Minute, rlsv3.RateLimitResponse_RateLimit_MINUTE},
+	{time.Hour, rlsv3.RateLimitResponse_RateLimit_HOUR},
+	{24 * time.Hour, rlsv3.RateLimitResponse_RateLimit_DAY},
+}
+
+// currentLimit descreve o limite no formato do Envoy (usado nos headers x-ratelimit-*)
+// Janelas que não correspondem a uma unidade do Envoy não são informadas
+func currentLimit(input check_rate_limit.Input) *rlsv3.RateLimitResponse_RateLimit {
+	for _, candidate := range responseUnits {
+		if input.Window == candidate.window {
+			return &rlsv3.RateLimitResponse_RateLimit{
+				Name:            input.Policy,
+				RequestsPerUnit: uint32(input.Limit),
+				Unit:            candidate.unit,
+			}
+		}
+	}
+	return nil
+}
+
+func okStatus() *rlsv3.RateLimitResponse_DescriptorStatus {
+	return &rlsv3.RateLimitResponse_DescriptorStatus{Code: rlsv3.RateLimitResponse_OK}
+}
+
+func overLimitStatus() *rlsv3.RateLimitResponse_DescriptorStatus {
+	return &rlsv3.RateLimitResponse_DescriptorStatus{Code: rlsv3.RateLimitResponse_OVER_LIMIT}
+}
@@ -0,0 +1,289 @@
+package rls
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// stubConfig é uma configuração fixa: IP 2 req/s, token "partner" 5 req/s,
+// rota POST /login 1 req/min e o IP 10.9.9.9 na deny list
+type stubConfig struct{}
+
+func (stubConfig) GetIPLimit() int                          { return 2 }
+func (stubConfig) GetIPWindow() time.Duration               { return time.Second }
+func (stubConfig) GetIPBlockTime() time.Duration            { return time.Minute }
+func (stubConfig) TokenKey(apiKey string) entity.LimiterKey { return entity.NewTokenKey(apiKey) }
+func (stubConfig) GetUnknownTokenPolicy() middleware.UnknownTokenPolicy {
+	return middleware.UnknownTokenPolicy{Mode: middleware.UnknownTokenAnonymous}
+}
+
+func (stubConfig) CheckAccess(ip, apiKey string) middleware.Access {
+	if ip == "10.9.9.9" {
+		return middleware.AccessDeny
+	}
+	return middleware.AccessDefault
+}
+
+func (stubConfig) GetTokenConfig(token string) (middleware.TokenConfig, bool) {
+	if token != "partner" {
+		return middleware.TokenConfig{}, false
+	}
+	return middleware.TokenConfig{Limit: 5, Window: time.Second, BlockTime: time.Minute}, true
+}
+
+func (stubConfig) GetRouteConfig(method, path string) (middleware.RouteConfig, bool) {
+	if method != http.MethodPost || path != "/login" {
+		return middleware.RouteConfig{}, false
+	}
+	return middleware.RouteConfig{Name: "login", Limit: 1, Window: time.Minute, BlockTime: 5 * time.Minute}, true
+}
+
+// newTestClient sobe o serviço em um servidor gRPC em memória (bufconn) e retorna o cliente
+func newTestClient(t *testing.T, domain string) (rlsv3.RateLimitServiceClient, *memory.MemoryStorage) {
+	storage := memory.NewMemoryStorage()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	useCase := check_rate_limit.NewUseCase(storage, logger)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	NewService(useCase, storage, stubConfig{}, DefaultDescriptorKeys(), logger).WithDomain(domain).Register(server)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return rlsv3.NewRateLimitServiceClient(conn), storage
+}
+
+// descriptor monta um descriptor a partir de pares chave/valor
+func descriptor(pairs ...string) *ratelimitv3.RateLimitDescriptor {
+	d := &ratelimitv3.RateLimitDescriptor{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		d.Entries = append(d.Entries, &ratelimitv3.RateLimitDescriptor_Entry{Key: pairs[i], Value: pairs[i+1]})
+	}
+	return d
+}
+
+func shouldRateLimit(t *testing.T, client rlsv3.RateLimitServiceClient, req *rlsv3.RateLimitRequest) *rlsv3.RateLimitResponse {
+	response, err := client.ShouldRateLimit(context.Background(), req)
+	require.NoError(t, err)
+	return response
+}
+
+func TestService_IPDescriptor_AllowsThenOverLimit(t *testing.T) {
+	// Arrange
+	client, _ := newTestClient(t, "")
+	req := &rlsv3.RateLimitRequest{
+		Domain:      "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{descriptor("remote_address", "10.0.0.1")},
+	}
+
+	// Act
+	first := shouldRateLimit(t, client, req)
+	shouldRateLimit(t, client, req)
+	third := shouldRateLimit(t, client, req)
+	fourth := shouldRateLimit(t, client, req)
+
+	// Assert
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, first.GetOverallCode())
+	require.Len(t, first.GetStatuses(), 1)
+	assert.Equal(t, uint32(1), first.GetStatuses()[0].GetLimitRemaining())
+	assert.Equal(t, uint32(2), first.GetStatuses()[0].GetCurrentLimit().GetRequestsPerUnit())
+	assert.Equal(t, rlsv3.RateLimitResponse_RateLimit_SECOND, first.GetStatuses()[0].GetCurrentLimit().GetUnit())
+
+	assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, third.GetOverallCode())
+	assert.Equal(t, time.Minute, third.GetStatuses()[0].GetDurationUntilReset().AsDuration())
+
+	assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, fourth.GetOverallCode())
+	assert.InDelta(t, time.Minute.Seconds(), fourth.GetStatuses()[0].GetDurationUntilReset().AsDuration().Seconds(), 1)
+}
+
+func TestService_TokenAndRouteDescriptors(t *testing.T) {
+	// Arrange
+	client, storage := newTestClient(t, "")
+	req := &rlsv3.RateLimitRequest{
+		Domain: "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{
+			descriptor("remote_address", "10.0.0.1", "api_key", "partner"),
+			descriptor("remote_address", "10.0.0.1", "method", "POST", "path", "/login"),
+		},
+		HitsAddend: 1,
+	}
+
+	// Act
+	response := shouldRateLimit(t, client, req)
+
+	// Assert - token com limite próprio e bucket da rota separado por cliente, como no middleware
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, response.GetOverallCode())
+	require.Len(t, response.GetStatuses(), 2)
+	assert.Equal(t, uint32(4), response.GetStatuses()[0].GetLimitRemaining())
+	assert.Equal(t, check_rate_limit.PolicyToken, response.GetStatuses()[0].GetCurrentLimit().GetName())
+	assert.Equal(t, rlsv3.RateLimitResponse_RateLimit_MINUTE, response.GetStatuses()[1].GetCurrentLimit().GetUnit())
+
+	state, err := storage.GetKeyState(context.Background(), entity.NewIPKey("10.0.0.1").Scoped("login"))
+	require.NoError(t, err)
+	assert.True(t, state.Exists)
+}
+
+func TestService_HitsAddendAndLimitOverride(t *testing.T) {
+	// Arrange
+	client, _ := newTestClient(t, "")
+	d := descriptor("remote_address", "10.0.0.2")
+	d.Limit = &ratelimitv3.RateLimitDescriptor_RateLimitOverride{RequestsPerUnit: 10, Unit: typev3.RateLimitUnit_MINUTE}
+
+	// Act
+	response := shouldRateLimit(t, client, &rlsv3.RateLimitRequest{
+		Domain:      "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{d},
+		HitsAddend:  4,
+	})
+
+	// Assert
+	require.Len(t, response.GetStatuses(), 1)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, response.GetOverallCode())
+	assert.Equal(t, uint32(6), response.GetStatuses()[0].GetLimitRemaining())
+	assert.Equal(t, uint32(10), response.GetStatuses()[0].GetCurrentLimit().GetRequestsPerUnit())
+}
+
+func TestService_DescriptorsWithSameKey_ConsumeOnce(t *testing.T) {
+	// Arrange - as duas actions do README resolvem para o IP quando o path não é uma rota
+	client, storage := newTestClient(t, "")
+	req := &rlsv3.RateLimitRequest{
+		Domain: "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{
+			descriptor("remote_address", "10.0.0.4"),
+			descriptor("remote_address", "10.0.0.4", "method", "GET", "path", "/catalog"),
+		},
+	}
+
+	// Act
+	response := shouldRateLimit(t, client, req)
+
+	// Assert - um único token gasto do bucket do IP
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, response.GetOverallCode())
+	require.Len(t, response.GetStatuses(), 2)
+	assert.Equal(t, uint32(1), response.GetStatuses()[0].GetLimitRemaining())
+	assert.Equal(t, uint32(1), response.GetStatuses()[1].GetLimitRemaining())
+
+	state, err := storage.GetKeyState(context.Background(), entity.NewIPKey("10.0.0.4"))
+	require.NoError(t, err)
+	assert.InDelta(t, 1, state.Tokens, 0.1)
+}
+
+func TestService_OverrideAndExtraEntries_UseOwnBuckets(t *testing.T) {
+	// Arrange
+	client, storage := newTestClient(t, "")
+	overridden := descriptor("remote_address", "10.0.0.5")
+	overridden.Limit = &ratelimitv3.RateLimitDescriptor_RateLimitOverride{RequestsPerUnit: 10, Unit: typev3.RateLimitUnit_MINUTE}
+	req := &rlsv3.RateLimitRequest{
+		Domain: "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{
+			descriptor("remote_address", "10.0.0.5"),
+			overridden,
+			descriptor("remote_address", "10.0.0.5", "generic_key", "search"),
+		},
+	}
+
+	// Act
+	response := shouldRateLimit(t, client, req)
+
+	// Assert - cada descriptor consome o próprio bucket; o do middleware é gasto uma vez
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, response.GetOverallCode())
+	require.Len(t, response.GetStatuses(), 3)
+	assert.Equal(t, uint32(1), response.GetStatuses()[0].GetLimitRemaining())
+	assert.Equal(t, uint32(9), response.GetStatuses()[1].GetLimitRemaining())
+	assert.Equal(t, uint32(1), response.GetStatuses()[2].GetLimitRemaining())
+
+	ctx := context.Background()
+	for _, key := range []entity.LimiterKey{
+		entity.NewIPKey("10.0.0.5"),
+		entity.NewIPKey("10.0.0.5").Scoped("envoy:limit=10/minute"),
+		entity.NewIPKey("10.0.0.5").Scoped("envoy:generic_key=search"),
+	} {
+		state, err := storage.GetKeyState(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, state.Exists, key.String())
+	}
+}
+
+func TestService_UnmatchedDescriptorsAndDomain(t *testing.T) {
+	tests := map[string]struct {
+		domain   string
+		request  *rlsv3.RateLimitRequest
+		expected rlsv3.RateLimitResponse_Code
+	}{
+		"descriptor without client key is not limited": {
+			request: &rlsv3.RateLimitRequest{Domain: "edge", Descriptors: []*ratelimitv3.RateLimitDescriptor{
+				descriptor("generic_key", "catalog"),
+			}},
+			expected: rlsv3.RateLimitResponse_OK,
+		},
+		"deny list": {
+			request: &rlsv3.RateLimitRequest{Domain: "edge", Descriptors: []*ratelimitv3.RateLimitDescriptor{
+				descriptor("remote_address", "10.9.9.9"),
+			}},
+			expected: rlsv3.RateLimitResponse_OVER_LIMIT,
+		},
+		"other domain is not limited": {
+			domain: "edge",
+			request: &rlsv3.RateLimitRequest{Domain: "internal", Descriptors: []*ratelimitv3.RateLimitDescriptor{
+				descriptor("remote_address", "10.9.9.9"),
+			}},
+			expected: rlsv3.RateLimitResponse_OK,
+		},
+		"hits above the limit": {
+			request: &rlsv3.RateLimitRequest{Domain: "edge", HitsAddend: 3, Descriptors: []*ratelimitv3.RateLimitDescriptor{
+				descriptor("remote_address", "10.0.0.3"),
+			}},
+			expected: rlsv3.RateLimitResponse_OVER_LIMIT,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			client, _ := newTestClient(t, tt.domain)
+
+			// Act
+			response := shouldRateLimit(t, client, tt.request)
+
+			// Assert
+			assert.Equal(t, tt.expected, response.GetOverallCode())
+			assert.Len(t, response.GetStatuses(), len(tt.request.GetDescriptors()))
+		})
+	}
+}
+
+func TestService_EmptyDescriptors_ReturnsInvalidArgument(t *testing.T) {
+	client, _ := newTestClient(t, "")
+
+	_, err := client.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{Domain: "edge"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
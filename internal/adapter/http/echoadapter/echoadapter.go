@@ -0,0 +1,34 @@
+package echoadapter
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+)
+
+// Middleware retorna o middleware echo do rate limiter
+// O IP vem de X-Forwarded-For, X-Real-IP ou da conexão, como no middleware net/http
+// (o IPExtractor do echo não é usado); rejeições são respondidas sem chamar o próximo handler
+func Middleware(limiter *middleware.RateLimiterMiddleware) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx, span := middleware.StartSpan(req.Context(), propagation.HeaderCarrier(req.Header), req.Method, req.URL.Path)
+			defer span.End()
+			req = req.WithContext(ctx)
+			c.SetRequest(req)
+
+			result := limiter.Check(ctx, middleware.Request{
+				Method: req.Method,
+				Path:   req.URL.Path,
+				IP:     middleware.ExtractIP(req),
+				APIKey: req.Header.Get(middleware.APIKeyHeader),
+			})
+			if !result.Allowed {
+				return c.Blob(result.StatusCode, middleware.ContentTypeJSON, result.Body)
+			}
+			return next(c)
+		}
+	}
+}
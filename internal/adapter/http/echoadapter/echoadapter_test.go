@@ -0,0 +1,106 @@
+package echoadapter
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// stubConfig é uma configuração fixa: IP 1 req/s, token "partner" 3 req/s e o IP 10.9.9.9 na deny list
+type stubConfig struct{}
+
+func (stubConfig) GetIPLimit() int                          { return 1 }
+func (stubConfig) GetIPWindow() time.Duration               { return time.Second }
+func (stubConfig) GetIPBlockTime() time.Duration            { return time.Minute }
+func (stubConfig) TokenKey(apiKey string) entity.LimiterKey { return entity.NewTokenKey(apiKey) }
+func (stubConfig) GetRouteConfig(method, path string) (middleware.RouteConfig, bool) {
+	return middleware.RouteConfig{}, false
+}
+func (stubConfig) GetUnknownTokenPolicy() middleware.UnknownTokenPolicy {
+	return middleware.UnknownTokenPolicy{Mode: middleware.UnknownTokenAnonymous}
+}
+
+func (stubConfig) CheckAccess(ip, apiKey string) middleware.Access {
+	if ip == "10.9.9.9" {
+		return middleware.AccessDeny
+	}
+	return middleware.AccessDefault
+}
+
+func (stubConfig) GetTokenConfig(token string) (middleware.TokenConfig, bool) {
+	if token != "partner" {
+		return middleware.TokenConfig{}, false
+	}
+	return middleware.TokenConfig{Limit: 3, Window: time.Second, BlockTime: time.Minute}, true
+}
+
+// newRouter monta um router echo com o rate limiter e uma rota que conta as chamadas
+func newRouter(calls *int) *echo.Echo {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limiter := middleware.NewRateLimiterMiddleware(check_rate_limit.NewUseCase(memory.NewMemoryStorage(), logger), stubConfig{}, logger)
+
+	router := echo.New()
+	router.Use(Middleware(limiter))
+	router.GET("/api/users", func(c echo.Context) error {
+		*calls++
+		return c.String(http.StatusOK, "ok")
+	})
+	return router
+}
+
+func doRequest(router http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_AllowsThenRejectsWithoutCallingHandler(t *testing.T) {
+	// Arrange
+	var calls int
+	router := newRouter(&calls)
+
+	// Act
+	first := doRequest(router, nil)
+	second := doRequest(router, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Equal(t, middleware.ContentTypeJSON, second.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"message":"`+check_rate_limit.RateLimitExceededMessage+`"}`, second.Body.String())
+	assert.Equal(t, 1, calls)
+}
+
+func TestMiddleware_UsesForwardedIPAndAPIKey(t *testing.T) {
+	// Arrange
+	var calls int
+	router := newRouter(&calls)
+
+	// Act
+	doRequest(router, map[string]string{"X-Forwarded-For": "203.0.113.7"})
+	otherIP := doRequest(router, map[string]string{"X-Forwarded-For": "203.0.113.8"})
+	token := doRequest(router, map[string]string{"X-Forwarded-For": "203.0.113.7", "API_KEY": "partner"})
+	denied := doRequest(router, map[string]string{"X-Forwarded-For": "10.9.9.9"})
+
+	// Assert
+	assert.Equal(t, http.StatusOK, otherIP.Code)
+	assert.Equal(t, http.StatusOK, token.Code, "o token tem bucket próprio")
+	assert.Equal(t, http.StatusForbidden, denied.Code)
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,75 @@
+package fasthttpadapter
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+)
+
+// traceContextKey guarda, nos user values da requisição, o contexto com o span do rate limiter
+const traceContextKey = "ratelimiter.trace_context"
+
+// Middleware envolve next com o rate limiter
+// A requisição é lida diretamente do fasthttp.RequestCtx, sem converter para *http.Request
+// O IP vem de X-Forwarded-For, X-Real-IP ou da conexão, como no middleware net/http;
+// rejeições são respondidas sem chamar next
+func Middleware(limiter *middleware.RateLimiterMiddleware, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		header := &ctx.Request.Header
+		method, path := string(header.Method()), string(ctx.Path())
+
+		traceCtx, span := middleware.StartSpan(ctx, headerCarrier{header}, method, path)
+		defer span.End()
+		ctx.SetUserValue(traceContextKey, traceCtx)
+
+		result := limiter.Check(traceCtx, middleware.Request{
+			Method: method,
+			Path:   path,
+			IP: middleware.ClientIP(
+				string(header.Peek("X-Forwarded-For")),
+				string(header.Peek("X-Real-IP")),
+				ctx.RemoteAddr().String(),
+			),
+			APIKey: string(header.Peek(middleware.APIKeyHeader)),
+		})
+		if !result.Allowed {
+			ctx.SetStatusCode(result.StatusCode)
+			ctx.SetContentType(middleware.ContentTypeJSON)
+			ctx.SetBody(result.Body)
+			return
+		}
+		next(ctx)
+	}
+}
+
+// TraceContext retorna o contexto com o span do rate limiter, para continuar o trace nos handlers
+// Fora do Middleware retorna o próprio RequestCtx
+func TraceContext(ctx *fasthttp.RequestCtx) context.Context {
+	if traceCtx, ok := ctx.UserValue(traceContextKey).(context.Context); ok {
+		return traceCtx
+	}
+	return ctx
+}
+
+// headerCarrier adapta os headers do fasthttp para a propagação do OpenTelemetry
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
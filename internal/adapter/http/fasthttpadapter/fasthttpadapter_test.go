@@ -0,0 +1,141 @@
+package fasthttpadapter
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// stubConfig é uma configuração fixa: IP 1 req/s, token "partner" 3 req/s e o IP 10.9.9.9 na deny list
+type stubConfig struct{}
+
+func (stubConfig) GetIPLimit() int                          { return 1 }
+func (stubConfig) GetIPWindow() time.Duration               { return time.Second }
+func (stubConfig) GetIPBlockTime() time.Duration            { return time.Minute }
+func (stubConfig) TokenKey(apiKey string) entity.LimiterKey { return entity.NewTokenKey(apiKey) }
+func (stubConfig) GetRouteConfig(method, path string) (middleware.RouteConfig, bool) {
+	return middleware.RouteConfig{}, false
+}
+func (stubConfig) GetUnknownTokenPolicy() middleware.UnknownTokenPolicy {
+	return middleware.UnknownTokenPolicy{Mode: middleware.UnknownTokenAnonymous}
+}
+
+func (stubConfig) CheckAccess(ip, apiKey string) middleware.Access {
+	if ip == "10.9.9.9" {
+		return middleware.AccessDeny
+	}
+	return middleware.AccessDefault
+}
+
+func (stubConfig) GetTokenConfig(token string) (middleware.TokenConfig, bool) {
+	if token != "partner" {
+		return middleware.TokenConfig{}, false
+	}
+	return middleware.TokenConfig{Limit: 3, Window: time.Second, BlockTime: time.Minute}, true
+}
+
+// newHandler monta o handler fasthttp com o rate limiter e um handler que conta as chamadas
+func newHandler(calls *int) fasthttp.RequestHandler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limiter := middleware.NewRateLimiterMiddleware(check_rate_limit.NewUseCase(memory.NewMemoryStorage(), logger), stubConfig{}, logger)
+
+	return Middleware(limiter, func(ctx *fasthttp.RequestCtx) {
+		*calls++
+		ctx.SetStatusCode(http.StatusOK)
+	})
+}
+
+// doRequest executa o handler com uma requisição vinda de 192.168.1.1
+func doRequest(handler fasthttp.RequestHandler, headers map[string]string) *fasthttp.RequestCtx {
+	var req fasthttp.Request
+	req.SetRequestURI("/api/users")
+	req.Header.SetMethod(http.MethodGet)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 12345}, nil)
+	handler(ctx)
+	return ctx
+}
+
+func TestMiddleware_AllowsThenRejectsWithoutCallingHandler(t *testing.T) {
+	// Arrange
+	var calls int
+	handler := newHandler(&calls)
+
+	// Act
+	first := doRequest(handler, nil)
+	second := doRequest(handler, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Response.StatusCode())
+	assert.Equal(t, http.StatusTooManyRequests, second.Response.StatusCode())
+	assert.Equal(t, middleware.ContentTypeJSON, string(second.Response.Header.ContentType()))
+	assert.JSONEq(t, `{"message":"`+check_rate_limit.RateLimitExceededMessage+`"}`, string(second.Response.Body()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestMiddleware_UsesForwardedIPAndAPIKey(t *testing.T) {
+	// Arrange
+	var calls int
+	handler := newHandler(&calls)
+
+	// Act
+	doRequest(handler, map[string]string{"X-Forwarded-For": "203.0.113.7"})
+	otherIP := doRequest(handler, map[string]string{"X-Forwarded-For": "203.0.113.8"})
+	token := doRequest(handler, map[string]string{"X-Forwarded-For": "203.0.113.7", "API_KEY": "partner"})
+	denied := doRequest(handler, map[string]string{"X-Forwarded-For": "10.9.9.9"})
+
+	// Assert
+	assert.Equal(t, http.StatusOK, otherIP.Response.StatusCode())
+	assert.Equal(t, http.StatusOK, token.Response.StatusCode(), "o token tem bucket próprio")
+	assert.Equal(t, http.StatusForbidden, denied.Response.StatusCode())
+	assert.Equal(t, 3, calls)
+}
+
+func TestMiddleware_ContinuesCallerTrace(t *testing.T) {
+	// Arrange - TracerProvider em memória e propagação W3C
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limiter := middleware.NewRateLimiterMiddleware(check_rate_limit.NewUseCase(memory.NewMemoryStorage(), logger), stubConfig{}, logger)
+	var handlerSpan trace.SpanContext
+	handler := Middleware(limiter, func(ctx *fasthttp.RequestCtx) {
+		handlerSpan = trace.SpanContextFromContext(TraceContext(ctx))
+	})
+
+	// Act
+	doRequest(handler, map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), handlerSpan.SpanID())
+}
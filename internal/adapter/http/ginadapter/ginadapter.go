@@ -0,0 +1,32 @@
+package ginadapter
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+)
+
+// Middleware retorna o handler gin do rate limiter
+// O IP vem de X-Forwarded-For, X-Real-IP ou da conexão, como no middleware net/http
+// (as trusted proxies do gin não são usadas); rejeições abortam a cadeia com a resposta JSON
+func Middleware(limiter *middleware.RateLimiterMiddleware) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, span := middleware.StartSpan(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header), c.Request.Method, c.Request.URL.Path)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		result := limiter.Check(ctx, middleware.Request{
+			Method: c.Request.Method,
+			Path:   c.Request.URL.Path,
+			IP:     middleware.ExtractIP(c.Request),
+			APIKey: c.GetHeader(middleware.APIKeyHeader),
+		})
+		if !result.Allowed {
+			c.Data(result.StatusCode, middleware.ContentTypeJSON, result.Body)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,570 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/audit"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// AdminHandler expõe a API administrativa para inspecionar e manipular chaves do rate limiter
+// Deve ser servido em uma porta separada, sem rate limiting e protegido por token
+type AdminHandler struct {
+	storage     repository.Storage
+	tokenConfig repository.TokenConfigStore
+	auditLog    repository.AuditLog
+	publisher   repository.EventPublisher
+	token       string
+	hasher      entity.TokenHasher
+	logger      *slog.Logger
+}
+
+// adminUserHeader identifica o operador nos bloqueios e desbloqueios manuais (registrado na auditoria)
+const adminUserHeader = "X-Admin-User"
+
+// Limites da consulta ao audit log
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// NewAdminHandler cria o handler administrativo
+// tokenConfig é opcional (nil desabilita as rotas /admin/tokens)
+// auditLog é opcional (nil desabilita a rota /admin/audit)
+// token é o Bearer token exigido em todas as requisições (ADMIN_TOKEN)
+// hasher converte a API key informada na rota para a chave usada no storage (TOKEN_KEY_SECRET)
+// logger registra as falhas do storage (chaves e tokens são registrados sem o valor do token)
+func NewAdminHandler(storage repository.Storage, tokenConfig repository.TokenConfigStore, auditLog repository.AuditLog, token string, hasher entity.TokenHasher, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		storage:     storage,
+		tokenConfig: tokenConfig,
+		auditLog:    auditLog,
+		token:       token,
+		hasher:      hasher,
+		logger:      logger,
+	}
+}
+
+// WithEventPublisher notifica o publisher a cada bloqueio e desbloqueio manual
+func (h *AdminHandler) WithEventPublisher(publisher repository.EventPublisher) *AdminHandler {
+	h.publisher = publisher
+	return h
+}
+
+// keyStateResponse é a representação JSON do estado de uma chave
+type keyStateResponse struct {
+	Key             string     `json:"key"`
+	Type            string     `json:"type"`
+	Value           string     `json:"value"`
+	Exists          bool       `json:"exists"`
+	Tokens          float64    `json:"tokens"`
+	LastRefill      *time.Time `json:"last_refill,omitempty"`
+	Blocked         bool       `json:"blocked"`
+	BlockTTLSeconds float64    `json:"block_ttl_seconds,omitempty"`
+	Block           *blockInfo `json:"block,omitempty"`
+	Strikes         int        `json:"strikes"`
+}
+
+// blockInfo é a representação JSON do motivo de um bloqueio
+// Bloqueios criados antes do registro de motivo não têm nenhum campo preenchido
+type blockInfo struct {
+	Reason    string     `json:"reason,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	BlockedAt *time.Time `json:"blocked_at,omitempty"`
+	Policy    string     `json:"policy,omitempty"`
+	Limit     int        `json:"limit,omitempty"`
+	Window    string     `json:"window,omitempty"`
+	Strikes   int        `json:"strikes,omitempty"`
+	Note      string     `json:"note,omitempty"`
+}
+
+// blockedKeyResponse é a representação JSON de uma chave bloqueada
+type blockedKeyResponse struct {
+	Key        string     `json:"key"`
+	Type       string     `json:"type"`
+	Value      string     `json:"value"`
+	TTLSeconds float64    `json:"ttl_seconds"`
+	Reason     string     `json:"reason,omitempty"`
+	Source     string     `json:"source,omitempty"`
+	BlockedAt  *time.Time `json:"blocked_at,omitempty"`
+}
+
+// auditEventResponse é a representação JSON de um evento do audit log
+type auditEventResponse struct {
+	Action          string     `json:"action"`
+	Key             string     `json:"key"`
+	Time            time.Time  `json:"time"`
+	DurationSeconds float64    `json:"duration_seconds,omitempty"`
+	Actor           string     `json:"actor,omitempty"`
+	Block           *blockInfo `json:"block,omitempty"`
+}
+
+// tokenConfigRequest é o body para criar/alterar a configuração de um token
+type tokenConfigRequest struct {
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`               // Ex: "1s"
+	BlockTime string `json:"block_time,omitempty"` // Ex: "10m"
+}
+
+// tokenConfigResponse é a representação JSON da configuração de um token
+type tokenConfigResponse struct {
+	Token     string `json:"token"`
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	BlockTime string `json:"block_time"`
+}
+
+// blockRequest é o body do bloqueio manual
+type blockRequest struct {
+	Duration string `json:"duration"`         // Ex: "10m", "1h"
+	Reason   string `json:"reason,omitempty"` // "manual" (padrão) ou "denylist"
+	Note     string `json:"note,omitempty"`   // Texto livre registrado com o bloqueio
+}
+
+// Routes monta o router administrativo
+//
+//	GET    /admin/keys/{type}/{value}         estado atual (tokens, último refill, bloqueio e seu motivo)
+//	DELETE /admin/keys/{type}/{value}/bucket  reseta o bucket (volta cheio)
+//	PUT    /admin/keys/{type}/{value}/block   bloqueia manualmente: {"duration": "10m", "reason": "denylist", "note": "..."}
+//	DELETE /admin/keys/{type}/{value}/block   remove o bloqueio
+//	DELETE /admin/keys/{type}/{value}/strikes zera as violações do bloqueio progressivo
+//	GET    /admin/blocked                     lista as chaves bloqueadas
+//	GET    /admin/audit?limit=100             últimos eventos de bloqueio/desbloqueio (mais recentes primeiro)
+//	GET    /admin/tokens                      lista as configurações de token em runtime
+//	GET    /admin/tokens/{token}              consulta a configuração de um token
+//	PUT    /admin/tokens/{token}              cria/altera: {"limit": 100, "window": "1s", "block_time": "10m"}
+//	DELETE /admin/tokens/{token}              remove a configuração de um token
+func (h *AdminHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(h.authenticate)
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/blocked", h.listBlocked)
+		if h.auditLog != nil {
+			r.Get("/audit", h.listAuditEvents)
+		}
+
+		r.Route("/keys/{type}/{value}", func(r chi.Router) {
+			r.Get("/", h.getKeyState)
+			r.Delete("/bucket", h.resetBucket)
+			r.Put("/block", h.block)
+			r.Delete("/block", h.unblock)
+			r.Delete("/strikes", h.resetStrikes)
+		})
+
+		if h.tokenConfig != nil {
+			r.Route("/tokens", func(r chi.Router) {
+				r.Get("/", h.listTokenConfigs)
+				r.Get("/{token}", h.getTokenConfig)
+				r.Put("/{token}", h.saveTokenConfig)
+				r.Delete("/{token}", h.deleteTokenConfig)
+			})
+		}
+	})
+
+	return r
+}
+
+// authenticate valida o Bearer token em tempo constante
+func (h *AdminHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasBearerToken(r, h.token) {
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasBearerToken compara o Bearer token da requisição com o esperado em tempo constante
+// Um token esperado vazio nunca é aceito
+func hasBearerToken(r *http.Request, expected string) bool {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+func (h *AdminHandler) getKeyState(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.keyFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.storage.GetKeyState(r.Context(), key)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to get key state", "key", key, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	response := keyStateResponse{
+		Key:     key.String(),
+		Type:    string(key.Type),
+		Value:   key.Value,
+		Exists:  state.Exists,
+		Tokens:  state.Tokens,
+		Strikes: state.Strikes,
+	}
+	if !state.LastRefill.IsZero() {
+		lastRefill := state.LastRefill.UTC()
+		response.LastRefill = &lastRefill
+	}
+	if state.Blocked {
+		response.Blocked = true
+		response.BlockTTLSeconds = state.BlockTTL.Seconds()
+		response.Block = newBlockInfo(state.BlockInfo)
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, response)
+}
+
+func (h *AdminHandler) resetBucket(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.keyFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.ResetBucket(r.Context(), key); err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to reset bucket", "key", key, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) block(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.keyFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req blockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		writeError(w, http.StatusBadRequest, "duration must be a positive duration (e.g. \"10m\")")
+		return
+	}
+	reason := entity.BlockReasonManual
+	if req.Reason != "" {
+		reason = entity.BlockReason(req.Reason)
+	}
+	if reason != entity.BlockReasonManual && reason != entity.BlockReasonDenylist {
+		writeError(w, http.StatusBadRequest, "reason must be 'manual' or 'denylist'")
+		return
+	}
+
+	actor := adminActor(r)
+	info := entity.BlockInfo{
+		Reason:    reason,
+		Source:    actor,
+		BlockedAt: time.Now(),
+		Note:      req.Note,
+	}
+	if err := h.storage.SetBlock(audit.WithActor(r.Context(), actor), key, duration, info); err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to block key", "key", key, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	h.publish(r, entity.AuditEvent{
+		Action:   entity.AuditActionBlock,
+		Key:      key,
+		Time:     info.BlockedAt,
+		Duration: duration,
+		Info:     info,
+		Actor:    actor,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) unblock(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.keyFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	actor := adminActor(r)
+	if err := h.storage.Unblock(audit.WithActor(r.Context(), actor), key); err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to unblock key", "key", key, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	h.publish(r, entity.AuditEvent{
+		Action: entity.AuditActionUnblock,
+		Key:    key,
+		Time:   time.Now(),
+		Actor:  actor,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resetStrikes perdoa as violações anteriores: o próximo bloqueio volta ao tempo base
+func (h *AdminHandler) resetStrikes(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.keyFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.ResetStrikes(r.Context(), key); err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to reset strikes", "key", key, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) listBlocked(w http.ResponseWriter, r *http.Request) {
+	blocked, err := h.storage.ListBlocked(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to list blocked keys", "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	response := make([]blockedKeyResponse, 0, len(blocked))
+	for _, b := range blocked {
+		item := blockedKeyResponse{
+			Key:        b.Key.String(),
+			Type:       string(b.Key.Type),
+			Value:      b.Key.Value,
+			TTLSeconds: b.TTL.Seconds(),
+			Reason:     string(b.Info.Reason),
+			Source:     b.Info.Source,
+		}
+		if !b.Info.BlockedAt.IsZero() {
+			blockedAt := b.Info.BlockedAt.UTC()
+			item.BlockedAt = &blockedAt
+		}
+		response = append(response, item)
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, map[string]interface{}{
+		"count":   len(response),
+		"blocked": response,
+	})
+}
+
+// listAuditEvents lista os últimos eventos de bloqueio/desbloqueio (?limit=N, padrão 100)
+func (h *AdminHandler) listAuditEvents(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAuditLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxAuditLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxAuditLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := h.auditLog.Recent(r.Context(), limit)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to read audit log", "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	response := make([]auditEventResponse, 0, len(events))
+	for _, event := range events {
+		item := auditEventResponse{
+			Action:          string(event.Action),
+			Key:             event.Key.String(),
+			Time:            event.Time.UTC(),
+			DurationSeconds: event.Duration.Seconds(),
+			Actor:           event.Actor,
+		}
+		if event.Action == entity.AuditActionBlock {
+			item.Block = newBlockInfo(event.Info)
+		}
+		response = append(response, item)
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, map[string]interface{}{
+		"count":  len(response),
+		"events": response,
+	})
+}
+
+func (h *AdminHandler) listTokenConfigs(w http.ResponseWriter, r *http.Request) {
+	configs, err := h.tokenConfig.List(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to list token configs", "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	response := make([]tokenConfigResponse, 0, len(configs))
+	for _, cfg := range configs {
+		response = append(response, newTokenConfigResponse(cfg))
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, map[string]interface{}{
+		"count":  len(response),
+		"tokens": response,
+	})
+}
+
+func (h *AdminHandler) getTokenConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.tokenConfig.Get(r.Context(), chi.URLParam(r, "token"))
+	if errors.Is(err, repository.ErrTokenConfigNotFound) {
+		writeError(w, http.StatusNotFound, "token config not found")
+		return
+	}
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to get token config", "token", entity.RedactToken(chi.URLParam(r, "token")), "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, newTokenConfigResponse(*cfg))
+}
+
+func (h *AdminHandler) saveTokenConfig(w http.ResponseWriter, r *http.Request) {
+	var req tokenConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	cfg := entity.TokenConfig{
+		Token: chi.URLParam(r, "token"),
+		Limit: req.Limit,
+	}
+	var err error
+	if cfg.Window, err = time.ParseDuration(req.Window); err != nil {
+		writeError(w, http.StatusBadRequest, "window must be a duration (e.g. \"1s\")")
+		return
+	}
+	if req.BlockTime != "" {
+		if cfg.BlockTime, err = time.ParseDuration(req.BlockTime); err != nil {
+			writeError(w, http.StatusBadRequest, "block_time must be a duration (e.g. \"10m\")")
+			return
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.tokenConfig.Save(r.Context(), cfg); err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to save token config", "token", entity.RedactToken(cfg.Token), "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, newTokenConfigResponse(cfg))
+}
+
+func (h *AdminHandler) deleteTokenConfig(w http.ResponseWriter, r *http.Request) {
+	err := h.tokenConfig.Delete(r.Context(), chi.URLParam(r, "token"))
+	if errors.Is(err, repository.ErrTokenConfigNotFound) {
+		writeError(w, http.StatusNotFound, "token config not found")
+		return
+	}
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Admin failed to delete token config", "token", entity.RedactToken(chi.URLParam(r, "token")), "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publish notifica o publisher (se configurado); falhas não afetam a resposta
+func (h *AdminHandler) publish(r *http.Request, event entity.AuditEvent) {
+	if h.publisher == nil {
+		return
+	}
+	if err := h.publisher.Publish(r.Context(), event); err != nil {
+		h.logger.WarnContext(r.Context(), "Admin failed to publish event", "action", event.Action, "key", event.Key, "error", err)
+	}
+}
+
+// adminActor identifica o operador: "admin" ou "admin:<X-Admin-User>"
+func adminActor(r *http.Request) string {
+	if user := strings.TrimSpace(r.Header.Get(adminUserHeader)); user != "" {
+		return "admin:" + user
+	}
+	return "admin"
+}
+
+// newBlockInfo converte o motivo do bloqueio para a representação JSON
+func newBlockInfo(info entity.BlockInfo) *blockInfo {
+	response := &blockInfo{
+		Reason:  string(info.Reason),
+		Source:  info.Source,
+		Policy:  info.Policy,
+		Limit:   info.Limit,
+		Strikes: info.Strikes,
+		Note:    info.Note,
+	}
+	if !info.BlockedAt.IsZero() {
+		blockedAt := info.BlockedAt.UTC()
+		response.BlockedAt = &blockedAt
+	}
+	if info.Window > 0 {
+		response.Window = info.Window.String()
+	}
+	return response
+}
+
+// newTokenConfigResponse converte a entidade para a representação JSON
+func newTokenConfigResponse(cfg entity.TokenConfig) tokenConfigResponse {
+	return tokenConfigResponse{
+		Token:     cfg.Token,
+		Limit:     cfg.Limit,
+		Window:    cfg.Window.String(),
+		BlockTime: cfg.BlockTime.String(),
+	}
+}
+
+// keyFromRequest monta a LimiterKey a partir dos parâmetros {type} e {value} da rota
+// Para tokens, {value} pode ser a API key (recebe o HMAC) ou o digest "hmac:..." listado em /admin/blocked
+// Escreve 400 e retorna false se a chave for inválida
+func (h *AdminHandler) keyFromRequest(w http.ResponseWriter, r *http.Request) (entity.LimiterKey, bool) {
+	key := entity.LimiterKey{
+		Type:  entity.KeyType(chi.URLParam(r, "type")),
+		Value: chi.URLParam(r, "value"),
+	}
+	if !key.Type.IsValid() || !key.IsValid() {
+		writeError(w, http.StatusBadRequest, "invalid key: type must be 'ip', 'token' or 'outbound'")
+		return entity.LimiterKey{}, false
+	}
+	if key.Type == entity.KeyTypeToken && !entity.IsTokenDigest(key.Value) {
+		key = h.hasher.Key(key.Value)
+	}
+	return key, true
+}
+
+// writeJSON envia uma resposta JSON com o status informado
+func writeJSON(w http.ResponseWriter, logger *slog.Logger, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Warn("Failed to encode JSON response", "error", err)
+	}
+}
+
+// writeError envia uma resposta de erro no formato {"error": "..."}
+// O body é sempre codificável: uma falha aqui só ocorre se o cliente já desconectou
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,394 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/audit"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/file"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+const testAdminToken = "s3cret"
+
+// newAdminServer cria o router administrativo sobre um storage em memória
+func newAdminServer() (http.Handler, *memory.MemoryStorage) {
+	storage := memory.NewMemoryStorage()
+	return NewAdminHandler(storage, nil, nil, testAdminToken, entity.TokenHasher{}, discardLogger()).Routes(), storage
+}
+
+// discardLogger descarta os logs dos handlers nos testes
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// failingTokenConfigStore falha em todas as escritas
+type failingTokenConfigStore struct {
+	repository.TokenConfigStore
+}
+
+func (failingTokenConfigStore) Save(ctx context.Context, cfg entity.TokenConfig) error {
+	return errors.New("disk full")
+}
+
+// doAdminRequest executa uma requisição autenticada contra o router
+func doAdminRequest(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminHandler_RejectsMissingOrWrongToken(t *testing.T) {
+	router, _ := newAdminServer()
+
+	for _, header := range []string{"", "Bearer wrong", testAdminToken} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/blocked", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "header %q", header)
+	}
+}
+
+func TestAdminHandler_GetKeyState_ReturnsTokensAndBlock(t *testing.T) {
+	// Arrange
+	router, storage := newAdminServer()
+	ctx := context.Background()
+	key := entity.NewIPKey("192.168.1.1")
+
+	_, err := storage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+	require.NoError(t, err)
+	require.NoError(t, storage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{}))
+
+	// Act
+	w := doAdminRequest(router, http.MethodGet, "/admin/keys/ip/192.168.1.1", "")
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body keyStateResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, "rate_limit:ip:192.168.1.1", body.Key)
+	assert.True(t, body.Exists)
+	assert.Equal(t, 9.0, body.Tokens)
+	assert.NotNil(t, body.LastRefill)
+	assert.True(t, body.Blocked)
+	assert.InDelta(t, 60, body.BlockTTLSeconds, 1)
+}
+
+func TestAdminHandler_WithTokenHasher_AcceptsTokenOrDigest(t *testing.T) {
+	// Arrange
+	storage := memory.NewMemoryStorage()
+	hasher := entity.NewTokenHasher("0123456789abcdef0123456789abcdef")
+	router := NewAdminHandler(storage, nil, nil, testAdminToken, hasher, discardLogger()).Routes()
+	key := hasher.Key("abc123")
+	require.NoError(t, storage.SetBlock(context.Background(), key, time.Minute, entity.BlockInfo{}))
+
+	for _, value := range []string{"abc123", key.Value} {
+		// Act
+		w := doAdminRequest(router, http.MethodGet, "/admin/keys/token/"+value, "")
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var body keyStateResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, key.String(), body.Key)
+		assert.True(t, body.Blocked)
+		assert.NotContains(t, body.Key, "abc123")
+	}
+}
+
+func TestAdminHandler_InvalidKeyType_ReturnsBadRequest(t *testing.T) {
+	router, _ := newAdminServer()
+
+	w := doAdminRequest(router, http.MethodGet, "/admin/keys/user/abc", "")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminHandler_BlockAndUnblock(t *testing.T) {
+	// Arrange
+	router, storage := newAdminServer()
+	ctx := context.Background()
+	key := entity.NewTokenKey("abc123")
+
+	// Act - bloqueio manual
+	w := doAdminRequest(router, http.MethodPut, "/admin/keys/token/abc123/block", `{"duration":"10m"}`)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	blocked, err := storage.IsBlocked(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	w = doAdminRequest(router, http.MethodGet, "/admin/blocked", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+	assert.Contains(t, w.Body.String(), "rate_limit:token:abc123")
+
+	// Act - desbloqueio
+	w = doAdminRequest(router, http.MethodDelete, "/admin/keys/token/abc123/block", "")
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	blocked, err = storage.IsBlocked(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, blocked)
+}
+
+func TestAdminHandler_Block_RecordsReasonAndAuditTrail(t *testing.T) {
+	// Arrange
+	auditLog, err := file.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer auditLog.Close()
+	storage := audit.NewAuditedStorage(memory.NewMemoryStorage(), auditLog, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewAdminHandler(storage, nil, auditLog, testAdminToken, entity.TokenHasher{}, discardLogger()).Routes()
+
+	// Act - bloqueio por deny list identificado pelo operador, depois desbloqueio
+	req := httptest.NewRequest(http.MethodPut, "/admin/keys/ip/10.0.0.1/block",
+		strings.NewReader(`{"duration":"1h","reason":"denylist","note":"scraper"}`))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	req.Header.Set("X-Admin-User", "alice")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	state := doAdminRequest(router, http.MethodGet, "/admin/keys/ip/10.0.0.1", "")
+	blocked := doAdminRequest(router, http.MethodGet, "/admin/blocked", "")
+	require.Equal(t, http.StatusNoContent, doAdminRequest(router, http.MethodDelete, "/admin/keys/ip/10.0.0.1/block", "").Code)
+	trail := doAdminRequest(router, http.MethodGet, "/admin/audit?limit=10", "")
+
+	// Assert - motivo no estado da chave e na listagem
+	var body keyStateResponse
+	require.NoError(t, json.NewDecoder(state.Body).Decode(&body))
+	require.NotNil(t, body.Block)
+	assert.Equal(t, "denylist", body.Block.Reason)
+	assert.Equal(t, "admin:alice", body.Block.Source)
+	assert.Equal(t, "scraper", body.Block.Note)
+	assert.NotNil(t, body.Block.BlockedAt)
+	assert.Contains(t, blocked.Body.String(), `"reason":"denylist"`)
+
+	// Assert - bloqueio e desbloqueio na trilha, mais recente primeiro
+	require.Equal(t, http.StatusOK, trail.Code)
+	var events struct {
+		Count  int                  `json:"count"`
+		Events []auditEventResponse `json:"events"`
+	}
+	require.NoError(t, json.NewDecoder(trail.Body).Decode(&events))
+	require.Equal(t, 2, events.Count)
+	assert.Equal(t, "unblock", events.Events[0].Action)
+	assert.Equal(t, "admin", events.Events[0].Actor)
+	assert.Equal(t, "block", events.Events[1].Action)
+	assert.Equal(t, "rate_limit:ip:10.0.0.1", events.Events[1].Key)
+	assert.Equal(t, 3600.0, events.Events[1].DurationSeconds)
+	require.NotNil(t, events.Events[1].Block)
+	assert.Equal(t, "denylist", events.Events[1].Block.Reason)
+}
+
+// recordingPublisher guarda os eventos publicados pelo handler
+type recordingPublisher struct {
+	events []entity.AuditEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event entity.AuditEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestAdminHandler_WithEventPublisher_PublishesBlockAndUnblock(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	router := NewAdminHandler(memory.NewMemoryStorage(), nil, nil, testAdminToken, entity.TokenHasher{}, discardLogger()).
+		WithEventPublisher(publisher).
+		Routes()
+
+	// Act
+	doAdminRequest(router, http.MethodPut, "/admin/keys/ip/10.0.0.1/block", `{"duration":"10m"}`)
+	doAdminRequest(router, http.MethodDelete, "/admin/keys/ip/10.0.0.1/block", "")
+
+	// Assert
+	require.Len(t, publisher.events, 2)
+	assert.Equal(t, entity.AuditActionBlock, publisher.events[0].Action)
+	assert.Equal(t, entity.BlockReasonManual, publisher.events[0].Info.Reason)
+	assert.Equal(t, 10*time.Minute, publisher.events[0].Duration)
+	assert.Equal(t, entity.AuditActionUnblock, publisher.events[1].Action)
+	assert.Equal(t, "admin", publisher.events[1].Actor)
+}
+
+func TestAdminHandler_Block_InvalidReason_ReturnsBadRequest(t *testing.T) {
+	router, _ := newAdminServer()
+
+	for _, reason := range []string{"rate_exceeded", "brute_force", "whatever"} {
+		w := doAdminRequest(router, http.MethodPut, "/admin/keys/ip/10.0.0.1/block", `{"duration":"10m","reason":"`+reason+`"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, reason)
+	}
+}
+
+func TestAdminHandler_AuditRoutes(t *testing.T) {
+	auditLog, err := file.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer auditLog.Close()
+	router := NewAdminHandler(memory.NewMemoryStorage(), nil, auditLog, testAdminToken, entity.TokenHasher{}, discardLogger()).Routes()
+	disabled, _ := newAdminServer()
+
+	for _, query := range []string{"?limit=0", "?limit=abc", "?limit=5000"} {
+		w := doAdminRequest(router, http.MethodGet, "/admin/audit"+query, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	assert.Equal(t, http.StatusOK, doAdminRequest(router, http.MethodGet, "/admin/audit", "").Code)
+	assert.Equal(t, http.StatusNotFound, doAdminRequest(disabled, http.MethodGet, "/admin/audit", "").Code)
+}
+
+func TestAdminHandler_Block_InvalidDuration_ReturnsBadRequest(t *testing.T) {
+	router, _ := newAdminServer()
+
+	for _, body := range []string{`{"duration":"forever"}`, `{"duration":"-1m"}`, `not json`} {
+		w := doAdminRequest(router, http.MethodPut, "/admin/keys/ip/10.0.0.1/block", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestAdminHandler_Strikes_ReportedAndReset(t *testing.T) {
+	// Arrange
+	router, storage := newAdminServer()
+	ctx := context.Background()
+	key := entity.NewIPKey("192.168.1.1")
+	for i := 0; i < 2; i++ {
+		_, err := storage.AddStrike(ctx, key, time.Hour)
+		require.NoError(t, err)
+	}
+
+	// Act
+	before := doAdminRequest(router, http.MethodGet, "/admin/keys/ip/192.168.1.1", "")
+	reset := doAdminRequest(router, http.MethodDelete, "/admin/keys/ip/192.168.1.1/strikes", "")
+	after := doAdminRequest(router, http.MethodGet, "/admin/keys/ip/192.168.1.1", "")
+
+	// Assert
+	assert.Contains(t, before.Body.String(), `"strikes":2`)
+	assert.Equal(t, http.StatusNoContent, reset.Code)
+	assert.Contains(t, after.Body.String(), `"strikes":0`)
+}
+
+func TestAdminHandler_ResetBucket_RestoresFullBucket(t *testing.T) {
+	// Arrange
+	router, storage := newAdminServer()
+	ctx := context.Background()
+	key := entity.NewIPKey("10.0.0.1")
+
+	_, err := storage.CheckAndConsume(ctx, key, 1, time.Minute, 1)
+	require.NoError(t, err)
+
+	// Act
+	w := doAdminRequest(router, http.MethodDelete, "/admin/keys/ip/10.0.0.1/bucket", "")
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	result, err := storage.CheckAndConsume(ctx, key, 1, time.Minute, 1)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestAdminHandler_TokenConfigCRUD(t *testing.T) {
+	// Arrange
+	store := file.NewTokenConfigStore(filepath.Join(t.TempDir(), "tokens.json"))
+	router := NewAdminHandler(memory.NewMemoryStorage(), store, nil, testAdminToken, entity.TokenHasher{}, discardLogger()).Routes()
+
+	// Act - cria
+	w := doAdminRequest(router, http.MethodPut, "/admin/tokens/abc123", `{"limit":100,"window":"1s","block_time":"10m"}`)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	cfg, err := store.Get(context.Background(), "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Limit)
+	assert.Equal(t, time.Second, cfg.Window)
+	assert.Equal(t, 10*time.Minute, cfg.BlockTime)
+
+	// Act - consulta e lista
+	w = doAdminRequest(router, http.MethodGet, "/admin/tokens/abc123", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":100`)
+
+	w = doAdminRequest(router, http.MethodGet, "/admin/tokens", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+
+	// Act - remove
+	w = doAdminRequest(router, http.MethodDelete, "/admin/tokens/abc123", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = doAdminRequest(router, http.MethodGet, "/admin/tokens/abc123", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doAdminRequest(router, http.MethodDelete, "/admin/tokens/abc123", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminHandler_SaveTokenConfig_WithoutBlockTime_DisablesBlocking(t *testing.T) {
+	// Arrange
+	store := file.NewTokenConfigStore(filepath.Join(t.TempDir(), "tokens.json"))
+	router := NewAdminHandler(memory.NewMemoryStorage(), store, nil, testAdminToken, entity.TokenHasher{}, discardLogger()).Routes()
+
+	// Act
+	w := doAdminRequest(router, http.MethodPut, "/admin/tokens/abc123", `{"limit":100,"window":"1s"}`)
+
+	// Assert - block_time é opcional: zero rejeita acima do limite sem bloquear a chave
+	require.Equal(t, http.StatusOK, w.Code)
+	cfg, err := store.Get(context.Background(), "abc123")
+	require.NoError(t, err)
+	assert.Zero(t, cfg.BlockTime)
+}
+
+func TestAdminHandler_StorageFailure_LogsRedactedToken(t *testing.T) {
+	// Arrange
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	router := NewAdminHandler(memory.NewMemoryStorage(), failingTokenConfigStore{}, nil, testAdminToken, entity.TokenHasher{}, logger).Routes()
+
+	// Act
+	w := doAdminRequest(router, http.MethodPut, "/admin/tokens/secret-api-key", `{"limit":100,"window":"1s"}`)
+
+	// Assert - a falha é registrada sem o valor do token
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, logs.String(), "disk full")
+	assert.Contains(t, logs.String(), entity.RedactToken("secret-api-key"))
+	assert.NotContains(t, logs.String(), "secret-api-key")
+}
+
+func TestAdminHandler_SaveTokenConfig_InvalidBody_ReturnsBadRequest(t *testing.T) {
+	store := file.NewTokenConfigStore(filepath.Join(t.TempDir(), "tokens.json"))
+	router := NewAdminHandler(memory.NewMemoryStorage(), store, nil, testAdminToken, entity.TokenHasher{}, discardLogger()).Routes()
+
+	for _, body := range []string{`{"limit":0,"window":"1s"}`, `{"limit":10,"window":"soon"}`, `{"limit":10}`, `nope`} {
+		w := doAdminRequest(router, http.MethodPut, "/admin/tokens/abc123", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestAdminHandler_TokenRoutesDisabledWithoutStore(t *testing.T) {
+	router, _ := newAdminServer()
+
+	w := doAdminRequest(router, http.MethodGet, "/admin/tokens", "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// Políticas aceitas no campo "policy" do /v1/check
+const (
+	decisionPolicyIP    = "ip"
+	decisionPolicyToken = "token"
+	decisionPolicyRoute = "route"
+)
+
+// policyExplicit identifica nas métricas as decisões com limite informado pelo chamador
+// Também é o escopo dos buckets dessas decisões (ver explicitScope)
+const policyExplicit = "explicit"
+
+// maxExplicitBlockTime limita o block_time informado pelo chamador no modo explícito
+const maxExplicitBlockTime = 24 * time.Hour
+
+// maxNamespaceLength limita o tamanho do campo namespace
+const maxNamespaceLength = 64
+
+// errExplicitRequiresToken é retornado quando o modo explícito é usado sem DECISION_API_TOKEN
+var errExplicitRequiresToken = errors.New("explicit limits require DECISION_API_TOKEN to be set")
+
+// maxDecisionBody limita o tamanho do body do /v1/check
+const maxDecisionBody = 64 << 10
+
+// DecisionHandler expõe o rate limiter como serviço de decisão (POST /v1/check)
+// Permite que serviços em outras linguagens consultem o limiter como sidecar ou serviço central:
+// o chamador informa a chave e a política (ou o limite explícito) e recebe a decisão
+type DecisionHandler struct {
+	useCase middleware.UseCase
+	storage repository.Storage
+	config  middleware.Config
+	token   string
+	logger  *slog.Logger
+}
+
+// NewDecisionHandler cria o handler de decisão
+// useCase é o mesmo usado pelo middleware (métricas, tracing e eventos incluídos)
+// storage é consultado apenas para informar quanto tempo resta de um bloqueio já existente
+// config resolve as políticas ip, token e route (com snapshot por requisição quando recarregável)
+// token é o Bearer token exigido (DECISION_API_TOKEN); vazio desabilita a autenticação
+// logger registra as falhas do storage (a chave é registrada sem o valor do token)
+func NewDecisionHandler(useCase middleware.UseCase, storage repository.Storage, config middleware.Config, token string, logger *slog.Logger) *DecisionHandler {
+	return &DecisionHandler{
+		useCase: useCase,
+		storage: storage,
+		config:  config,
+		token:   token,
+		logger:  logger,
+	}
+}
+
+// decisionRequest é o body do /v1/check
+// Com limit > 0 a política é ignorada e são usados limit, window e block_time, em buckets
+// separados dos do middleware (ver explicitScope)
+type decisionRequest struct {
+	KeyType  string `json:"key_type"`  // "ip" ou "token"
+	KeyValue string `json:"key_value"` // IP ou API key (o HMAC é aplicado aqui, como no middleware)
+	Policy   string `json:"policy,omitempty"`
+	Method   string `json:"method,omitempty"` // Política "route": rota a ser casada com RATE_LIMIT_ROUTES
+	Path     string `json:"path,omitempty"`
+
+	Limit     int    `json:"limit,omitempty"`
+	Window    string `json:"window,omitempty"`     // Ex: "1s"
+	BlockTime string `json:"block_time,omitempty"` // Ex: "10m" (no máximo maxExplicitBlockTime)
+	Namespace string `json:"namespace,omitempty"`  // Separa os buckets explícitos de chamadores diferentes
+
+	Cost int `json:"cost,omitempty"` // Tokens consumidos (padrão 1)
+}
+
+// decisionResponse é a representação JSON da decisão (check_rate_limit.Output mais reset/retry)
+type decisionResponse struct {
+	Allowed          bool    `json:"allowed"`
+	Decision         string  `json:"decision"`
+	Blocked          bool    `json:"blocked"`
+	CurrentTokens    float64 `json:"current_tokens"`
+	Limit            int     `json:"limit"`
+	Cost             int     `json:"cost"`
+	Policy           string  `json:"policy"`
+	Message          string  `json:"message,omitempty"`
+	BlockTimeSeconds float64 `json:"block_time_seconds,omitempty"`
+	Strikes          int     `json:"strikes,omitempty"`
+
+	// ResetAfterSeconds é o tempo até o bucket voltar a ficar cheio (ou até o fim do bloqueio)
+	ResetAfterSeconds float64 `json:"reset_after_seconds"`
+	// RetryAfterSeconds é o tempo até uma nova requisição com o mesmo custo poder ser aceita (0 quando permitida)
+	RetryAfterSeconds float64 `json:"retry_after_seconds,omitempty"`
+}
+
+// Routes monta o router da API de decisão
+//
+//	POST /v1/check  {"key_type": "token", "key_value": "abc", "policy": "token", "cost": 1}
+//	                {"key_type": "ip", "key_value": "10.0.0.1", "policy": "route", "method": "POST", "path": "/login"}
+//	                {"key_type": "ip", "key_value": "10.0.0.1", "limit": 10, "window": "1s", "block_time": "1m"}
+//
+// A resposta é sempre 200 com a decisão (o chamador decide como rejeitar); 400 para body inválido
+func (h *DecisionHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(h.authenticate)
+	r.Post("/v1/check", h.check)
+	return r
+}
+
+// authenticate exige o Bearer token quando configurado
+func (h *DecisionHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" && !hasBearerToken(r, h.token) {
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *DecisionHandler) check(w http.ResponseWriter, r *http.Request) {
+	var req decisionRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDecisionBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	input, err := h.buildInput(req)
+	if errors.Is(err, errExplicitRequiresToken) {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	output, err := h.useCase.Execute(r.Context(), input)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Decision API rate limit check failed", "key", input.Key, "policy", input.Policy, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	response := decisionResponse{
+		Allowed:          output.Allowed,
+		Decision:         output.Decision(),
+		Blocked:          output.Blocked,
+		CurrentTokens:    output.CurrentTokens,
+		Limit:            input.Limit,
+		Cost:             input.TokenCost(),
+		Policy:           input.Policy,
+		Message:          output.Message,
+		BlockTimeSeconds: output.BlockTime.Seconds(),
+		Strikes:          output.Strikes,
+	}
+
+	resetAfter, retryAfter, err := middleware.RateLimitTimes(r.Context(), h.storage, input, output)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "Decision API failed to get block TTL", "key", input.Key, "error", err)
+	}
+	response.ResetAfterSeconds = resetAfter.Seconds()
+	response.RetryAfterSeconds = retryAfter.Seconds()
+
+	if response.RetryAfterSeconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(response.RetryAfterSeconds))))
+	}
+	writeJSON(w, h.logger, http.StatusOK, response)
+}
+
+// buildInput resolve a chave e o limite da requisição
+func (h *DecisionHandler) buildInput(req decisionRequest) (check_rate_limit.Input, error) {
+	cfg := h.snapshotConfig()
+
+	if req.KeyValue == "" {
+		return check_rate_limit.Input{}, errors.New("key_value is required")
+	}
+	var key entity.LimiterKey
+	switch entity.KeyType(req.KeyType) {
+	case entity.KeyTypeIP:
+		key = entity.NewIPKey(req.KeyValue)
+	case entity.KeyTypeToken:
+		key = cfg.TokenKey(req.KeyValue)
+	default:
+		return check_rate_limit.Input{}, errors.New("key_type must be 'ip' or 'token'")
+	}
+
+	input := check_rate_limit.Input{Key: key, Cost: req.Cost}
+
+	if req.Limit > 0 {
+		// Sem autenticação qualquer um poderia criar bloqueios longos com limit=1
+		if h.token == "" {
+			return check_rate_limit.Input{}, errExplicitRequiresToken
+		}
+		window, err := parsePositiveDuration("window", req.Window)
+		if err != nil {
+			return check_rate_limit.Input{}, err
+		}
+		blockTime, err := parsePositiveDuration("block_time", req.BlockTime)
+		if err != nil {
+			return check_rate_limit.Input{}, err
+		}
+		if blockTime > maxExplicitBlockTime {
+			return check_rate_limit.Input{}, fmt.Errorf("block_time cannot exceed %s", maxExplicitBlockTime)
+		}
+		scope, err := explicitScope(req.Namespace)
+		if err != nil {
+			return check_rate_limit.Input{}, err
+		}
+		// Bucket próprio: um limite explícito nunca bloqueia o IP/token no middleware
+		input.Key = key.Scoped(scope)
+		input.Limit = req.Limit
+		input.Window = window
+		input.BlockTime = blockTime
+		input.Policy = policyExplicit
+		return input, input.Validate()
+	}
+
+	policy := req.Policy
+	if policy == "" {
+		policy = req.KeyType
+	}
+
+	switch policy {
+	case decisionPolicyIP:
+		if key.Type != entity.KeyTypeIP {
+			return check_rate_limit.Input{}, errors.New("policy 'ip' requires key_type 'ip'")
+		}
+		input.Limit = cfg.GetIPLimit()
+		input.Window = cfg.GetIPWindow()
+		input.BlockTime = cfg.GetIPBlockTime()
+		input.Policy = check_rate_limit.PolicyIP
+	case decisionPolicyToken:
+		if key.Type != entity.KeyTypeToken {
+			return check_rate_limit.Input{}, errors.New("policy 'token' requires key_type 'token'")
+		}
+		tokenConfig, exists := cfg.GetTokenConfig(req.KeyValue)
+		if !exists {
+			return check_rate_limit.Input{}, errors.New("no limit configured for this token")
+		}
+		input.Limit = tokenConfig.Limit
+		input.Window = tokenConfig.Window
+		input.BlockTime = tokenConfig.BlockTime
+		input.Policy = check_rate_limit.PolicyToken
+	case decisionPolicyRoute:
+		route, exists := cfg.GetRouteConfig(req.Method, req.Path)
+		if !exists {
+			return check_rate_limit.Input{}, errors.New("no route limit matches method and path")
+		}
+		// Mesmo bucket usado pelo middleware: cliente separado por rota
+		input.Key = key.Scoped(route.Name)
+		input.Limit = route.Limit
+		input.Window = route.Window
+		input.BlockTime = route.BlockTime
+		input.Policy = check_rate_limit.RoutePolicy(route.Name)
+	default:
+		return check_rate_limit.Input{}, errors.New("policy must be 'ip', 'token' or 'route' (or set limit, window and block_time)")
+	}
+
+	return input, input.Validate()
+}
+
+// explicitScope retorna o escopo dos buckets do modo explícito: "explicit" ou "explicit:<namespace>"
+// O namespace aceita apenas letras, dígitos, '-', '_' e '.'
+func explicitScope(namespace string) (string, error) {
+	if namespace == "" {
+		return policyExplicit, nil
+	}
+	if len(namespace) > maxNamespaceLength {
+		return "", fmt.Errorf("namespace cannot exceed %d characters", maxNamespaceLength)
+	}
+	for _, c := range namespace {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return "", errors.New("namespace may only contain letters, digits, '-', '_' and '.'")
+		}
+	}
+	return policyExplicit + ":" + namespace, nil
+}
+
+// snapshotConfig retorna a configuração a ser usada durante toda a requisição
+func (h *DecisionHandler) snapshotConfig() middleware.Config {
+	if snapshotter, ok := h.config.(middleware.ConfigSnapshotter); ok {
+		return snapshotter.Snapshot()
+	}
+	return h.config
+}
+
+// parsePositiveDuration converte um campo de duração obrigatório do body
+func parsePositiveDuration(field, value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration (e.g. \"1s\")", field)
+	}
+	return duration, nil
+}
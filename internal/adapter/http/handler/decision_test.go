@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+const testDecisionToken = "decision-s3cret"
+
+// stubConfig é uma configuração fixa: IP 2 req/s, token "partner" 5 req/s e rota POST /login 1 req/min
+type stubConfig struct{}
+
+func (stubConfig) GetIPLimit() int                                 { return 2 }
+func (stubConfig) GetIPWindow() time.Duration                      { return time.Second }
+func (stubConfig) GetIPBlockTime() time.Duration                   { return time.Minute }
+func (stubConfig) CheckAccess(ip, apiKey string) middleware.Access { return middleware.AccessDefault }
+func (stubConfig) TokenKey(apiKey string) entity.LimiterKey        { return entity.NewTokenKey(apiKey) }
+func (stubConfig) GetUnknownTokenPolicy() middleware.UnknownTokenPolicy {
+	return middleware.UnknownTokenPolicy{Mode: middleware.UnknownTokenAnonymous}
+}
+
+func (stubConfig) GetTokenConfig(token string) (middleware.TokenConfig, bool) {
+	if token != "partner" {
+		return middleware.TokenConfig{}, false
+	}
+	return middleware.TokenConfig{Limit: 5, Window: time.Second, BlockTime: time.Minute}, true
+}
+
+func (stubConfig) GetRouteConfig(method, path string) (middleware.RouteConfig, bool) {
+	if method != http.MethodPost || path != "/login" {
+		return middleware.RouteConfig{}, false
+	}
+	return middleware.RouteConfig{Name: "login", Limit: 1, Window: time.Minute, BlockTime: 5 * time.Minute}, true
+}
+
+// newDecisionServer cria o router de decisão sobre um storage em memória e o use case real
+func newDecisionServer(token string) (http.Handler, *memory.MemoryStorage) {
+	storage := memory.NewMemoryStorage()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	useCase := check_rate_limit.NewUseCase(storage, logger)
+	return NewDecisionHandler(useCase, storage, stubConfig{}, token, logger).Routes(), storage
+}
+
+// doCheck envia o body para POST /v1/check
+func doCheck(t *testing.T, router http.Handler, body string) (*httptest.ResponseRecorder, decisionResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/check", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testDecisionToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response decisionResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	}
+	return w, response
+}
+
+func TestDecisionHandler_RequiresTokenWhenConfigured(t *testing.T) {
+	router, _ := newDecisionServer(testDecisionToken)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/check", strings.NewReader(`{"key_type":"ip","key_value":"10.0.0.1"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestDecisionHandler_IPPolicy_AllowsThenRejects(t *testing.T) {
+	// Arrange
+	router, _ := newDecisionServer(testDecisionToken)
+	body := `{"key_type":"ip","key_value":"10.0.0.1"}`
+
+	// Act
+	_, first := doCheck(t, router, body)
+	_, second := doCheck(t, router, body)
+	w, third := doCheck(t, router, body)
+	_, fourth := doCheck(t, router, body)
+
+	// Assert
+	assert.True(t, first.Allowed)
+	assert.Equal(t, check_rate_limit.PolicyIP, first.Policy)
+	assert.Equal(t, 1.0, first.CurrentTokens)
+	assert.InDelta(t, 0.5, first.ResetAfterSeconds, 0.01)
+	assert.True(t, second.Allowed)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, third.Allowed)
+	assert.Equal(t, check_rate_limit.DecisionRejected, third.Decision)
+	assert.Equal(t, 60.0, third.BlockTimeSeconds)
+	assert.Equal(t, 60.0, third.RetryAfterSeconds)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, check_rate_limit.DecisionBlocked, fourth.Decision)
+	assert.InDelta(t, 60, fourth.RetryAfterSeconds, 1)
+}
+
+func TestDecisionHandler_TokenPolicy_UsesTokenLimitAndCost(t *testing.T) {
+	// Arrange
+	router, storage := newDecisionServer(testDecisionToken)
+
+	// Act
+	_, response := doCheck(t, router, `{"key_type":"token","key_value":"partner","cost":3}`)
+
+	// Assert
+	assert.True(t, response.Allowed)
+	assert.Equal(t, check_rate_limit.PolicyToken, response.Policy)
+	assert.Equal(t, 5, response.Limit)
+	assert.Equal(t, 3, response.Cost)
+	assert.Equal(t, 2.0, response.CurrentTokens)
+
+	state, err := storage.GetKeyState(context.Background(), entity.NewTokenKey("partner"))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, state.Tokens)
+}
+
+func TestDecisionHandler_RoutePolicy_ScopesBucketByRoute(t *testing.T) {
+	// Arrange
+	router, storage := newDecisionServer(testDecisionToken)
+
+	// Act
+	_, response := doCheck(t, router, `{"key_type":"ip","key_value":"10.0.0.1","policy":"route","method":"POST","path":"/login"}`)
+
+	// Assert - mesmo bucket usado pelo middleware para a rota
+	assert.True(t, response.Allowed)
+	assert.Equal(t, check_rate_limit.RoutePolicy("login"), response.Policy)
+
+	state, err := storage.GetKeyState(context.Background(), entity.NewIPKey("10.0.0.1").Scoped("login"))
+	require.NoError(t, err)
+	assert.True(t, state.Exists)
+}
+
+func TestDecisionHandler_ExplicitLimit(t *testing.T) {
+	// Arrange
+	router, _ := newDecisionServer(testDecisionToken)
+	body := `{"key_type":"ip","key_value":"10.0.0.9","limit":10,"window":"10s","block_time":"30s","cost":10}`
+
+	// Act
+	_, first := doCheck(t, router, body)
+
+	// Assert
+	assert.True(t, first.Allowed)
+	assert.Equal(t, "explicit", first.Policy)
+	assert.Equal(t, 0.0, first.CurrentTokens)
+	assert.InDelta(t, 10, first.ResetAfterSeconds, 0.01)
+}
+
+func TestDecisionHandler_ExplicitLimit_UsesOwnBucketPerNamespace(t *testing.T) {
+	// Arrange
+	router, storage := newDecisionServer(testDecisionToken)
+	body := `{"key_type":"ip","key_value":"10.0.0.9","limit":1,"window":"1m","block_time":"1h","namespace":"billing"}`
+
+	// Act - a segunda requisição estoura o limite e bloqueia o bucket explícito
+	doCheck(t, router, body)
+	_, second := doCheck(t, router, body)
+	_, ipPolicy := doCheck(t, router, `{"key_type":"ip","key_value":"10.0.0.9"}`)
+
+	// Assert - o bucket do middleware (política ip) não é afetado
+	assert.Equal(t, check_rate_limit.DecisionRejected, second.Decision)
+	assert.True(t, ipPolicy.Allowed)
+
+	blocked, err := storage.IsBlocked(context.Background(), entity.NewIPKey("10.0.0.9").Scoped("explicit:billing"))
+	require.NoError(t, err)
+	assert.True(t, blocked)
+	blocked, err = storage.IsBlocked(context.Background(), entity.NewIPKey("10.0.0.9"))
+	require.NoError(t, err)
+	assert.False(t, blocked)
+}
+
+func TestDecisionHandler_ExplicitLimit_RequiresToken(t *testing.T) {
+	// Arrange
+	router, _ := newDecisionServer("")
+	body := `{"key_type":"ip","key_value":"10.0.0.9","limit":1,"window":"1s","block_time":"1m"}`
+
+	// Act
+	w, _ := doCheck(t, router, body)
+	policyW, _ := doCheck(t, router, `{"key_type":"ip","key_value":"10.0.0.9"}`)
+
+	// Assert - as políticas configuradas continuam disponíveis sem token
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusOK, policyW.Code)
+}
+
+func TestDecisionHandler_InvalidRequests_Return400(t *testing.T) {
+	tests := map[string]string{
+		"invalid JSON":        `{`,
+		"unknown field":       `{"key_type":"ip","key_value":"10.0.0.1","foo":1}`,
+		"missing key value":   `{"key_type":"ip"}`,
+		"invalid key type":    `{"key_type":"user","key_value":"alice"}`,
+		"unknown token":       `{"key_type":"token","key_value":"nope"}`,
+		"policy mismatch":     `{"key_type":"ip","key_value":"10.0.0.1","policy":"token"}`,
+		"unmatched route":     `{"key_type":"ip","key_value":"10.0.0.1","policy":"route","method":"GET","path":"/"}`,
+		"missing window":      `{"key_type":"ip","key_value":"10.0.0.1","limit":10,"block_time":"1m"}`,
+		"block time too long": `{"key_type":"ip","key_value":"10.0.0.1","limit":1,"window":"1s","block_time":"8760h"}`,
+		"invalid namespace":   `{"key_type":"ip","key_value":"10.0.0.1","limit":1,"window":"1s","block_time":"1m","namespace":"a|b"}`,
+		"cost above limit":    `{"key_type":"ip","key_value":"10.0.0.1","cost":3}`,
+		"negative cost":       `{"key_type":"ip","key_value":"10.0.0.1","cost":-1}`,
+		"unknown policy name": `{"key_type":"ip","key_value":"10.0.0.1","policy":"global"}`,
+	}
+
+	router, _ := newDecisionServer(testDecisionToken)
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			w, _ := doCheck(t, router, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+// failingUseCase simula o storage fora do ar
+type failingUseCase struct{}
+
+func (failingUseCase) Execute(ctx context.Context, input check_rate_limit.Input) (*check_rate_limit.Output, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func TestDecisionHandler_UseCaseError_LogsRedactedKey(t *testing.T) {
+	// Arrange
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	router := NewDecisionHandler(failingUseCase{}, memory.NewMemoryStorage(), stubConfig{}, testDecisionToken, logger).Routes()
+
+	// Act
+	w, _ := doCheck(t, router, `{"key_type":"token","key_value":"partner"}`)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, logs.String(), `"msg":"Decision API rate limit check failed"`)
+	assert.Contains(t, logs.String(), entity.RedactToken("partner"))
+	assert.NotContains(t, logs.String(), "partner")
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/http/middleware"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// Headers com a requisição original enviados pelo nginx (auth_request) e pelo Traefik (forwardAuth)
+// O nginx não envia método nem URI por padrão: configure proxy_set_header X-Original-Method/X-Original-URI
+var (
+	originalMethodHeaders = []string{"X-Original-Method", "X-Forwarded-Method"}
+	originalURIHeaders    = []string{"X-Original-URI", "X-Forwarded-Uri"}
+)
+
+// Headers de rate limit da resposta, para o proxy copiar na resposta ao cliente
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// ForwardAuthHandler implementa o endpoint de forward auth (nginx auth_request / Traefik forwardAuth)
+// Reconstrói a requisição original a partir dos headers do proxy e aplica as mesmas políticas do
+// middleware (Rota > Token > IP): 200 libera, 429 rejeita, 403 deny list e 401 API key inválida
+type ForwardAuthHandler struct {
+	useCase middleware.UseCase
+	storage repository.Storage
+	config  middleware.Config
+	logger  *slog.Logger
+}
+
+// NewForwardAuthHandler cria o handler de forward auth
+// useCase é o mesmo usado pelo middleware (métricas, tracing e eventos incluídos)
+// storage é consultado apenas para informar quanto tempo resta de um bloqueio já existente
+// config resolve as políticas ip, token e route (com snapshot por requisição quando recarregável)
+// logger registra as falhas do storage (a chave é registrada sem o valor do token)
+func NewForwardAuthHandler(useCase middleware.UseCase, storage repository.Storage, config middleware.Config, logger *slog.Logger) *ForwardAuthHandler {
+	return &ForwardAuthHandler{
+		useCase: useCase,
+		storage: storage,
+		config:  config,
+		logger:  logger,
+	}
+}
+
+// Routes monta o router do forward auth
+//
+//	ANY /auth   X-Original-Method: POST
+//	            X-Original-URI: /login?next=/home
+//	            X-Forwarded-For: 203.0.113.7
+//	            API_KEY: abc123
+//
+// A resposta traz X-RateLimit-Limit, X-RateLimit-Remaining e X-RateLimit-Reset (e Retry-After no 429)
+func (h *ForwardAuthHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.HandleFunc("/auth", h.check)
+	return r
+}
+
+func (h *ForwardAuthHandler) check(w http.ResponseWriter, r *http.Request) {
+	cfg := h.snapshotConfig()
+
+	method := firstHeader(r, originalMethodHeaders, r.Method)
+	path := originalPath(firstHeader(r, originalURIHeaders, ""))
+	ip := middleware.ExtractIP(r)
+	apiKey := r.Header.Get(middleware.APIKeyHeader)
+
+	// Allow/deny lists (deny tem prioridade sobre allow)
+	switch cfg.CheckAccess(ip, apiKey) {
+	case middleware.AccessDeny:
+		writeJSON(w, h.logger, http.StatusForbidden, map[string]string{"message": "access denied"})
+		return
+	case middleware.AccessAllow:
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if apiKey != "" {
+		if _, known := cfg.GetTokenConfig(apiKey); !known && cfg.GetUnknownTokenPolicy().Mode == middleware.UnknownTokenReject {
+			writeJSON(w, h.logger, http.StatusUnauthorized, map[string]string{"message": middleware.InvalidAPIKeyMessage})
+			return
+		}
+	}
+
+	input := middleware.BuildRateLimitInput(cfg, method, path, ip, apiKey)
+	output, err := h.useCase.Execute(r.Context(), input)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Forward auth rate limit check failed", "key", input.Key, "policy", input.Policy, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	resetAfter, retryAfter, err := middleware.RateLimitTimes(r.Context(), h.storage, input, output)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "Forward auth failed to get block TTL", "key", input.Key, "error", err)
+	}
+
+	w.Header().Set(headerRateLimitLimit, strconv.Itoa(input.Limit))
+	w.Header().Set(headerRateLimitRemaining, strconv.Itoa(int(math.Floor(max(output.CurrentTokens, 0)))))
+	w.Header().Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(resetAfter)))
+
+	if !output.Allowed {
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+		}
+		writeJSON(w, h.logger, http.StatusTooManyRequests, map[string]string{"message": output.Message})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// snapshotConfig retorna a configuração a ser usada durante toda a requisição
+func (h *ForwardAuthHandler) snapshotConfig() middleware.Config {
+	if snapshotter, ok := h.config.(middleware.ConfigSnapshotter); ok {
+		return snapshotter.Snapshot()
+	}
+	return h.config
+}
+
+// firstHeader retorna o primeiro header preenchido da lista, ou fallback
+func firstHeader(r *http.Request, names []string, fallback string) string {
+	for _, name := range names {
+		if value := r.Header.Get(name); value != "" {
+			return value
+		}
+	}
+	return fallback
+}
+
+// originalPath extrai o path da URI original (sem query string)
+// Sem a URI original o path fica vazio: apenas os limites por token e por IP se aplicam
+func originalPath(uri string) string {
+	if u, err := url.ParseRequestURI(uri); err == nil {
+		return u.Path
+	}
+	path, _, _ := strings.Cut(uri, "?")
+	return path
+}
+
+// ceilSeconds arredonda a duração para cima, em segundos inteiros (formato dos headers)
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
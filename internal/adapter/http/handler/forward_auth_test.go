@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// newForwardAuthServer cria o router de forward auth sobre um storage em memória e o use case real
+func newForwardAuthServer() (http.Handler, *memory.MemoryStorage) {
+	storage := memory.NewMemoryStorage()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	useCase := check_rate_limit.NewUseCase(storage, logger)
+	return NewForwardAuthHandler(useCase, storage, stubConfig{}, logger).Routes(), storage
+}
+
+// doForwardAuth envia a subrequisição de auth com os headers informados
+func doForwardAuth(router http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/auth", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestForwardAuthHandler_IPLimit_AllowsThenRejects(t *testing.T) {
+	// Arrange
+	router, _ := newForwardAuthServer()
+	headers := map[string]string{"X-Original-URI": "/", "X-Forwarded-For": "203.0.113.7"}
+
+	// Act
+	first := doForwardAuth(router, headers)
+	doForwardAuth(router, headers)
+	third := doForwardAuth(router, headers)
+	other := doForwardAuth(router, map[string]string{"X-Original-URI": "/", "X-Forwarded-For": "203.0.113.8"})
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	assert.Equal(t, "0", third.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", third.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, other.Code, "cada IP original tem o próprio bucket")
+}
+
+func TestForwardAuthHandler_OriginalRequestHeaders_MatchRouteLimit(t *testing.T) {
+	tests := map[string]map[string]string{
+		"nginx auth_request": {
+			"X-Original-Method": http.MethodPost,
+			"X-Original-URI":    "/login?next=/home",
+			"X-Real-IP":         "203.0.113.7",
+		},
+		"traefik forwardAuth": {
+			"X-Forwarded-Method": http.MethodPost,
+			"X-Forwarded-Uri":    "/login?next=/home",
+			"X-Forwarded-For":    "203.0.113.7",
+		},
+	}
+
+	for name, headers := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			router, storage := newForwardAuthServer()
+
+			// Act
+			first := doForwardAuth(router, headers)
+			second := doForwardAuth(router, headers)
+
+			// Assert - mesmo bucket da rota usado pelo middleware (1 req/min)
+			assert.Equal(t, http.StatusOK, first.Code)
+			assert.Equal(t, "1", first.Header().Get("X-RateLimit-Limit"))
+			assert.Equal(t, http.StatusTooManyRequests, second.Code)
+
+			state, err := storage.GetKeyState(context.Background(), entity.NewIPKey("203.0.113.7").Scoped("login"))
+			require.NoError(t, err)
+			assert.True(t, state.Exists)
+		})
+	}
+}
+
+func TestForwardAuthHandler_TokenLimit(t *testing.T) {
+	router, _ := newForwardAuthServer()
+
+	w := doForwardAuth(router, map[string]string{"X-Original-URI": "/orders", "X-Forwarded-For": "203.0.113.7", "API_KEY": "partner"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "4", w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestOriginalPath(t *testing.T) {
+	tests := map[string]string{
+		"/login?next=/home": "/login",
+		"/api/users":        "/api/users",
+		"":                  "",
+		"/a b?x=1":          "/a b",
+	}
+
+	for uri, expected := range tests {
+		assert.Equal(t, expected, originalPath(uri), uri)
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// Valores padrão da amostragem de logs de rejeição
+const (
+	defaultLogSampleInterval = 10 * time.Second
+	defaultLogSampleBurst    = 1
+	maxSampledKeys           = 10000 // Limita a memória usada com muitos clientes distintos
+)
+
+// logSampler limita as linhas de log por chave: no máximo burst linhas a cada interval
+// Um cliente bloqueado que insiste gera uma linha por intervalo, não uma por requisição;
+// as linhas suprimidas são contadas e informadas na próxima linha emitida
+type logSampler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	now      func() time.Time
+	entries  map[string]*sampleEntry
+}
+
+type sampleEntry struct {
+	windowStart time.Time
+	emitted     int
+	suppressed  int
+}
+
+func newLogSampler(interval time.Duration, burst int) *logSampler {
+	return &logSampler{
+		interval: interval,
+		burst:    burst,
+		now:      time.Now,
+		entries:  make(map[string]*sampleEntry),
+	}
+}
+
+// allow informa se a linha da chave deve ser registrada e quantas foram suprimidas desde a última
+func (s *logSampler) allow(key string) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, exists := s.entries[key]
+	if !exists || now.Sub(entry.windowStart) >= s.interval {
+		suppressed := 0
+		if exists {
+			suppressed = entry.suppressed
+		} else {
+			s.evict(now)
+		}
+		s.entries[key] = &sampleEntry{windowStart: now, emitted: 1}
+		return true, suppressed
+	}
+
+	if entry.emitted < s.burst {
+		entry.emitted++
+		return true, 0
+	}
+
+	entry.suppressed++
+	return false, 0
+}
+
+// evict remove as janelas expiradas quando o mapa atinge o limite de chaves
+// Se ainda assim estiver cheio, recomeça do zero (perde apenas a contagem de suprimidas)
+func (s *logSampler) evict(now time.Time) {
+	if len(s.entries) < maxSampledKeys {
+		return
+	}
+	for key, entry := range s.entries {
+		if now.Sub(entry.windowStart) >= s.interval {
+			delete(s.entries, key)
+		}
+	}
+	if len(s.entries) >= maxSampledKeys {
+		s.entries = make(map[string]*sampleEntry)
+	}
+}
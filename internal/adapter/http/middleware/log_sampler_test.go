@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSampler_AllowsBurstPerKeyAndReportsSuppressed(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sampler := newLogSampler(10*time.Second, 2)
+	sampler.now = func() time.Time { return now }
+
+	// Act & Assert - burst de 2 linhas por janela
+	ok, _ := sampler.allow("ip:1")
+	assert.True(t, ok)
+	ok, _ = sampler.allow("ip:1")
+	assert.True(t, ok)
+	for i := 0; i < 5; i++ {
+		ok, _ = sampler.allow("ip:1")
+		assert.False(t, ok)
+	}
+
+	// Outras chaves têm cota própria
+	ok, _ = sampler.allow("ip:2")
+	assert.True(t, ok)
+
+	// Nova janela informa quantas linhas foram suprimidas
+	now = now.Add(10 * time.Second)
+	ok, suppressed := sampler.allow("ip:1")
+	assert.True(t, ok)
+	assert.Equal(t, 5, suppressed)
+}
+
+func TestLogSampler_BoundsTrackedKeys(t *testing.T) {
+	sampler := newLogSampler(time.Minute, 1)
+
+	for i := 0; i < maxSampledKeys+10; i++ {
+		sampler.allow(time.Duration(i).String())
+	}
+
+	assert.LessOrEqual(t, len(sampler.entries), maxSampledKeys)
+}
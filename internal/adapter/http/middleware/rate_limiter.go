@@ -3,13 +3,22 @@ package middleware
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/tracing"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/detect_token_abuse"
 )
 
 // Config interface para permitir mock em testes
@@ -18,6 +27,19 @@ type Config interface {
 	GetIPWindow() time.Duration
 	GetIPBlockTime() time.Duration
 	GetTokenConfig(token string) (TokenConfig, bool)
+	GetRouteConfig(method, path string) (RouteConfig, bool)
+	CheckAccess(ip, apiKey string) Access
+	// TokenKey deriva a chave do rate limiter a partir da API key (HMAC quando há segredo configurado)
+	TokenKey(apiKey string) entity.LimiterKey
+	// GetUnknownTokenPolicy define o tratamento de API keys que não correspondem a nenhum token
+	GetUnknownTokenPolicy() UnknownTokenPolicy
+}
+
+// ConfigSnapshotter é implementado por configurações recarregáveis em runtime
+// O middleware usa um único snapshot por requisição, para que um reload no meio
+// da requisição não misture valores de versões diferentes
+type ConfigSnapshotter interface {
+	Snapshot() Config
 }
 
 type TokenConfig struct {
@@ -26,80 +48,372 @@ type TokenConfig struct {
 	BlockTime time.Duration
 }
 
+// RouteConfig define um limite específico de rota
+// O bucket é separado por rota (Name) e por cliente (token ou IP)
+type RouteConfig struct {
+	Name      string
+	Limit     int
+	Window    time.Duration
+	BlockTime time.Duration
+}
+
+// UnknownTokenMode define o que fazer com uma API key que não corresponde a nenhum token configurado
+type UnknownTokenMode string
+
+const (
+	UnknownTokenAnonymous UnknownTokenMode = "anonymous" // Trata como requisição sem token (limite por IP)
+	UnknownTokenReject    UnknownTokenMode = "reject"    // Rejeita com 401
+	UnknownTokenLimit     UnknownTokenMode = "limit"     // Aplica um limite próprio, por IP
+)
+
+// UnknownTokenPolicy configura o tratamento de API keys desconhecidas
+type UnknownTokenPolicy struct {
+	Mode UnknownTokenMode
+
+	// Limite aplicado por IP quando Mode é UnknownTokenLimit
+	Limit     int
+	Window    time.Duration
+	BlockTime time.Duration
+
+	// Detecção de força bruta: o IP que enviar mais de MaxDistinct tokens inválidos
+	// distintos dentro de DistinctWindow fica bloqueado por AbuseBlockTime (0 desabilita)
+	MaxDistinct    int
+	DistinctWindow time.Duration
+	AbuseBlockTime time.Duration
+}
+
+// TokenAbuseDetector detecta IPs que testam muitas API keys inválidas
+type TokenAbuseDetector interface {
+	IsBlocked(ctx context.Context, ip string) (bool, error)
+	RecordInvalidToken(ctx context.Context, input detect_token_abuse.Input) (bool, error)
+}
+
+// Mensagens das respostas de API keys inválidas e de requisições canceladas na fila
+const (
+	InvalidAPIKeyMessage    = "invalid API key"
+	TokenAbuseMessage       = "too many invalid API keys"
+	RequestCancelledMessage = "request cancelled while queued"
+)
+
+// Access é o resultado da consulta às listas de allow/deny
+type Access int
+
+const (
+	AccessDefault Access = iota // Segue o rate limiting normal
+	AccessAllow                 // Não sofre rate limiting
+	AccessDeny                  // Rejeitado com 403
+)
+
+// Decisões das listas de acesso registradas no span (as demais vêm do use case)
+const (
+	decisionDenied      = "denied"
+	decisionAllowListed = "allowlisted"
+	decisionInvalidKey  = "invalid_key" // API key desconhecida rejeitada (UnknownTokenReject)
+	decisionTokenAbuse  = "token_abuse" // IP bloqueado por força bruta de API keys
+	decisionCancelled   = "cancelled"   // Cliente desistiu enquanto esperava na fila
+)
+
 // UseCase interface para permitir mock em testes
 type UseCase interface {
 	Execute(ctx context.Context, input check_rate_limit.Input) (*check_rate_limit.Output, error)
 }
 
 type RateLimiterMiddleware struct {
-	useCase UseCase
-	config  Config
+	useCase  UseCase
+	config   Config
+	logger   *slog.Logger
+	sampler  *logSampler        // Limita os logs de rejeição por chave
+	detector TokenAbuseDetector // Opcional: detecção de força bruta de API keys
 }
 
-func NewRateLimiterMiddleware(useCase UseCase, config Config) *RateLimiterMiddleware {
+// NewRateLimiterMiddleware cria o middleware
+// Decisões permitidas são registradas em debug; rejeições em info, amostradas por chave
+func NewRateLimiterMiddleware(useCase UseCase, config Config, logger *slog.Logger) *RateLimiterMiddleware {
 	return &RateLimiterMiddleware{
 		useCase: useCase,
 		config:  config,
+		logger:  logger,
+		sampler: newLogSampler(defaultLogSampleInterval, defaultLogSampleBurst),
 	}
 }
 
+// WithTokenAbuseDetector habilita a detecção de força bruta de API keys
+// (ativa apenas quando a política de tokens desconhecidos define MaxDistinct)
+func (m *RateLimiterMiddleware) WithTokenAbuseDetector(detector TokenAbuseDetector) *RateLimiterMiddleware {
+	m.detector = detector
+	return m
+}
+
+// Request é a requisição vista pelo rate limiter, independente do framework HTTP
+// Os adaptadores (net/http, gin, echo, fasthttp) extraem esses campos e chamam Check
+type Request struct {
+	Method string
+	Path   string
+	IP     string // Ver ClientIP
+	APIKey string // Header API_KEY
+}
+
+// Result é a decisão do rate limiter para uma requisição
+// Quando Allowed é falso, a requisição é respondida com StatusCode e Body (JSON) sem chegar ao handler
+type Result struct {
+	Allowed    bool
+	StatusCode int
+	Body       []byte
+}
+
+// APIKeyHeader é o header com a API key do cliente
+const APIKeyHeader = "API_KEY"
+
+// ContentTypeJSON é o Content-Type das respostas de rejeição
+const ContentTypeJSON = "application/json"
+
+// StartSpan continua o trace do chamador (W3C traceparent lido de carrier) e abre o span do rate limiter
+// O adaptador repassa o contexto retornado ao restante da requisição e encerra o span ao final dela
+func StartSpan(ctx context.Context, carrier propagation.TextMapCarrier, method, path string) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	return tracing.Tracer().Start(ctx, "RateLimiterMiddleware.Handle",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.path", path),
+		),
+	)
+}
+
 func (m *RateLimiterMiddleware) Handle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
+		// Continua o trace do chamador (W3C traceparent) e o repassa aos próximos handlers
+		ctx, span := StartSpan(r.Context(), propagation.HeaderCarrier(r.Header), r.Method, r.URL.Path)
+		defer span.End()
+		r = r.WithContext(ctx)
 
-		// 1. Extrai IP do request
-		ip := extractIP(r)
+		result := m.Check(ctx, Request{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			IP:     ExtractIP(r),
+			APIKey: r.Header.Get(APIKeyHeader),
+		})
+		if !result.Allowed {
+			WriteResult(w, result)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		// 2. Extrai API_KEY do header
-		apiKey := r.Header.Get("API_KEY")
+// WriteResult envia a resposta de rejeição de um Result em um http.ResponseWriter
+func WriteResult(w http.ResponseWriter, result Result) {
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(result.StatusCode)
+	w.Write(result.Body)
+}
 
-		// 3. Determina qual configuração usar com prioridade Token > IP
-		input := m.buildRateLimitInput(ip, apiKey)
+// Check aplica as allow/deny lists, a detecção de força bruta de API keys e o rate limiting
+// As decisões são registradas no span de ctx (ver StartSpan)
+func (m *RateLimiterMiddleware) Check(ctx context.Context, req Request) Result {
+	span := trace.SpanFromContext(ctx)
+	cfg := m.snapshotConfig()
+	ip, apiKey := req.IP, req.APIKey
 
-		// Log da configuração utilizada
-		keyType := "ip"
-		if input.Key.Type == entity.KeyTypeToken {
-			keyType = "token"
+	// 1. Allow/deny lists (deny tem prioridade sobre allow)
+	switch cfg.CheckAccess(ip, apiKey) {
+	case AccessDeny:
+		if ok, suppressed := m.sampler.allow("denied|" + ip); ok {
+			m.logger.InfoContext(ctx, "Access denied by deny list", "ip", ip, "suppressed", suppressed)
 		}
-		log.Printf("Rate limiter: using %s key '%s' with limit %d req/%v",
-			keyType, input.Key.Value, input.Limit, input.Window)
+		span.SetAttributes(tracing.AttrDecision.String(decisionDenied))
+		return rejection(http.StatusForbidden, "message", "access denied")
+	case AccessAllow:
+		m.logger.DebugContext(ctx, "Request allowed by allow list", "ip", ip)
+		span.SetAttributes(tracing.AttrDecision.String(decisionAllowListed))
+		return Result{Allowed: true}
+	}
 
-		// 4. Executa use case
-		output, err := m.useCase.Execute(ctx, input)
+	// 2. API keys desconhecidas: força bruta e política de tokens desconhecidos
+	if apiKey != "" {
+		decision, err := m.screenAPIKey(ctx, cfg, ip, apiKey)
 		if err != nil {
-			// Log do erro interno
-			log.Printf("Rate limiter error: %v for key %s", err, input.Key.Value)
-			m.sendInternalServerError(w)
-			return
+			if ok, suppressed := m.sampler.allow("error|abuse|" + ip); ok {
+				m.logger.ErrorContext(ctx, "Token abuse check failed", "ip", ip, "error", err, "suppressed", suppressed)
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return internalServerError()
 		}
 
-		// 5. Se não permitido, bloqueia com 429
-		if !output.Allowed {
-			log.Printf("Rate limit exceeded: %s for key %s (tokens: %.2f/%d)",
-				output.Message, input.Key.Value, output.CurrentTokens, output.Limit)
-			m.sendRateLimitExceeded(w, output.Message)
-			return
+		switch decision {
+		case decisionTokenAbuse:
+			if ok, suppressed := m.sampler.allow("abuse|" + ip); ok {
+				m.logger.InfoContext(ctx, "Request rejected: too many invalid API keys", "ip", ip, "suppressed", suppressed)
+			}
+			span.SetAttributes(tracing.AttrDecision.String(decisionTokenAbuse))
+			return rejection(http.StatusTooManyRequests, "message", TokenAbuseMessage)
+		case decisionInvalidKey:
+			if ok, suppressed := m.sampler.allow("invalid|" + ip); ok {
+				m.logger.InfoContext(ctx, "Request rejected: invalid API key", "ip", ip, "suppressed", suppressed)
+			}
+			span.SetAttributes(tracing.AttrDecision.String(decisionInvalidKey))
+			return rejection(http.StatusUnauthorized, "message", InvalidAPIKeyMessage)
 		}
+	}
 
-		// 6. Permitido - continua para próximo handler
-		log.Printf("Rate limit OK: %s for key %s (tokens remaining: %.2f/%d)",
-			"allowed", input.Key.Value, output.CurrentTokens, output.Limit)
-		next.ServeHTTP(w, r)
-	})
+	// 3. Determina qual configuração usar com prioridade Rota > Token > IP
+	input := BuildRateLimitInput(cfg, req.Method, req.Path, ip, apiKey)
+	span.SetAttributes(tracing.InputAttributes(input)...)
+
+	// 4. Executa use case
+	output, err := m.useCase.Execute(ctx, input)
+	if err != nil && ctx.Err() != nil {
+		// O cliente desistiu enquanto a requisição esperava na fila (QUEUE_MAX_DELAY): não é falha do storage
+		m.logger.DebugContext(ctx, "Request cancelled while queued", "key", input.Key, "policy", input.Policy)
+		span.SetAttributes(tracing.AttrDecision.String(decisionCancelled))
+		return rejection(http.StatusServiceUnavailable, "message", RequestCancelledMessage)
+	}
+	if err != nil {
+		// Com o storage fora do ar todas as requisições falham: amostra também os erros
+		if ok, suppressed := m.sampler.allow("error|" + input.Key.String()); ok {
+			m.logger.ErrorContext(ctx, "Rate limit check failed",
+				"key", input.Key, "policy", input.Policy, "error", err, "suppressed", suppressed)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return internalServerError()
+	}
+
+	span.SetAttributes(tracing.OutputAttributes(output)...)
+
+	// 5. Se não permitido, bloqueia com 429
+	if !output.Allowed {
+		if ok, suppressed := m.sampler.allow(input.Key.String()); ok {
+			m.logger.InfoContext(ctx, "Rate limit exceeded",
+				"key", input.Key,
+				"policy", input.Policy,
+				"decision", output.Decision(),
+				"limit", input.Limit,
+				"window", input.Window,
+				"suppressed", suppressed,
+			)
+		}
+		return rejection(http.StatusTooManyRequests, "message", output.Message)
+	}
+
+	// 6. Permitido - continua para próximo handler
+	if m.logger.Enabled(ctx, slog.LevelDebug) {
+		m.logger.DebugContext(ctx, "Rate limit check passed",
+			"key", input.Key,
+			"policy", input.Policy,
+			"tokens_remaining", output.CurrentTokens,
+			"limit", output.Limit,
+			"queue_delay", output.QueueDelay,
+		)
+	}
+	return Result{Allowed: true}
 }
 
-// buildRateLimitInput constrói o input baseado na prioridade Token > IP
-func (m *RateLimiterMiddleware) buildRateLimitInput(ip, apiKey string) check_rate_limit.Input {
+// screenAPIKey aplica a detecção de força bruta e a política de API keys desconhecidas
+// Retorna a decisão de rejeição, ou "" quando a requisição segue para o rate limiting
+// Um IP bloqueado por força bruta é rejeitado mesmo com uma API key válida:
+// caso contrário, acertar um token revelaria que ele existe
+func (m *RateLimiterMiddleware) screenAPIKey(ctx context.Context, cfg Config, ip, apiKey string) (string, error) {
+	policy := cfg.GetUnknownTokenPolicy()
+	_, known := cfg.GetTokenConfig(apiKey)
+
+	if m.detector != nil && policy.MaxDistinct > 0 {
+		var blocked bool
+		var err error
+		if known {
+			blocked, err = m.detector.IsBlocked(ctx, ip)
+		} else {
+			blocked, err = m.detector.RecordInvalidToken(ctx, detect_token_abuse.Input{
+				IP:          ip,
+				Token:       cfg.TokenKey(apiKey),
+				MaxDistinct: policy.MaxDistinct,
+				Window:      policy.DistinctWindow,
+				BlockTime:   policy.AbuseBlockTime,
+			})
+		}
+		if err != nil {
+			return "", err
+		}
+		if blocked {
+			return decisionTokenAbuse, nil
+		}
+	}
+
+	if !known && policy.Mode == UnknownTokenReject {
+		return decisionInvalidKey, nil
+	}
+	return "", nil
+}
+
+// BuildRateLimitInput constrói o input baseado na prioridade Rota > Token > IP
+// Rotas com limite próprio usam um bucket separado para o cliente (token ou IP)
+// Exportada para que outros adaptadores (ex: Envoy RLS) apliquem exatamente as mesmas políticas
+func BuildRateLimitInput(cfg Config, method, path, ip, apiKey string) check_rate_limit.Input {
+	input := buildClientInput(cfg, ip, apiKey)
+
+	if route, exists := cfg.GetRouteConfig(method, path); exists {
+		input.Key = input.Key.Scoped(route.Name)
+		input.Limit = route.Limit
+		input.Window = route.Window
+		input.BlockTime = route.BlockTime
+		input.Policy = check_rate_limit.RoutePolicy(route.Name)
+	}
+
+	return input
+}
+
+// RateLimitTimes calcula o tempo até o bucket voltar a ficar cheio (reset) e até uma nova
+// requisição com o mesmo custo poder ser aceita (retry, zero quando permitida)
+// Para uma chave já bloqueada os dois são o tempo restante do bloqueio, consultado no storage
+// Usada pelos adaptadores que informam ao cliente quando tentar de novo (API de decisão, forward auth, gRPC)
+func RateLimitTimes(ctx context.Context, storage repository.Storage, input check_rate_limit.Input, output *check_rate_limit.Output) (reset, retry time.Duration, err error) {
+	switch output.Decision() {
+	case check_rate_limit.DecisionBlocked:
+		// O use case não informa quanto resta do bloqueio: consulta o TTL no storage
+		state, err := storage.GetKeyState(ctx, input.Key)
+		if err != nil || !state.Blocked {
+			return 0, 0, err
+		}
+		return state.BlockTTL, state.BlockTTL, nil
+	case check_rate_limit.DecisionRejected:
+		retry = output.BlockTime
+		if retry <= 0 {
+			retry = input.RefillDuration(float64(input.TokenCost()) - output.CurrentTokens)
+		}
+		return input.RefillDuration(float64(input.Limit) - output.CurrentTokens), retry, nil
+	default:
+		return input.RefillDuration(float64(input.Limit) - output.CurrentTokens), 0, nil
+	}
+}
+
+// unknownTokenScope separa o bucket de API keys desconhecidas do bucket por IP
+const unknownTokenScope = "unknown-token"
+
+// buildClientInput constrói o input do cliente com prioridade Token > IP
+func buildClientInput(cfg Config, ip, apiKey string) check_rate_limit.Input {
 	// Prioridade: Token > IP
 	// Se tem API_KEY, tenta usar configuração do token primeiro
 	if apiKey != "" {
-		if tokenConfig, exists := m.config.GetTokenConfig(apiKey); exists {
+		if tokenConfig, exists := cfg.GetTokenConfig(apiKey); exists {
 			// Usa configuração do token (prioridade alta)
 			return check_rate_limit.Input{
-				Key:       entity.NewTokenKey(apiKey),
+				Key:       cfg.TokenKey(apiKey),
 				Limit:     tokenConfig.Limit,
 				Window:    tokenConfig.Window,
 				BlockTime: tokenConfig.BlockTime,
+				Policy:    check_rate_limit.PolicyToken,
+			}
+		}
+
+		// API key desconhecida com limite próprio: bucket por IP separado do limite anônimo
+		if policy := cfg.GetUnknownTokenPolicy(); policy.Mode == UnknownTokenLimit {
+			return check_rate_limit.Input{
+				Key:       entity.NewIPKey(ip).Scoped(unknownTokenScope),
+				Limit:     policy.Limit,
+				Window:    policy.Window,
+				BlockTime: policy.BlockTime,
+				Policy:    check_rate_limit.PolicyUnknownToken,
 			}
 		}
 	}
@@ -107,61 +421,57 @@ func (m *RateLimiterMiddleware) buildRateLimitInput(ip, apiKey string) check_rat
 	// Fallback: usa configuração do IP (prioridade baixa)
 	return check_rate_limit.Input{
 		Key:       entity.NewIPKey(ip),
-		Limit:     m.config.GetIPLimit(),
-		Window:    m.config.GetIPWindow(),
-		BlockTime: m.config.GetIPBlockTime(),
+		Limit:     cfg.GetIPLimit(),
+		Window:    cfg.GetIPWindow(),
+		BlockTime: cfg.GetIPBlockTime(),
+		Policy:    check_rate_limit.PolicyIP,
 	}
 }
 
-// sendInternalServerError envia resposta de erro interno 500
-func (m *RateLimiterMiddleware) sendInternalServerError(w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
-
-	response := map[string]string{
-		"error": "Internal Server Error",
-	}
-
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		// Se o JSON encoding falhar, envia erro simples
-		log.Printf("Failed to encode JSON error response: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// snapshotConfig retorna a configuração a ser usada durante toda a requisição
+func (m *RateLimiterMiddleware) snapshotConfig() Config {
+	if snapshotter, ok := m.config.(ConfigSnapshotter); ok {
+		return snapshotter.Snapshot()
 	}
+	return m.config
 }
 
-// sendRateLimitExceeded envia resposta de rate limit exceeded 429
-func (m *RateLimiterMiddleware) sendRateLimitExceeded(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusTooManyRequests)
+// internalServerError é a resposta para falhas do storage ou da detecção de força bruta
+func internalServerError() Result {
+	return rejection(http.StatusInternalServerError, "error", "Internal Server Error")
+}
 
-	response := map[string]string{
-		"message": message,
-	}
+// rejection monta uma resposta de rejeição no formato {"<field>": "<message>"}
+func rejection(status int, field, message string) Result {
+	// Um map[string]string sempre é serializável
+	body, _ := json.Marshal(map[string]string{field: message})
+	return Result{StatusCode: status, Body: append(body, '\n')}
+}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		// Se o JSON encoding falhar, envia erro simples
-		log.Printf("Failed to encode JSON rate limit response: %v", err)
-		http.Error(w, message, http.StatusTooManyRequests)
-	}
+// ExtractIP extrai o IP real do cliente considerando proxies (X-Forwarded-For, X-Real-IP, RemoteAddr)
+// Exportada para que o endpoint de forward auth identifique o cliente da requisição original
+func ExtractIP(r *http.Request) string {
+	return ClientIP(r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Real-IP"), r.RemoteAddr)
 }
 
-// extractIP extrai o IP real do cliente considerando proxies
-func extractIP(r *http.Request) string {
+// ClientIP escolhe o IP do cliente a partir dos headers de proxy e do endereço da conexão
+// Usada pelos adaptadores que não têm um *http.Request (ex: fasthttp)
+func ClientIP(forwardedFor, realIP, remoteAddr string) string {
 	// 1. Tenta X-Forwarded-For (proxy, load balancer)
-	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+	if forwardedFor != "" {
 		// Pega o primeiro IP da lista (cliente original)
-		ips := strings.Split(forwardedFor, ",")
-		return strings.TrimSpace(ips[0])
+		first, _, _ := strings.Cut(forwardedFor, ",")
+		return strings.TrimSpace(first)
 	}
 
 	// 2. Tenta X-Real-IP (nginx, cloudflare)
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+	if realIP != "" {
 		return realIP
 	}
 
 	// 3. Usa RemoteAddr (conexão direta)
 	// Remove porta: "192.168.1.1:12345" → "192.168.1.1"
-	ip := r.RemoteAddr
+	ip := remoteAddr
 	if idx := strings.LastIndex(ip, ":"); idx != -1 {
 		ip = ip[:idx]
 	}
@@ -172,7 +482,7 @@ func extractIP(r *http.Request) string {
 // RateLimiterMiddlewareFunc é uma função temporária para compatibilidade com testes
 // Agora usa o método Handle() do struct RateLimiterMiddleware
 func RateLimiterMiddlewareFunc(useCase UseCase, config Config) func(http.Handler) http.Handler {
-	middleware := NewRateLimiterMiddleware(useCase, config)
+	middleware := NewRateLimiterMiddleware(useCase, config, slog.Default())
 	return middleware.Handle
 }
 
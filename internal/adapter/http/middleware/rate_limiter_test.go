@@ -1,18 +1,31 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/tracing"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/detect_token_abuse"
 )
 
 // MockUseCase simula o use case para testes
@@ -33,6 +46,11 @@ type MockConfig struct {
 	IPLimit     int
 	IPWindow    time.Duration
 	IPBlockTime time.Duration
+	Routes      map[string]RouteConfig // path → rota
+	Tokens      map[string]TokenConfig // token → config (além do "test-token")
+	Access      map[string]Access      // IP ou token → decisão
+	Hasher      entity.TokenHasher     // Zero value: token em texto puro
+	Unknown     UnknownTokenPolicy     // Zero value: comportamento anônimo
 }
 
 func (m *MockConfig) GetIPLimit() int {
@@ -48,6 +66,9 @@ func (m *MockConfig) GetIPBlockTime() time.Duration {
 }
 
 func (m *MockConfig) GetTokenConfig(token string) (TokenConfig, bool) {
+	if cfg, exists := m.Tokens[token]; exists {
+		return cfg, true
+	}
 	// Retorna config fake para token "test-token"
 	if token == "test-token" {
 		return TokenConfig{
@@ -59,13 +80,33 @@ func (m *MockConfig) GetTokenConfig(token string) (TokenConfig, bool) {
 	return TokenConfig{}, false
 }
 
+func (m *MockConfig) GetRouteConfig(method, path string) (RouteConfig, bool) {
+	route, exists := m.Routes[path]
+	return route, exists
+}
+
+func (m *MockConfig) TokenKey(apiKey string) entity.LimiterKey {
+	return m.Hasher.Key(apiKey)
+}
+
+func (m *MockConfig) GetUnknownTokenPolicy() UnknownTokenPolicy {
+	return m.Unknown
+}
+
+func (m *MockConfig) CheckAccess(ip, apiKey string) Access {
+	if access, exists := m.Access[apiKey]; exists {
+		return access
+	}
+	return m.Access[ip]
+}
+
 func TestExtractIP_FromRemoteAddr(t *testing.T) {
 	// Arrange
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 	req.RemoteAddr = "192.168.1.1:12345"
 
 	// Act
-	ip := extractIP(req)
+	ip := ExtractIP(req)
 
 	// Assert
 	assert.Equal(t, "192.168.1.1", ip)
@@ -77,7 +118,7 @@ func TestExtractIP_FromXForwardedFor(t *testing.T) {
 	req.Header.Set("X-Forwarded-For", "1.2.3.4, 5.6.7.8")
 
 	// Act
-	ip := extractIP(req)
+	ip := ExtractIP(req)
 
 	// Assert
 	assert.Equal(t, "1.2.3.4", ip)
@@ -89,7 +130,7 @@ func TestExtractIP_FromXRealIP(t *testing.T) {
 	req.Header.Set("X-Real-IP", "9.8.7.6")
 
 	// Act
-	ip := extractIP(req)
+	ip := ExtractIP(req)
 
 	// Assert
 	assert.Equal(t, "9.8.7.6", ip)
@@ -194,6 +235,7 @@ func TestRateLimiterMiddleware_UsesIPByDefault(t *testing.T) {
 		Limit:     10,
 		Window:    time.Second,
 		BlockTime: 5 * time.Minute,
+		Policy:    check_rate_limit.PolicyIP,
 	}).Return(
 		&check_rate_limit.Output{
 			Allowed: true,
@@ -232,6 +274,7 @@ func TestRateLimiterMiddleware_UsesTokenWhenProvided(t *testing.T) {
 		Limit:     100, // Token limit, not IP limit
 		Window:    time.Second,
 		BlockTime: 5 * time.Minute,
+		Policy:    check_rate_limit.PolicyToken,
 	}).Return(
 		&check_rate_limit.Output{
 			Allowed: true,
@@ -294,7 +337,404 @@ func TestRateLimiterMiddleware_TokenOverridesIP(t *testing.T) {
 	mockUseCase.AssertExpectations(t)
 }
 
+func TestRateLimiterMiddleware_UsesHashedTokenKey(t *testing.T) {
+	// Arrange
+	hasher := entity.NewTokenHasher("0123456789abcdef0123456789abcdef")
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{
+		IPLimit:     10,
+		IPWindow:    time.Second,
+		IPBlockTime: 5 * time.Minute,
+		Hasher:      hasher,
+	}
+
+	// O storage recebe apenas o digest, nunca o token em texto puro
+	mockUseCase.On("Execute", mock.Anything, mock.MatchedBy(func(input check_rate_limit.Input) bool {
+		return input.Key == hasher.Key("test-token") && input.Limit == 100
+	})).Return(&check_rate_limit.Output{Allowed: true}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("API_KEY", "test-token")
+	w := httptest.NewRecorder()
+
+	// Act
+	middleware := createRateLimiterMiddleware(mockUseCase, mockConfig)
+	middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, req)
+
+	// Assert
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestRateLimiterMiddleware_UnknownTokenReject_ReturnsUnauthorized(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{
+		IPLimit:  10,
+		IPWindow: time.Second,
+		Unknown:  UnknownTokenPolicy{Mode: UnknownTokenReject},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("API_KEY", "random-guess")
+	w := httptest.NewRecorder()
+
+	// Act
+	middleware := createRateLimiterMiddleware(mockUseCase, mockConfig)
+	middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"message": "invalid API key"}`, w.Body.String())
+	mockUseCase.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+}
+
+func TestRateLimiterMiddleware_UnknownTokenLimit_UsesDedicatedBucketPerIP(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{
+		IPLimit:  10,
+		IPWindow: time.Second,
+		Unknown:  UnknownTokenPolicy{Mode: UnknownTokenLimit, Limit: 2, Window: time.Minute, BlockTime: time.Hour},
+	}
+
+	mockUseCase.On("Execute", mock.Anything, mock.MatchedBy(func(input check_rate_limit.Input) bool {
+		return input.Key == entity.NewIPKey("192.168.1.1").Scoped("unknown-token") &&
+			input.Limit == 2 &&
+			input.Policy == check_rate_limit.PolicyUnknownToken
+	})).Return(&check_rate_limit.Output{Allowed: true}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	req.Header.Set("API_KEY", "random-guess")
+	w := httptest.NewRecorder()
+
+	// Act
+	middleware := createRateLimiterMiddleware(mockUseCase, mockConfig)
+	middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestRateLimiterMiddleware_BlocksIPAfterTooManyInvalidTokens(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockUseCase)
+	mockUseCase.On("Execute", mock.Anything, mock.Anything).Return(&check_rate_limit.Output{Allowed: true}, nil)
+	mockConfig := &MockConfig{
+		IPLimit:  10,
+		IPWindow: time.Second,
+		Unknown: UnknownTokenPolicy{
+			Mode:           UnknownTokenAnonymous,
+			MaxDistinct:    2,
+			DistinctWindow: time.Minute,
+			AbuseBlockTime: time.Hour,
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	detector := detect_token_abuse.NewUseCase(memory.NewMemoryStorage(), logger)
+	handler := NewRateLimiterMiddleware(mockUseCase, mockConfig, logger).
+		WithTokenAbuseDetector(detector).
+		Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		req.Header.Set("API_KEY", apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	codes := []int{send("guess-1").Code, send("guess-1").Code, send("guess-2").Code, send("guess-3").Code}
+	validAfterBlock := send("test-token")
+
+	// Assert - tentativas repetidas contam uma vez; o 3º token distinto bloqueia o IP
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, http.StatusTooManyRequests, validAfterBlock.Code, "token válido não escapa do bloqueio")
+	assert.JSONEq(t, `{"message": "too many invalid API keys"}`, validAfterBlock.Body.String())
+}
+
+func TestRateLimiterMiddleware_TokenWithoutBlockTime_RejectsWithoutBlocking(t *testing.T) {
+	// Arrange - token cadastrado sem block_time (admin ou policy file)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	storage := memory.NewMemoryStorage()
+	mockConfig := &MockConfig{
+		IPLimit:  10,
+		IPWindow: time.Second,
+		Tokens:   map[string]TokenConfig{"no-block": {Limit: 1, Window: time.Minute}},
+	}
+	handler := NewRateLimiterMiddleware(check_rate_limit.NewUseCase(storage, logger), mockConfig, logger).
+		Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		req.Header.Set("API_KEY", "no-block")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	codes := []int{send().Code, send().Code}
+
+	// Assert - acima do limite responde 429 (não 500) e a chave não fica bloqueada
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+	blocked, err := storage.IsBlocked(context.Background(), entity.NewTokenKey("no-block"))
+	require.NoError(t, err)
+	assert.False(t, blocked)
+}
+
 // createRateLimiterMiddleware é uma função helper para criar o middleware nos testes
 func createRateLimiterMiddleware(useCase UseCase, config Config) func(http.Handler) http.Handler {
-	return RateLimiterMiddlewareHandlerWrapper(useCase, config)
+	return NewRateLimiterMiddleware(useCase, config, slog.New(slog.NewTextHandler(io.Discard, nil))).Handle
+}
+
+func TestRateLimiterMiddleware_DenyListReturnsForbidden(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{
+		IPLimit:  10,
+		IPWindow: time.Second,
+		Access:   map[string]Access{"203.0.113.7": AccessDeny},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "203.0.113.7:12345"
+	w := httptest.NewRecorder()
+
+	nextHandlerCalled := false
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nextHandlerCalled = true
+	})
+
+	// Act
+	middleware := createRateLimiterMiddleware(mockUseCase, mockConfig)
+	middleware(nextHandler).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.False(t, nextHandlerCalled)
+	mockUseCase.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+}
+
+func TestRateLimiterMiddleware_AllowListSkipsRateLimiting(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{
+		IPLimit:  10,
+		IPWindow: time.Second,
+		Access:   map[string]Access{"internal-job": AccessAllow},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	req.Header.Set("API_KEY", "internal-job")
+	w := httptest.NewRecorder()
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Act
+	middleware := createRateLimiterMiddleware(mockUseCase, mockConfig)
+	middleware(nextHandler).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUseCase.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+}
+
+func TestRateLimiterMiddleware_RouteUsesScopedBucket(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{
+		IPLimit:     10,
+		IPWindow:    time.Second,
+		IPBlockTime: 5 * time.Minute,
+		Routes: map[string]RouteConfig{
+			"/api/upload": {Name: "upload", Limit: 2, Window: time.Minute, BlockTime: 15 * time.Minute},
+		},
+	}
+
+	// Rota tem prioridade sobre o token e usa um bucket próprio
+	mockUseCase.On("Execute", mock.Anything, check_rate_limit.Input{
+		Key:       entity.NewTokenKey("test-token").Scoped("upload"),
+		Limit:     2,
+		Window:    time.Minute,
+		BlockTime: 15 * time.Minute,
+		Policy:    check_rate_limit.RoutePolicy("upload"),
+	}).Return(
+		&check_rate_limit.Output{
+			Allowed: true,
+		}, nil,
+	).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	req.Header.Set("API_KEY", "test-token")
+	w := httptest.NewRecorder()
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Act
+	middleware := createRateLimiterMiddleware(mockUseCase, mockConfig)
+	middleware(nextHandler).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+// snapshottingConfig simula uma configuração recarregável
+type snapshottingConfig struct {
+	*MockConfig
+	current *MockConfig
+}
+
+func (c *snapshottingConfig) Snapshot() Config {
+	return c.current
+}
+
+func TestRateLimiterMiddleware_UsesConfigSnapshot(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockUseCase)
+	reloadable := &snapshottingConfig{
+		MockConfig: &MockConfig{IPLimit: 10, IPWindow: time.Second},
+		current:    &MockConfig{IPLimit: 20, IPWindow: time.Second, IPBlockTime: time.Minute},
+	}
+
+	// Valores vêm do snapshot (configuração recarregada), não da original
+	mockUseCase.On("Execute", mock.Anything, check_rate_limit.Input{
+		Key:       entity.NewIPKey("192.168.1.1"),
+		Limit:     20,
+		Window:    time.Second,
+		BlockTime: time.Minute,
+		Policy:    check_rate_limit.PolicyIP,
+	}).Return(
+		&check_rate_limit.Output{
+			Allowed: true,
+		}, nil,
+	).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	w := httptest.NewRecorder()
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Act
+	middleware := createRateLimiterMiddleware(mockUseCase, reloadable)
+	middleware(nextHandler).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestRateLimiterMiddleware_CreatesSpanContinuingCallerTrace(t *testing.T) {
+	// Arrange - TracerProvider em memória e propagação W3C
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{IPLimit: 10, IPWindow: time.Second, IPBlockTime: time.Minute}
+	mockUseCase.On("Execute", mock.Anything, mock.AnythingOfType("check_rate_limit.Input")).Return(
+		&check_rate_limit.Output{Allowed: false, CurrentTokens: 0, Limit: 10}, nil,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+
+	// Act
+	createRateLimiterMiddleware(mockUseCase, mockConfig)(http.NotFoundHandler()).ServeHTTP(w, req)
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "RateLimiterMiddleware.Handle", span.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	assert.Equal(t, "ip", attrs[tracing.AttrKeyType])
+	assert.Equal(t, "ip", attrs[tracing.AttrPolicy])
+	assert.Equal(t, "rejected", attrs[tracing.AttrDecision])
+	assert.Equal(t, "0", attrs[tracing.AttrTokensRemaining])
+
+	// O contexto repassado ao use case carrega o span do middleware
+	ctx := mockUseCase.Calls[0].Arguments.Get(0).(context.Context)
+	assert.Equal(t, span.SpanContext().SpanID(), trace.SpanContextFromContext(ctx).SpanID())
+}
+
+func TestRateLimiterMiddleware_LogsRejectionsSampledWithRedactedToken(t *testing.T) {
+	// Arrange
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{IPLimit: 10, IPWindow: time.Second, IPBlockTime: time.Minute}
+	mockUseCase.On("Execute", mock.Anything, mock.AnythingOfType("check_rate_limit.Input")).Return(
+		&check_rate_limit.Output{Allowed: true, CurrentTokens: 9, Limit: 100}, nil,
+	).Once()
+	mockUseCase.On("Execute", mock.Anything, mock.AnythingOfType("check_rate_limit.Input")).Return(
+		&check_rate_limit.Output{Allowed: false, Blocked: true, Message: check_rate_limit.RateLimitExceededMessage}, nil,
+	)
+	handler := NewRateLimiterMiddleware(mockUseCase, mockConfig, logger).Handle(http.NotFoundHandler())
+
+	// Act - 1 permitida (debug, não aparece) e 5 rejeitadas (apenas a primeira é registrada)
+	for i := 0; i < 6; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("API_KEY", "test-token")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Assert
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"msg":"Rate limit exceeded"`)
+	assert.Contains(t, lines[0], `"key":"token:`+entity.RedactToken("test-token")+`"`)
+	assert.Contains(t, lines[0], `"decision":"blocked"`)
+	assert.NotContains(t, logs.String(), "test-token")
+}
+
+func TestRateLimiterMiddleware_ClientCancelledWhileQueued_ReturnsServiceUnavailableWithoutErrorLog(t *testing.T) {
+	// Arrange
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	mockUseCase := new(MockUseCase)
+	mockConfig := &MockConfig{IPLimit: 10, IPWindow: time.Second, IPBlockTime: time.Minute}
+	mockUseCase.On("Execute", mock.Anything, mock.AnythingOfType("check_rate_limit.Input")).Return(nil, context.Canceled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	// Act
+	NewRateLimiterMiddleware(mockUseCase, mockConfig, logger).Handle(http.NotFoundHandler()).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), RequestCancelledMessage)
+	assert.Empty(t, logs.String())
 }
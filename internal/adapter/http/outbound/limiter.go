@@ -0,0 +1,136 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// minRetryDelay evita que o chamador tente de novo em seguida quando falta uma fração mínima de token
+const minRetryDelay = 10 * time.Millisecond
+
+// refundTimeout limita a devolução do token de uma espera cancelada
+const refundTimeout = time.Second
+
+// ErrRateLimited indica que a chamada excederia o limite do destino
+var ErrRateLimited = errors.New("outbound rate limit exceeded")
+
+// RateLimitedError é o erro retornado quando a chamada é rejeitada (modo sem espera)
+// errors.Is(err, ErrRateLimited) identifica o erro
+type RateLimitedError struct {
+	Key        entity.LimiterKey
+	RetryAfter time.Duration // Tempo até um token ficar disponível
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s for %s (retry after %s)", ErrRateLimited, e.Key.Value, e.RetryAfter)
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Limit é o limite de chamadas a um destino: Limit chamadas a cada Window
+type Limit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// enabled indica se o limite deve ser aplicado (limite zero não limita)
+func (l Limit) enabled() bool {
+	return l.Limit > 0 && l.Window > 0
+}
+
+// refillDuration é o tempo para o bucket recuperar tokens
+func (l Limit) refillDuration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens * float64(l.Window) / float64(l.Limit))
+}
+
+// Limiter limita as chamadas a um destino com um token bucket no storage compartilhado
+// Como o estado fica no storage (ex: Redis), o limite vale para todas as réplicas do serviço
+// Diferente do rate limiting de entrada, não há bloqueio: a chamada só espera o próximo token
+type Limiter struct {
+	storage repository.Storage
+	key     entity.LimiterKey
+	limit   Limit
+}
+
+// NewLimiter cria o limiter do destino (ex: o host de uma API de terceiros)
+func NewLimiter(storage repository.Storage, destination string, limit Limit) *Limiter {
+	return &Limiter{
+		storage: storage,
+		key:     entity.NewOutboundKey(destination),
+		limit:   limit,
+	}
+}
+
+// Allow consome um token se houver um disponível
+// Retorna *RateLimitedError (com o tempo até o próximo token) quando não há
+func (l *Limiter) Allow(ctx context.Context) error {
+	result, err := l.storage.CheckAndConsume(ctx, l.key, l.limit.Limit, l.limit.Window, 1)
+	if err != nil {
+		return fmt.Errorf("outbound rate limit check for %s: %w", l.key.Value, err)
+	}
+	if result.Allowed {
+		return nil
+	}
+	return &RateLimitedError{
+		Key:        l.key,
+		RetryAfter: max(l.limit.refillDuration(1-result.CurrentTokens), minRetryDelay),
+	}
+}
+
+// Wait reserva o próximo token e espera até ele ficar disponível
+// Se o token só ficaria disponível depois do deadline do contexto, falha na hora sem reservar
+// (context.DeadlineExceeded); se o contexto terminar durante a espera, o token é devolvido
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = time.Until(deadline)
+	}
+
+	result, err := l.storage.Reserve(ctx, l.key, l.limit.Limit, l.limit.Window, 1, maxWait)
+	if err != nil {
+		return fmt.Errorf("outbound rate limit reservation for %s: %w", l.key.Value, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("outbound rate limit for %s: no token before the deadline: %w", l.key.Value, context.DeadlineExceeded)
+	}
+	if result.Delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(result.Delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.Join(ctx.Err(), l.refund(ctx))
+	}
+}
+
+// refund devolve o token reservado por uma espera cancelada
+// ctx já terminou quando isto roda, então a chamada ao storage não herda o cancelamento
+func (l *Limiter) refund(ctx context.Context) error {
+	refundCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refundTimeout)
+	defer cancel()
+
+	if err := l.storage.Refund(refundCtx, l.key, l.limit.Limit, l.limit.Window, 1); err != nil {
+		return fmt.Errorf("outbound rate limit refund for %s: %w", l.key.Value, err)
+	}
+	return nil
+}
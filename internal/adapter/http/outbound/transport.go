@@ -0,0 +1,99 @@
+package outbound
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// KeyFunc escolhe o destino (a chave do limite) de uma requisição
+type KeyFunc func(req *http.Request) string
+
+// HostKey usa o host de destino como chave (padrão)
+func HostKey(req *http.Request) string {
+	return strings.ToLower(req.URL.Hostname())
+}
+
+// Transport é um http.RoundTripper que respeita os limites das APIs chamadas
+// Cada destino (host, ou a chave de WithKeyFunc) tem o próprio bucket no storage compartilhado;
+// por padrão a chamada acima do limite falha com *RateLimitedError, e WithWait faz a chamada esperar
+type Transport struct {
+	base         http.RoundTripper
+	storage      repository.Storage
+	defaultLimit Limit
+	limits       map[string]Limit
+	keyFunc      KeyFunc
+	wait         bool
+}
+
+// NewTransport cria o transport
+// base é o transport que faz a chamada (nil usa http.DefaultTransport)
+// defaultLimit vale para destinos sem limite próprio (limite zero deixa esses destinos sem limite)
+func NewTransport(base http.RoundTripper, storage repository.Storage, defaultLimit Limit) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:         base,
+		storage:      storage,
+		defaultLimit: defaultLimit,
+		limits:       make(map[string]Limit),
+		keyFunc:      HostKey,
+	}
+}
+
+// WithLimit define o limite de um destino (o host, ou a chave retornada pela KeyFunc)
+func (t *Transport) WithLimit(key string, limit Limit) *Transport {
+	t.limits[key] = limit
+	return t
+}
+
+// WithKeyFunc troca a chave do limite (ex: um nome de API compartilhado por vários hosts)
+// Requisições com chave vazia não são limitadas
+func (t *Transport) WithKeyFunc(keyFunc KeyFunc) *Transport {
+	t.keyFunc = keyFunc
+	return t
+}
+
+// WithWait faz as chamadas acima do limite esperarem o próximo token em vez de falhar
+// A espera respeita o contexto da requisição
+func (t *Transport) WithWait() *Transport {
+	t.wait = true
+	return t
+}
+
+// RoundTrip implementa http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if limiter := t.limiter(t.keyFunc(req)); limiter != nil {
+		var err error
+		if t.wait {
+			err = limiter.Wait(req.Context())
+		} else {
+			err = limiter.Allow(req.Context())
+		}
+		if err != nil {
+			// O RoundTripper deve fechar o body mesmo quando não envia a requisição
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// limiter retorna o limiter do destino, ou nil quando ele não tem limite
+func (t *Transport) limiter(key string) *Limiter {
+	if key == "" {
+		return nil
+	}
+	limit, ok := t.limits[key]
+	if !ok {
+		limit = t.defaultLimit
+	}
+	if !limit.enabled() {
+		return nil
+	}
+	return NewLimiter(t.storage, key, limit)
+}
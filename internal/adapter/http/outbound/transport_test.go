@@ -0,0 +1,152 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// newUpstream sobe uma API de terceiros que conta as chamadas recebidas
+func newUpstream(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func get(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestTransport_RejectsAboveLimitWithoutCallingUpstream(t *testing.T) {
+	// Arrange
+	upstream, calls := newUpstream(t)
+	storage := memory.NewMemoryStorage()
+	client := &http.Client{Transport: NewTransport(nil, storage, Limit{Limit: 2, Window: time.Minute})}
+
+	// Act
+	first := get(client, upstream.URL)
+	second := get(client, upstream.URL)
+	third := get(client, upstream.URL)
+
+	// Assert
+	require.NoError(t, first)
+	require.NoError(t, second)
+	assert.ErrorIs(t, third, ErrRateLimited)
+
+	var limited *RateLimitedError
+	require.ErrorAs(t, third, &limited)
+	assert.Equal(t, entity.NewOutboundKey("127.0.0.1"), limited.Key)
+	assert.InDelta(t, 30*time.Second, limited.RetryAfter, float64(time.Second))
+	assert.Equal(t, int32(2), calls.Load())
+
+	state, err := storage.GetKeyState(context.Background(), entity.NewOutboundKey("127.0.0.1"))
+	require.NoError(t, err)
+	assert.True(t, state.Exists, "o bucket fica no storage compartilhado")
+}
+
+func TestTransport_WaitMode_SleepsUntilTokenIsAvailable(t *testing.T) {
+	// Arrange - 1 chamada a cada 100ms
+	upstream, calls := newUpstream(t)
+	transport := NewTransport(nil, memory.NewMemoryStorage(), Limit{Limit: 1, Window: 100 * time.Millisecond}).WithWait()
+	client := &http.Client{Transport: transport}
+
+	// Act
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, get(client, upstream.URL))
+	}
+	elapsed := time.Since(start)
+
+	// Assert
+	assert.Equal(t, int32(3), calls.Load())
+	assert.GreaterOrEqual(t, elapsed, 150*time.Millisecond)
+}
+
+func TestTransport_WaitMode_StopsWhenContextEnds(t *testing.T) {
+	// Arrange
+	upstream, calls := newUpstream(t)
+	transport := NewTransport(nil, memory.NewMemoryStorage(), Limit{Limit: 1, Window: time.Hour}).WithWait()
+	client := &http.Client{Transport: transport}
+	require.NoError(t, get(client, upstream.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Do(req)
+
+	// Assert
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLimiter_Wait_CancelledMidWait_RefundsToken(t *testing.T) {
+	// Arrange - 1 chamada a cada 200ms, token atual já consumido
+	storage := memory.NewMemoryStorage()
+	limiter := NewLimiter(storage, "api.example.com", Limit{Limit: 1, Window: 200 * time.Millisecond})
+	require.NoError(t, limiter.Allow(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	// Act
+	err := limiter.Wait(ctx)
+
+	// Assert - o token reservado volta ao bucket em vez de ficar em débito
+	assert.True(t, errors.Is(err, context.Canceled))
+	state, err := storage.GetKeyState(context.Background(), entity.NewOutboundKey("api.example.com"))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, state.Tokens, 0.0)
+}
+
+func TestTransport_PerKeyLimitsAndCustomKey(t *testing.T) {
+	// Arrange - sem limite padrão: só a chave "partner-api" é limitada
+	upstream, calls := newUpstream(t)
+	limited := NewTransport(nil, memory.NewMemoryStorage(), Limit{}).
+		WithKeyFunc(func(req *http.Request) string { return req.Header.Get("X-Api-Name") }).
+		WithLimit("partner-api", Limit{Limit: 1, Window: time.Minute})
+	client := &http.Client{Transport: limited}
+
+	request := func(apiName string) error {
+		req, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+		req.Header.Set("X-Api-Name", apiName)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// Act
+	first := request("partner-api")
+	second := request("partner-api")
+	unlimited := []error{request("other-api"), request("other-api"), request("")}
+
+	// Assert
+	assert.NoError(t, first)
+	assert.ErrorIs(t, second, ErrRateLimited)
+	for _, err := range unlimited {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(4), calls.Load())
+}
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Timeouts padrão do transporte até o upstream
+const (
+	defaultDialTimeout     = 5 * time.Second
+	defaultResponseTimeout = 30 * time.Second
+)
+
+// route encaminha os paths que casam com pattern para target
+type route struct {
+	pattern string // Exato ou prefixo terminado em "/*"
+	target  *url.URL
+}
+
+// ReverseProxy encaminha as requisições (já liberadas pelo rate limiter) ao upstream
+// Usa httputil.ReverseProxy: headers fim-a-fim, streaming (SSE/chunked) e upgrade de websocket
+// são repassados; X-Forwarded-For/Host/Proto são acrescentados e o traceparent é propagado
+type ReverseProxy struct {
+	defaultTarget *url.URL
+	routes        []route
+	preserveHost  bool
+	transport     *http.Transport
+	proxy         *httputil.ReverseProxy
+	logger        *slog.Logger
+}
+
+// NewReverseProxy cria o proxy
+// defaultTarget recebe os paths sem rota específica; nil faz esses paths responderem 404
+func NewReverseProxy(defaultTarget *url.URL, logger *slog.Logger) *ReverseProxy {
+	p := &ReverseProxy{
+		defaultTarget: defaultTarget,
+		logger:        logger,
+	}
+
+	p.transport = http.DefaultTransport.(*http.Transport).Clone()
+	p.WithTimeouts(defaultDialTimeout, defaultResponseTimeout)
+
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite:      p.rewrite,
+		Transport:    p.transport,
+		ErrorHandler: p.handleError,
+	}
+	return p
+}
+
+// WithRoute encaminha os paths que casam com pattern (exato ou prefixo terminado em "/*") para target
+// As rotas são avaliadas na ordem em que foram adicionadas; a primeira que casar vence
+func (p *ReverseProxy) WithRoute(pattern string, target *url.URL) *ReverseProxy {
+	p.routes = append(p.routes, route{pattern: pattern, target: target})
+	return p
+}
+
+// WithPreserveHost mantém o header Host original em vez do host do upstream
+func (p *ReverseProxy) WithPreserveHost(preserve bool) *ReverseProxy {
+	p.preserveHost = preserve
+	return p
+}
+
+// WithTimeouts define o timeout de conexão e o tempo máximo até o upstream enviar os headers da resposta
+// O corpo da resposta não tem timeout, para não interromper streaming e websockets
+func (p *ReverseProxy) WithTimeouts(dial, response time.Duration) *ReverseProxy {
+	dialer := &net.Dialer{Timeout: dial, KeepAlive: 30 * time.Second}
+	p.transport.DialContext = dialer.DialContext
+	p.transport.ResponseHeaderTimeout = response
+	return p
+}
+
+func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.target(r.URL.Path) == nil {
+		writeError(w, http.StatusNotFound, "no upstream configured for this path")
+		return
+	}
+	p.proxy.ServeHTTP(w, r)
+}
+
+// target retorna o upstream do path (rota específica ou o padrão)
+func (p *ReverseProxy) target(path string) *url.URL {
+	for _, route := range p.routes {
+		if matchPath(route.pattern, path) {
+			return route.target
+		}
+	}
+	return p.defaultTarget
+}
+
+func (p *ReverseProxy) rewrite(pr *httputil.ProxyRequest) {
+	pr.SetURL(p.target(pr.In.URL.Path))
+	pr.SetXForwarded()
+	if p.preserveHost {
+		pr.Out.Host = pr.In.Host
+	}
+
+	// Continua o trace iniciado no middleware de rate limit
+	otel.GetTextMapPropagator().Inject(pr.Out.Context(), propagation.HeaderCarrier(pr.Out.Header))
+}
+
+// handleError responde 504 quando o upstream não responde a tempo e 502 nos demais erros
+func (p *ReverseProxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) {
+		// O cliente desistiu da requisição: não há a quem responder
+		p.logger.DebugContext(r.Context(), "Proxy request canceled by client", "path", r.URL.Path)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	p.logger.WarnContext(r.Context(), "Upstream request failed",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"upstream", p.target(r.URL.Path).Redacted(),
+		"error", err,
+	)
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		writeError(w, http.StatusGatewayTimeout, "upstream timeout")
+		return
+	}
+	writeError(w, http.StatusBadGateway, "upstream unavailable")
+}
+
+// matchPath casa o path com um padrão exato ou com prefixo terminado em "/*"
+func matchPath(pattern, path string) bool {
+	if base, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return path == base || strings.HasPrefix(path, base+"/")
+	}
+	return path == pattern
+}
+
+// writeError envia uma resposta de erro no formato {"error": "..."}
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUpstream sobe um upstream que responde com o próprio nome e ecoa Host e X-Forwarded-For
+func newUpstream(t *testing.T, name string) *url.URL {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", name)
+		w.Header().Set("X-Seen-Host", r.Host)
+		w.Header().Set("X-Seen-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+		w.Header().Set("X-Seen-Custom", r.Header.Get("X-Custom"))
+		fmt.Fprint(w, r.URL.RequestURI())
+	}))
+	t.Cleanup(upstream.Close)
+
+	target, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+	return target
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestReverseProxy_RoutesToUpstreamAndPreservesHeaders(t *testing.T) {
+	// Arrange
+	api := newUpstream(t, "api")
+	web := newUpstream(t, "web")
+	proxy := NewReverseProxy(web, discardLogger()).WithRoute("/api/*", api)
+
+	tests := map[string]struct {
+		path     string
+		upstream string
+	}{
+		"prefix route":     {path: "/api/users?page=2", upstream: "api"},
+		"prefix base":      {path: "/api", upstream: "api"},
+		"default upstream": {path: "/index.html", upstream: "web"},
+		"similar prefix":   {path: "/apis", upstream: "web"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://public.example"+tt.path, nil)
+			req.RemoteAddr = "203.0.113.7:5555"
+			req.Header.Set("X-Custom", "kept")
+			w := httptest.NewRecorder()
+
+			// Act
+			proxy.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.upstream, w.Header().Get("X-Upstream"))
+			assert.Equal(t, tt.path, w.Body.String())
+			assert.Equal(t, "kept", w.Header().Get("X-Seen-Custom"))
+			assert.Equal(t, "203.0.113.7", w.Header().Get("X-Seen-Forwarded-For"))
+		})
+	}
+}
+
+func TestReverseProxy_PreserveHost(t *testing.T) {
+	upstream := newUpstream(t, "api")
+
+	for _, preserve := range []bool{false, true} {
+		t.Run(fmt.Sprint(preserve), func(t *testing.T) {
+			// Arrange
+			proxy := NewReverseProxy(upstream, discardLogger()).WithPreserveHost(preserve)
+			req := httptest.NewRequest(http.MethodGet, "http://public.example/", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			proxy.ServeHTTP(w, req)
+
+			// Assert
+			if preserve {
+				assert.Equal(t, "public.example", w.Header().Get("X-Seen-Host"))
+			} else {
+				assert.Equal(t, upstream.Host, w.Header().Get("X-Seen-Host"))
+			}
+		})
+	}
+}
+
+func TestReverseProxy_NoUpstream_Returns404(t *testing.T) {
+	proxy := NewReverseProxy(nil, discardLogger()).WithRoute("/api/*", newUpstream(t, "api"))
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReverseProxy_UpstreamErrors(t *testing.T) {
+	// Arrange - upstream lento (timeout) e upstream fora do ar (conexão recusada)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(slow.Close)
+	slowURL, _ := url.Parse(slow.URL)
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL, _ := url.Parse(down.URL)
+	down.Close()
+
+	proxy := NewReverseProxy(downURL, discardLogger()).
+		WithRoute("/slow", slowURL).
+		WithTimeouts(time.Second, 50*time.Millisecond)
+
+	// Act
+	timeout := httptest.NewRecorder()
+	proxy.ServeHTTP(timeout, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	unavailable := httptest.NewRecorder()
+	proxy.ServeHTTP(unavailable, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusGatewayTimeout, timeout.Code)
+	assert.Equal(t, http.StatusBadGateway, unavailable.Code)
+	assert.JSONEq(t, `{"error":"upstream unavailable"}`, unavailable.Body.String())
+}
+
+func TestReverseProxy_StreamsResponse(t *testing.T) {
+	// Arrange - o upstream envia um evento e só termina depois que o cliente o recebeu
+	received := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+		}
+		fmt.Fprint(w, "data: second\n\n")
+	}))
+	t.Cleanup(upstream.Close)
+	target, _ := url.Parse(upstream.URL)
+
+	server := httptest.NewServer(NewReverseProxy(target, discardLogger()))
+	t.Cleanup(server.Close)
+
+	// Act
+	resp, err := http.Get(server.URL + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	first, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	close(received)
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	// Assert - o primeiro evento chegou antes do upstream terminar a resposta
+	assert.Equal(t, "data: first\n", first)
+	assert.Contains(t, string(rest), "data: second")
+}
+
+func TestReverseProxy_WebsocketUpgrade(t *testing.T) {
+	// Arrange - upstream que aceita o upgrade e ecoa uma linha pela conexão
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		buf.Flush()
+		line, _ := buf.ReadString('\n')
+		buf.WriteString("echo: " + line)
+		buf.Flush()
+	}))
+	t.Cleanup(upstream.Close)
+	target, _ := url.Parse(upstream.URL)
+
+	server := httptest.NewServer(NewReverseProxy(target, discardLogger()))
+	t.Cleanup(server.Close)
+
+	// Act
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(2*time.Second)))
+
+	fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: example\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	fmt.Fprint(conn, "hello\n")
+	echo, err := reader.ReadString('\n')
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "echo: hello\n", echo)
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace é o prefixo de todas as métricas expostas
+const namespace = "ratelimiter"
+
+// refreshTimeout limita cada consulta ao storage feita em background (ex: chaves bloqueadas)
+const refreshTimeout = 2 * time.Second
+
+// Metrics agrupa os coletores Prometheus do rate limiter
+// Cada instância tem seu próprio registry, exposto por Handler em /metrics
+type Metrics struct {
+	registry        *prometheus.Registry
+	decisions       *prometheus.CounterVec
+	useCaseErrors   *prometheus.CounterVec
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+	blockedKeys     *blockedKeysCollector // Registrado por InstrumentStorage
+}
+
+// New cria o registry com as métricas do rate limiter e as métricas padrão do processo Go
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "decisions_total",
+			Help:      "Rate limit decisions by result (allowed, rejected, blocked), key type and policy.",
+		}, []string{"decision", "key_type", "policy"}),
+		useCaseErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "check_errors_total",
+			Help:      "Rate limit checks that failed with an error (the request receives 500).",
+		}, []string{"key_type", "policy"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of storage operations.",
+			Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "errors_total",
+			Help:      "Storage operations that returned an error.",
+		}, []string{"operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.decisions,
+		m.useCaseErrors,
+		m.storageDuration,
+		m.storageErrors,
+	)
+	return m
+}
+
+// Handler retorna o handler HTTP do endpoint /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ReloadStats é o resultado acumulado das recargas de configuração
+type ReloadStats struct {
+	Successes uint64
+	Failures  uint64
+}
+
+// RegisterConfigReloads expõe os contadores de recarga de configuração (hot reload)
+// stats é consultada a cada scrape
+func (m *Metrics) RegisterConfigReloads(stats func() ReloadStats) {
+	m.registry.MustRegister(&reloadCollector{
+		stats: stats,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", "reloads_total"),
+			"Configuration reloads by result (success, failure).",
+			[]string{"result"}, nil,
+		),
+	})
+}
+
+// reloadCollector lê os contadores do reloader no momento do scrape
+type reloadCollector struct {
+	stats func() ReloadStats
+	desc  *prometheus.Desc
+}
+
+func (c *reloadCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *reloadCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(stats.Successes), "success")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(stats.Failures), "failure")
+}
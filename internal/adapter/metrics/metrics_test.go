@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// failingStorage simula um storage indisponível
+type failingStorage struct {
+	repository.Storage
+}
+
+func (s *failingStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+// shardedStub simula um storage sharded com um nó fora do anel
+type shardedStub struct {
+	repository.Storage
+}
+
+func (s *shardedStub) Health() map[string]bool {
+	return map[string]bool{"redis-1:6379": true, "redis-2:6379": false}
+}
+
+func TestInstrumentedUseCase_CountsDecisionsByKeyTypeAndPolicy(t *testing.T) {
+	// Arrange
+	m := New()
+	storage := m.InstrumentStorage(memory.NewMemoryStorage())
+	useCase := m.InstrumentUseCase(check_rate_limit.NewUseCase(storage, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	input := check_rate_limit.Input{
+		Key:       entity.NewTokenKey("abc123").Scoped("upload"),
+		Limit:     1,
+		Window:    time.Minute,
+		BlockTime: time.Minute,
+		Policy:    check_rate_limit.RoutePolicy("upload"),
+	}
+
+	// Act - permitida, excede o limite (bloqueia) e já bloqueada
+	for i := 0; i < 3; i++ {
+		_, err := useCase.Execute(context.Background(), input)
+		require.NoError(t, err)
+	}
+
+	// Assert
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.decisions.WithLabelValues(check_rate_limit.DecisionAllowed, "token", "route:upload")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.decisions.WithLabelValues(check_rate_limit.DecisionRejected, "token", "route:upload")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.decisions.WithLabelValues(check_rate_limit.DecisionBlocked, "token", "route:upload")))
+	assert.Equal(t, 3, testutil.CollectAndCount(m.storageDuration, "ratelimiter_storage_operation_duration_seconds"),
+		"CheckAndConsume, IsBlocked e SetBlock")
+}
+
+func TestInstrumentedStorage_CountsErrors(t *testing.T) {
+	// Arrange
+	m := New()
+	storage := m.InstrumentStorage(&failingStorage{Storage: memory.NewMemoryStorage()})
+	useCase := m.InstrumentUseCase(check_rate_limit.NewUseCase(storage, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	input := check_rate_limit.Input{
+		Key:    entity.NewIPKey("192.168.1.1"),
+		Limit:  10,
+		Window: time.Second,
+		Policy: check_rate_limit.PolicyIP,
+	}
+
+	// Act
+	_, err := useCase.Execute(context.Background(), input)
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.storageErrors.WithLabelValues("IsBlocked")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.useCaseErrors.WithLabelValues("ip", "ip")))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.decisions))
+}
+
+func TestMetrics_BlockedKeys_RefreshedInBackgroundNotOnScrape(t *testing.T) {
+	// Arrange
+	m := New()
+	storage := m.InstrumentStorage(memory.NewMemoryStorage())
+	require.NoError(t, storage.SetBlock(context.Background(), entity.NewIPKey("10.0.0.1"), time.Minute, entity.BlockInfo{}))
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec.Body.String()
+	}
+
+	// Act - o scrape não consulta o storage
+	before := scrape()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.StartBlockedKeysRefresh(ctx, time.Hour)
+
+	// Assert
+	assert.NotContains(t, before, "ratelimiter_blocked_keys ")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(scrape(), "ratelimiter_blocked_keys 1")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMetrics_Handler_ExposesBlockedKeysCircuitAndReloads(t *testing.T) {
+	// Arrange
+	m := New()
+	backend := memory.NewMemoryStorage()
+	storage := m.InstrumentStorage(&shardedStub{Storage: backend})
+	m.RegisterConfigReloads(func() ReloadStats {
+		return ReloadStats{Successes: 3, Failures: 1}
+	})
+	require.NoError(t, storage.SetBlock(context.Background(), entity.NewIPKey("10.0.0.1"), time.Minute, entity.BlockInfo{}))
+	require.NoError(t, storage.SetBlock(context.Background(), entity.NewTokenKey("abc123"), time.Minute, entity.BlockInfo{}))
+
+	// Act
+	m.blockedKeys.refresh(context.Background(), time.Second)
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "ratelimiter_blocked_keys 2")
+	assert.Contains(t, body, `ratelimiter_storage_circuit_open{shard="redis-1:6379"} 0`)
+	assert.Contains(t, body, `ratelimiter_storage_circuit_open{shard="redis-2:6379"} 1`)
+	assert.Contains(t, body, `ratelimiter_config_reloads_total{result="success"} 3`)
+	assert.Contains(t, body, `ratelimiter_config_reloads_total{result="failure"} 1`)
+	assert.True(t, strings.Contains(body, "go_goroutines"), "métricas padrão do processo Go")
+}
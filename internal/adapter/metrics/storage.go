@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// healthReporter é implementado por storages com circuit breaker por nó (ex: ShardedStorage)
+type healthReporter interface {
+	Health() map[string]bool
+}
+
+// InstrumentedStorage decora um repository.Storage medindo a latência e os erros de cada operação
+type InstrumentedStorage struct {
+	storage  repository.Storage
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// InstrumentStorage decora o storage e registra as métricas que dependem dele:
+// chaves bloqueadas (atualizadas por StartBlockedKeysRefresh) e, no modo sharded, o estado do circuito de cada nó
+// Deve ser chamado uma única vez por Metrics
+func (m *Metrics) InstrumentStorage(storage repository.Storage) *InstrumentedStorage {
+	instrumented := &InstrumentedStorage{
+		storage:  storage,
+		duration: m.storageDuration,
+		errors:   m.storageErrors,
+	}
+
+	m.blockedKeys = &blockedKeysCollector{
+		storage: instrumented,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "blocked_keys"),
+			"Keys currently blocked (ListBlocked refreshed in the background).",
+			nil, nil,
+		),
+	}
+	m.registry.MustRegister(m.blockedKeys)
+
+	if health, ok := storage.(healthReporter); ok {
+		m.registry.MustRegister(&circuitCollector{
+			health: health,
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(namespace, "storage", "circuit_open"),
+				"Circuit state of each storage shard (1 = open, shard removed from the ring; 0 = closed).",
+				[]string{"shard"}, nil,
+			),
+		})
+	}
+
+	return instrumented
+}
+
+// observe registra a latência e o eventual erro de uma operação
+func (s *InstrumentedStorage) observe(operation string, start time.Time, err error) {
+	s.duration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		s.errors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (s *InstrumentedStorage) CheckAndConsume(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+) (*repository.CheckResult, error) {
+	start := time.Now()
+	result, err := s.storage.CheckAndConsume(ctx, key, limit, window, cost)
+	s.observe("CheckAndConsume", start, err)
+	return result, err
+}
+
+func (s *InstrumentedStorage) Reserve(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+	maxWait time.Duration,
+) (*repository.ReserveResult, error) {
+	start := time.Now()
+	result, err := s.storage.Reserve(ctx, key, limit, window, cost, maxWait)
+	s.observe("Reserve", start, err)
+	return result, err
+}
+
+func (s *InstrumentedStorage) Refund(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+) error {
+	start := time.Now()
+	err := s.storage.Refund(ctx, key, limit, window, cost)
+	s.observe("Refund", start, err)
+	return err
+}
+
+func (s *InstrumentedStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration, info entity.BlockInfo) error {
+	start := time.Now()
+	err := s.storage.SetBlock(ctx, key, blockTime, info)
+	s.observe("SetBlock", start, err)
+	return err
+}
+
+func (s *InstrumentedStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (bool, error) {
+	start := time.Now()
+	blocked, err := s.storage.IsBlocked(ctx, key)
+	s.observe("IsBlocked", start, err)
+	return blocked, err
+}
+
+func (s *InstrumentedStorage) GetKeyState(ctx context.Context, key entity.LimiterKey) (*repository.KeyState, error) {
+	start := time.Now()
+	state, err := s.storage.GetKeyState(ctx, key)
+	s.observe("GetKeyState", start, err)
+	return state, err
+}
+
+func (s *InstrumentedStorage) ResetBucket(ctx context.Context, key entity.LimiterKey) error {
+	start := time.Now()
+	err := s.storage.ResetBucket(ctx, key)
+	s.observe("ResetBucket", start, err)
+	return err
+}
+
+func (s *InstrumentedStorage) Unblock(ctx context.Context, key entity.LimiterKey) error {
+	start := time.Now()
+	err := s.storage.Unblock(ctx, key)
+	s.observe("Unblock", start, err)
+	return err
+}
+
+func (s *InstrumentedStorage) ListBlocked(ctx context.Context) ([]repository.BlockedKey, error) {
+	start := time.Now()
+	blocked, err := s.storage.ListBlocked(ctx)
+	s.observe("ListBlocked", start, err)
+	return blocked, err
+}
+
+func (s *InstrumentedStorage) AddStrike(ctx context.Context, key entity.LimiterKey, decay time.Duration) (int, error) {
+	start := time.Now()
+	strikes, err := s.storage.AddStrike(ctx, key, decay)
+	s.observe("AddStrike", start, err)
+	return strikes, err
+}
+
+func (s *InstrumentedStorage) ResetStrikes(ctx context.Context, key entity.LimiterKey) error {
+	start := time.Now()
+	err := s.storage.ResetStrikes(ctx, key)
+	s.observe("ResetStrikes", start, err)
+	return err
+}
+
+func (s *InstrumentedStorage) CountDistinct(ctx context.Context, key entity.LimiterKey, member string, window time.Duration) (int, error) {
+	start := time.Now()
+	count, err := s.storage.CountDistinct(ctx, key, member, window)
+	s.observe("CountDistinct", start, err)
+	return count, err
+}
+
+// Close fecha o storage decorado
+func (s *InstrumentedStorage) Close() error {
+	return s.storage.Close()
+}
+
+// StartBlockedKeysRefresh atualiza o número de chaves bloqueadas a cada interval até o contexto ser cancelado
+// ListBlocked percorre todas as chaves do storage (SCAN), então roda em background e não a cada scrape
+func (m *Metrics) StartBlockedKeysRefresh(ctx context.Context, interval time.Duration) {
+	if m.blockedKeys == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			m.blockedKeys.refresh(ctx, min(interval, refreshTimeout))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// blockedKeysCollector expõe o número de chaves bloqueadas da última atualização
+// Consultar o storage (e não contar localmente) mantém o valor correto com várias instâncias
+type blockedKeysCollector struct {
+	storage repository.Storage
+	desc    *prometheus.Desc
+	count   atomic.Int64
+	ready   atomic.Bool // false até a primeira consulta e após uma falha
+}
+
+// refresh consulta o storage e guarda o resultado para os próximos scrapes
+func (c *blockedKeysCollector) refresh(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	blocked, err := c.storage.ListBlocked(ctx)
+	if err != nil {
+		// O erro já é contabilizado em storage_errors_total; a série fica ausente até a próxima consulta
+		c.ready.Store(false)
+		return
+	}
+	c.count.Store(int64(len(blocked)))
+	c.ready.Store(true)
+}
+
+func (c *blockedKeysCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *blockedKeysCollector) Collect(ch chan<- prometheus.Metric) {
+	if !c.ready.Load() {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(c.count.Load()))
+}
+
+// circuitCollector expõe o estado do circuito de cada nó do storage sharded
+type circuitCollector struct {
+	health healthReporter
+	desc   *prometheus.Desc
+}
+
+func (c *circuitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *circuitCollector) Collect(ch chan<- prometheus.Metric) {
+	for shard, healthy := range c.health.Health() {
+		open := 0.0
+		if !healthy {
+			open = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, open, shard)
+	}
+}
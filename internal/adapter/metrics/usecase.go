@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// UseCase é o caso de uso de verificação de rate limit
+type UseCase interface {
+	Execute(ctx context.Context, input check_rate_limit.Input) (*check_rate_limit.Output, error)
+}
+
+// InstrumentedUseCase decora o caso de uso contando as decisões por tipo de chave e política
+type InstrumentedUseCase struct {
+	useCase   UseCase
+	decisions *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+}
+
+// InstrumentUseCase decora o caso de uso
+func (m *Metrics) InstrumentUseCase(useCase UseCase) *InstrumentedUseCase {
+	return &InstrumentedUseCase{
+		useCase:   useCase,
+		decisions: m.decisions,
+		errors:    m.useCaseErrors,
+	}
+}
+
+func (u *InstrumentedUseCase) Execute(ctx context.Context, input check_rate_limit.Input) (*check_rate_limit.Output, error) {
+	output, err := u.useCase.Execute(ctx, input)
+
+	keyType := string(input.Key.Type)
+	if err != nil {
+		u.errors.WithLabelValues(keyType, input.Policy).Inc()
+		return output, err
+	}
+
+	u.decisions.WithLabelValues(output.Decision(), keyType, input.Policy).Inc()
+	return output, nil
+}
@@ -0,0 +1,176 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// auditRecord é o formato JSON de cada linha do arquivo de auditoria
+type auditRecord struct {
+	Action    string     `json:"action"`
+	Key       string     `json:"key"`
+	Time      time.Time  `json:"time"`
+	Duration  string     `json:"duration,omitempty"`
+	Actor     string     `json:"actor,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	BlockedAt *time.Time `json:"blocked_at,omitempty"`
+	Policy    string     `json:"policy,omitempty"`
+	Limit     int        `json:"limit,omitempty"`
+	Window    string     `json:"window,omitempty"`
+	Strikes   int        `json:"strikes,omitempty"`
+	Note      string     `json:"note,omitempty"`
+}
+
+// AuditLog implementa repository.AuditLog em um arquivo local, um evento JSON por linha
+// O arquivo é aberto com O_APPEND: eventos nunca são reescritos (rotação fica a cargo do logrotate)
+// Indicado para uma única instância; com várias instâncias prefira o Redis Stream
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewAuditLog abre (ou cria) o arquivo de auditoria para escrita em modo append
+func NewAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	return &AuditLog{path: path, file: file}, nil
+}
+
+// Close fecha o arquivo de auditoria
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// Append implementa o método da interface AuditLog
+func (a *AuditLog) Append(ctx context.Context, event entity.AuditEvent) error {
+	data, err := json.Marshal(newAuditRecord(event))
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Uma única escrita por linha: com O_APPEND a linha não se mistura com a de outro processo
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append audit event: %w", err)
+	}
+	return nil
+}
+
+// Recent implementa o método da interface AuditLog
+// Lê o arquivo inteiro e devolve as últimas linhas, mais recentes primeiro
+func (a *AuditLog) Recent(ctx context.Context, limit int) ([]entity.AuditEvent, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := os.Open(a.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []entity.AuditEvent{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	// Janela circular com os últimos "limit" eventos
+	var window []entity.AuditEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Ignora linhas corrompidas (ex: escrita interrompida)
+		}
+		event, err := record.toEvent()
+		if err != nil {
+			continue
+		}
+		window = append(window, event)
+		if len(window) > limit {
+			window = window[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	events := make([]entity.AuditEvent, 0, len(window))
+	for i := len(window) - 1; i >= 0; i-- {
+		events = append(events, window[i])
+	}
+	return events, nil
+}
+
+// newAuditRecord converte o evento para o formato do arquivo
+func newAuditRecord(event entity.AuditEvent) auditRecord {
+	record := auditRecord{
+		Action:  string(event.Action),
+		Key:     event.Key.String(),
+		Time:    event.Time.UTC(),
+		Actor:   event.Actor,
+		Reason:  string(event.Info.Reason),
+		Source:  event.Info.Source,
+		Policy:  event.Info.Policy,
+		Limit:   event.Info.Limit,
+		Strikes: event.Info.Strikes,
+		Note:    event.Info.Note,
+	}
+	if event.Duration > 0 {
+		record.Duration = event.Duration.String()
+	}
+	if !event.Info.BlockedAt.IsZero() {
+		blockedAt := event.Info.BlockedAt.UTC()
+		record.BlockedAt = &blockedAt
+	}
+	if event.Info.Window > 0 {
+		record.Window = event.Info.Window.String()
+	}
+	return record
+}
+
+// toEvent converte a linha do arquivo de volta para o evento
+func (r auditRecord) toEvent() (entity.AuditEvent, error) {
+	key, err := entity.ParseLimiterKey(r.Key)
+	if err != nil {
+		return entity.AuditEvent{}, err
+	}
+
+	event := entity.AuditEvent{
+		Action: entity.AuditAction(r.Action),
+		Key:    key,
+		Time:   r.Time,
+		Actor:  r.Actor,
+		Info: entity.BlockInfo{
+			Reason:  entity.BlockReason(r.Reason),
+			Source:  r.Source,
+			Policy:  r.Policy,
+			Limit:   r.Limit,
+			Strikes: r.Strikes,
+			Note:    r.Note,
+		},
+	}
+	if r.BlockedAt != nil {
+		event.Info.BlockedAt = *r.BlockedAt
+	}
+	if r.Duration != "" {
+		event.Duration, _ = time.ParseDuration(r.Duration)
+	}
+	if r.Window != "" {
+		event.Info.Window, _ = time.ParseDuration(r.Window)
+	}
+	return event, nil
+}
@@ -0,0 +1,85 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+func TestAuditLog_AppendAndRecent_NewestFirst(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := NewAuditLog(path)
+	require.NoError(t, err)
+	defer auditLog.Close()
+	ctx := context.Background()
+
+	blockedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	block := entity.AuditEvent{
+		Action:   entity.AuditActionBlock,
+		Key:      entity.NewIPKey("10.0.0.1"),
+		Time:     blockedAt,
+		Duration: 10 * time.Minute,
+		Info: entity.BlockInfo{
+			Reason:    entity.BlockReasonRateExceeded,
+			Source:    entity.BlockSourceRateLimiter,
+			BlockedAt: blockedAt,
+			Policy:    "ip",
+			Limit:     10,
+			Window:    time.Second,
+			Strikes:   2,
+		},
+	}
+	unblock := entity.AuditEvent{
+		Action: entity.AuditActionUnblock,
+		Key:    entity.NewIPKey("10.0.0.1"),
+		Time:   blockedAt.Add(time.Minute),
+		Actor:  "admin:alice",
+	}
+
+	// Act
+	require.NoError(t, auditLog.Append(ctx, block))
+	require.NoError(t, auditLog.Append(ctx, unblock))
+	events, err := auditLog.Recent(ctx, 10)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []entity.AuditEvent{unblock, block}, events)
+
+	latest, err := auditLog.Recent(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.AuditEvent{unblock}, latest)
+}
+
+func TestAuditLog_ReopenAppendsAndSkipsCorruptedLines(t *testing.T) {
+	// Arrange - arquivo com uma linha corrompida (ex: escrita interrompida)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(path, []byte("{\"action\":\"blo\n"), 0o600))
+
+	auditLog, err := NewAuditLog(path)
+	require.NoError(t, err)
+	defer auditLog.Close()
+	event := entity.AuditEvent{
+		Action: entity.AuditActionUnblock,
+		Key:    entity.NewTokenKey("hmac:abc"),
+		Time:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	require.NoError(t, auditLog.Append(context.Background(), event))
+	events, err := auditLog.Recent(context.Background(), 10)
+
+	// Assert - a linha antiga continua no arquivo, mas é ignorada na leitura
+	require.NoError(t, err)
+	assert.Equal(t, []entity.AuditEvent{event}, events)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `{"action":"blo`)
+}
@@ -0,0 +1,233 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// tokenConfigRecord é o formato JSON de cada token no arquivo
+type tokenConfigRecord struct {
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	BlockTime string `json:"block_time"`
+}
+
+// tokenConfigFile é o formato do arquivo: {"tokens": {"abc123": {...}}}
+type tokenConfigFile struct {
+	Tokens map[string]tokenConfigRecord `json:"tokens"`
+}
+
+// TokenConfigStore implementa repository.TokenConfigStore usando um arquivo JSON local
+// Indicado para uma única instância ou arquivo compartilhado (ex: ConfigMap montado em volume);
+// alterações feitas por outros processos são detectadas via fsnotify
+type TokenConfigStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewTokenConfigStore cria o store sobre o arquivo informado (criado no primeiro Save)
+func NewTokenConfigStore(path string) *TokenConfigStore {
+	return &TokenConfigStore{
+		path: path,
+	}
+}
+
+// Get implementa o método da interface TokenConfigStore
+func (s *TokenConfigStore) Get(ctx context.Context, token string) (*entity.TokenConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	record, exists := data.Tokens[token]
+	if !exists {
+		return nil, repository.ErrTokenConfigNotFound
+	}
+
+	cfg, err := decodeTokenConfig(token, record)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// List implementa o método da interface TokenConfigStore
+func (s *TokenConfigStore) List(ctx context.Context) ([]entity.TokenConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]entity.TokenConfig, 0, len(data.Tokens))
+	for token, record := range data.Tokens {
+		cfg, err := decodeTokenConfig(token, record)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, cfg)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Token < configs[j].Token })
+
+	return configs, nil
+}
+
+// Save implementa o método da interface TokenConfigStore
+func (s *TokenConfigStore) Save(ctx context.Context, cfg entity.TokenConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	data.Tokens[cfg.Token] = tokenConfigRecord{
+		Limit:     cfg.Limit,
+		Window:    cfg.Window.String(),
+		BlockTime: cfg.BlockTime.String(),
+	}
+	return s.write(data)
+}
+
+// Delete implementa o método da interface TokenConfigStore
+func (s *TokenConfigStore) Delete(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if _, exists := data.Tokens[token]; !exists {
+		return repository.ErrTokenConfigNotFound
+	}
+	delete(data.Tokens, token)
+	return s.write(data)
+}
+
+// Watch implementa o método da interface TokenConfigStore
+// Observa o diretório (e não o arquivo) para sobreviver a escritas atômicas via rename
+func (s *TokenConfigStore) Watch(ctx context.Context, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(s.path)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", s.path, err)
+	}
+
+	target := filepath.Clean(s.path)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("file watcher closed")
+			}
+			if filepath.Clean(event.Name) == target && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				onChange()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("file watcher closed")
+			}
+			return fmt.Errorf("file watcher error: %w", err)
+		}
+	}
+}
+
+// read carrega o arquivo; arquivo inexistente equivale a nenhum token (deve ser chamado com lock)
+func (s *TokenConfigStore) read() (*tokenConfigFile, error) {
+	data := &tokenConfigFile{Tokens: make(map[string]tokenConfigRecord)}
+
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token config file: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("invalid token config file %s: %w", s.path, err)
+	}
+	if data.Tokens == nil {
+		data.Tokens = make(map[string]tokenConfigRecord)
+	}
+	return data, nil
+}
+
+// write grava o arquivo de forma atômica (arquivo temporário + rename) (deve ser chamado com lock)
+func (s *TokenConfigStore) write(data *tokenConfigFile) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode token config file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".token_configs-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write token config file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // Não faz nada após o rename
+
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token config file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write token config file: %w", err)
+	}
+	return nil
+}
+
+// decodeTokenConfig converte o registro do arquivo em entity.TokenConfig
+func decodeTokenConfig(token string, record tokenConfigRecord) (entity.TokenConfig, error) {
+	window, err := time.ParseDuration(record.Window)
+	if err != nil {
+		return entity.TokenConfig{}, fmt.Errorf("invalid window for token %s: %w", token, err)
+	}
+
+	var blockTime time.Duration
+	if record.BlockTime != "" {
+		if blockTime, err = time.ParseDuration(record.BlockTime); err != nil {
+			return entity.TokenConfig{}, fmt.Errorf("invalid block time for token %s: %w", token, err)
+		}
+	}
+
+	return entity.TokenConfig{
+		Token:     token,
+		Limit:     record.Limit,
+		Window:    window,
+		BlockTime: blockTime,
+	}, nil
+}
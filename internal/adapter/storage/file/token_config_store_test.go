@@ -0,0 +1,77 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+func TestTokenConfigStore_SaveGetListDelete(t *testing.T) {
+	// Arrange
+	store := NewTokenConfigStore(filepath.Join(t.TempDir(), "tokens.json"))
+	ctx := context.Background()
+	cfg := entity.TokenConfig{Token: "abc123", Limit: 100, Window: time.Second, BlockTime: 10 * time.Minute}
+
+	// Act & Assert - arquivo inexistente equivale a nenhum token
+	configs, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+
+	require.NoError(t, store.Save(ctx, cfg))
+
+	got, err := store.Get(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, cfg, *got)
+
+	configs, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.TokenConfig{cfg}, configs)
+
+	require.NoError(t, store.Delete(ctx, "abc123"))
+	_, err = store.Get(ctx, "abc123")
+	assert.ErrorIs(t, err, repository.ErrTokenConfigNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "abc123"), repository.ErrTokenConfigNotFound)
+}
+
+func TestTokenConfigStore_Save_RejectsInvalidConfig(t *testing.T) {
+	store := NewTokenConfigStore(filepath.Join(t.TempDir(), "tokens.json"))
+
+	err := store.Save(context.Background(), entity.TokenConfig{Token: "abc123", Limit: 0, Window: time.Second})
+
+	assert.Error(t, err)
+}
+
+func TestTokenConfigStore_Watch_NotifiesExternalChanges(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewTokenConfigStore(path)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 10)
+	go store.Watch(ctx, func() { changed <- struct{}{} })
+	time.Sleep(100 * time.Millisecond) // Aguarda o watcher ser registrado
+
+	// Act - outro processo escreve o arquivo
+	require.NoError(t, os.WriteFile(path, []byte(`{"tokens":{"xyz789":{"limit":50,"window":"1s"}}}`), 0o600))
+
+	// Assert
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected change notification")
+	}
+
+	got, err := store.Get(context.Background(), "xyz789")
+	require.NoError(t, err)
+	assert.Equal(t, 50, got.Limit)
+	assert.Zero(t, got.BlockTime)
+}
@@ -0,0 +1,364 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// bucketTTL replica o TTL das chaves de bucket no Redis (SETEX 3600 no script Lua)
+const bucketTTL = time.Hour
+
+// purgeInterval define a frequência mínima de limpeza das entradas expiradas
+const purgeInterval = time.Minute
+
+// bucket guarda o estado do Token Bucket de uma chave
+type bucket struct {
+	rateLimit *entity.RateLimit
+	expiresAt time.Time
+}
+
+// block guarda um bloqueio ativo
+type block struct {
+	key       entity.LimiterKey
+	info      entity.BlockInfo
+	expiresAt time.Time
+}
+
+// strike guarda o contador de violações consecutivas de uma chave
+type strike struct {
+	count     int
+	expiresAt time.Time
+}
+
+// distinctSet guarda os membros distintos de uma chave durante a janela
+type distinctSet struct {
+	members   map[string]struct{}
+	expiresAt time.Time
+}
+
+// MemoryStorage implementa a interface repository.Storage em memória
+// Útil para desenvolvimento local e testes; o estado não é compartilhado entre instâncias
+type MemoryStorage struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	blocks    map[string]*block
+	strikes   map[string]*strike
+	distinct  map[string]*distinctSet
+	lastPurge time.Time
+	now       func() time.Time // Fonte de tempo (substituível nos testes)
+}
+
+// NewMemoryStorage cria uma nova instância de MemoryStorage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		buckets:  make(map[string]*bucket),
+		blocks:   make(map[string]*block),
+		strikes:  make(map[string]*strike),
+		distinct: make(map[string]*distinctSet),
+		now:      time.Now,
+	}
+}
+
+// CheckAndConsume implementa o método da interface Storage
+// Usa as regras de refill/consumo da entidade RateLimit sob um mutex (operação atômica)
+func (m *MemoryStorage) CheckAndConsume(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+) (*repository.CheckResult, error) {
+	if err := validateBucket(limit, window, cost); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.refilledBucketLocked(key, limit, window)
+	allowed := b.rateLimit.ConsumeTokens(cost) == nil
+
+	return &repository.CheckResult{
+		Allowed:       allowed,
+		CurrentTokens: b.rateLimit.CurrentTokens,
+		Limit:         limit,
+	}, nil
+}
+
+// Reserve implementa o método da interface Storage
+func (m *MemoryStorage) Reserve(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+	maxWait time.Duration,
+) (*repository.ReserveResult, error) {
+	if err := validateBucket(limit, window, cost); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.refilledBucketLocked(key, limit, window)
+	delay, ok := b.rateLimit.ReserveTokens(cost, maxWait)
+
+	return &repository.ReserveResult{
+		OK:            ok,
+		Delay:         delay,
+		CurrentTokens: b.rateLimit.CurrentTokens,
+		Limit:         limit,
+	}, nil
+}
+
+// Refund implementa o método da interface Storage
+func (m *MemoryStorage) Refund(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+) error {
+	if err := validateBucket(limit, window, cost); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Sem bucket (ou expirado) ele já começaria cheio
+	if b, exists := m.buckets[key.String()]; !exists || !m.now().Before(b.expiresAt) {
+		return nil
+	}
+	m.refilledBucketLocked(key, limit, window).rateLimit.RefundTokens(cost)
+	return nil
+}
+
+// validateBucket valida os parâmetros do Token Bucket
+func validateBucket(limit int, window time.Duration, cost int) error {
+	if limit <= 0 {
+		return fmt.Errorf("limit must be positive, got: %d", limit)
+	}
+	if window <= 0 {
+		return fmt.Errorf("window must be positive, got: %v", window)
+	}
+	if cost <= 0 {
+		return fmt.Errorf("cost must be positive, got: %d", cost)
+	}
+	return nil
+}
+
+// refilledBucketLocked retorna o bucket da chave com o refill aplicado até agora
+// Deve ser chamado com o mutex travado
+func (m *MemoryStorage) refilledBucketLocked(key entity.LimiterKey, limit int, window time.Duration) *bucket {
+	now := m.now()
+	m.purgeExpiredLocked(now)
+
+	// Bucket novo (ou expirado) começa cheio
+	b, exists := m.buckets[key.String()]
+	if !exists || !now.Before(b.expiresAt) {
+		b = &bucket{rateLimit: entity.NewRateLimit(key, limit, window, 0)}
+		b.rateLimit.LastRefill = now
+		m.buckets[key.String()] = b
+	}
+
+	// Limite e janela podem mudar entre chamadas (ex: reload de configuração)
+	b.rateLimit.Limit = limit
+	b.rateLimit.Window = window
+	b.rateLimit.RefillTokens(now)
+	b.expiresAt = now.Add(bucketTTL)
+	return b
+}
+
+// SetBlock implementa o método da interface Storage
+func (m *MemoryStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration, info entity.BlockInfo) error {
+	if blockTime <= 0 {
+		return fmt.Errorf("block time must be positive, got: %v", blockTime)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blocks[key.String()] = &block{key: key, info: info, expiresAt: m.now().Add(blockTime)}
+	return nil
+}
+
+// IsBlocked implementa o método da interface Storage
+func (m *MemoryStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, blocked := m.activeBlockLocked(key, m.now())
+	return blocked, nil
+}
+
+// GetKeyState implementa o método da interface Storage
+func (m *MemoryStorage) GetKeyState(ctx context.Context, key entity.LimiterKey) (*repository.KeyState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	state := &repository.KeyState{Key: key}
+
+	if b, exists := m.buckets[key.String()]; exists && now.Before(b.expiresAt) {
+		state.Exists = true
+		state.Tokens = b.rateLimit.CurrentTokens
+		state.LastRefill = b.rateLimit.LastRefill
+	}
+
+	if ttl, blocked := m.activeBlockLocked(key, now); blocked {
+		state.Blocked = true
+		state.BlockTTL = ttl
+		state.BlockInfo = m.blocks[key.String()].info
+	}
+
+	if s, exists := m.strikes[key.String()]; exists && now.Before(s.expiresAt) {
+		state.Strikes = s.count
+	}
+
+	return state, nil
+}
+
+// ResetBucket implementa o método da interface Storage
+func (m *MemoryStorage) ResetBucket(ctx context.Context, key entity.LimiterKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.buckets, key.String())
+	return nil
+}
+
+// Unblock implementa o método da interface Storage
+func (m *MemoryStorage) Unblock(ctx context.Context, key entity.LimiterKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.blocks, key.String())
+	return nil
+}
+
+// ListBlocked implementa o método da interface Storage
+func (m *MemoryStorage) ListBlocked(ctx context.Context) ([]repository.BlockedKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var blocked []repository.BlockedKey
+	for _, b := range m.blocks {
+		if ttl := b.expiresAt.Sub(now); ttl > 0 {
+			blocked = append(blocked, repository.BlockedKey{Key: b.key, TTL: ttl, Info: b.info})
+		}
+	}
+
+	return blocked, nil
+}
+
+// AddStrike implementa o método da interface Storage
+// Cada violação renova o prazo de decay do contador
+func (m *MemoryStorage) AddStrike(ctx context.Context, key entity.LimiterKey, decay time.Duration) (int, error) {
+	if decay <= 0 {
+		return 0, fmt.Errorf("decay must be positive, got: %v", decay)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	s, exists := m.strikes[key.String()]
+	if !exists || !now.Before(s.expiresAt) {
+		s = &strike{}
+		m.strikes[key.String()] = s
+	}
+	s.count++
+	s.expiresAt = now.Add(decay)
+
+	return s.count, nil
+}
+
+// ResetStrikes implementa o método da interface Storage
+func (m *MemoryStorage) ResetStrikes(ctx context.Context, key entity.LimiterKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.strikes, key.String())
+	return nil
+}
+
+// CountDistinct implementa o método da interface Storage
+// A janela começa no primeiro membro e não é renovada pelos seguintes
+func (m *MemoryStorage) CountDistinct(ctx context.Context, key entity.LimiterKey, member string, window time.Duration) (int, error) {
+	if window <= 0 {
+		return 0, fmt.Errorf("window must be positive, got: %v", window)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.purgeExpiredLocked(now)
+
+	set, exists := m.distinct[key.String()]
+	if !exists || !now.Before(set.expiresAt) {
+		set = &distinctSet{members: make(map[string]struct{}), expiresAt: now.Add(window)}
+		m.distinct[key.String()] = set
+	}
+	set.members[member] = struct{}{}
+
+	return len(set.members), nil
+}
+
+// Close implementa o método da interface Storage (não há recursos externos)
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+// activeBlockLocked retorna o tempo restante do bloqueio, se houver (deve ser chamado com lock)
+func (m *MemoryStorage) activeBlockLocked(key entity.LimiterKey, now time.Time) (time.Duration, bool) {
+	b, exists := m.blocks[key.String()]
+	if !exists {
+		return 0, false
+	}
+
+	ttl := b.expiresAt.Sub(now)
+	if ttl <= 0 {
+		delete(m.blocks, key.String())
+		return 0, false
+	}
+	return ttl, true
+}
+
+// purgeExpiredLocked remove buckets, bloqueios, strikes e conjuntos expirados (deve ser chamado com lock)
+// Executa no máximo uma vez por purgeInterval para não percorrer os mapas a cada request
+func (m *MemoryStorage) purgeExpiredLocked(now time.Time) {
+	if now.Sub(m.lastPurge) < purgeInterval {
+		return
+	}
+	m.lastPurge = now
+
+	for k, b := range m.buckets {
+		if !now.Before(b.expiresAt) {
+			delete(m.buckets, k)
+		}
+	}
+	for k, b := range m.blocks {
+		if !now.Before(b.expiresAt) {
+			delete(m.blocks, k)
+		}
+	}
+	for k, s := range m.strikes {
+		if !now.Before(s.expiresAt) {
+			delete(m.strikes, k)
+		}
+	}
+	for k, s := range m.distinct {
+		if !now.Before(s.expiresAt) {
+			delete(m.distinct, k)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// newTestStorage cria um MemoryStorage com relógio controlado pelo teste
+func newTestStorage() (*MemoryStorage, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.now = func() time.Time { return now }
+	return storage, &now
+}
+
+func TestMemoryStorage_CheckAndConsume_AllowsFirstNRequests(t *testing.T) {
+	// Arrange
+	storage, _ := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	// Act & Assert
+	for i := 0; i < 5; i++ {
+		result, err := storage.CheckAndConsume(ctx, key, 5, time.Second, 1)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed, "request %d should be allowed", i+1)
+	}
+
+	result, err := storage.CheckAndConsume(ctx, key, 5, time.Second, 1)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestMemoryStorage_CheckAndConsume_RefillsTokensOverTime(t *testing.T) {
+	// Arrange
+	storage, now := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err := storage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+		require.NoError(t, err)
+	}
+
+	// Act - meio segundo gera 5 tokens
+	*now = now.Add(500 * time.Millisecond)
+	result, err := storage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.InDelta(t, 4.0, result.CurrentTokens, 0.001)
+}
+
+func TestMemoryStorage_CheckAndConsume_ConsumesCost(t *testing.T) {
+	// Arrange
+	storage, _ := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	// Act
+	first, err := storage.CheckAndConsume(ctx, key, 5, time.Second, 3)
+	require.NoError(t, err)
+	second, err := storage.CheckAndConsume(ctx, key, 5, time.Second, 3)
+	require.NoError(t, err)
+
+	// Assert - a requisição negada não consome os tokens restantes
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 2.0, first.CurrentTokens)
+	assert.False(t, second.Allowed)
+	assert.Equal(t, 2.0, second.CurrentTokens)
+}
+
+func TestMemoryStorage_CheckAndConsume_InvalidParams_ReturnsError(t *testing.T) {
+	storage, _ := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+
+	_, err := storage.CheckAndConsume(context.Background(), key, 0, time.Second, 1)
+	assert.Error(t, err)
+
+	_, err = storage.CheckAndConsume(context.Background(), key, 10, 0, 1)
+	assert.Error(t, err)
+
+	_, err = storage.CheckAndConsume(context.Background(), key, 10, time.Second, 0)
+	assert.Error(t, err)
+}
+
+func TestMemoryStorage_Reserve_QueuesCallersBehindTheDebt(t *testing.T) {
+	// Arrange
+	storage, now := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+	forever := time.Hour
+
+	// Act - 2 tokens por segundo: as reservas além do bucket esperam 500ms cada
+	first, err := storage.Reserve(ctx, key, 2, time.Second, 2, forever)
+	require.NoError(t, err)
+	second, err := storage.Reserve(ctx, key, 2, time.Second, 1, forever)
+	require.NoError(t, err)
+	third, err := storage.Reserve(ctx, key, 2, time.Second, 1, forever)
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, first.OK)
+	assert.Zero(t, first.Delay)
+	assert.Equal(t, 500*time.Millisecond, second.Delay)
+	assert.Equal(t, time.Second, third.Delay)
+	assert.Equal(t, -2.0, third.CurrentTokens)
+
+	// O refill quita a dívida antes de liberar novas requisições
+	*now = now.Add(time.Second)
+	result, err := storage.CheckAndConsume(ctx, key, 2, time.Second, 1)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestMemoryStorage_Reserve_ExceedingMaxWait_ReservesNothing(t *testing.T) {
+	// Arrange
+	storage, _ := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+	_, err := storage.Reserve(ctx, key, 2, time.Second, 2, 0)
+	require.NoError(t, err)
+
+	// Act
+	result, err := storage.Reserve(ctx, key, 2, time.Second, 1, 100*time.Millisecond)
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, result.OK)
+	assert.Equal(t, 500*time.Millisecond, result.Delay)
+	assert.Equal(t, 0.0, result.CurrentTokens)
+}
+
+func TestMemoryStorage_SetBlock_ExpiresAfterBlockTime(t *testing.T) {
+	// Arrange
+	storage, now := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	// Act
+	require.NoError(t, storage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{}))
+
+	// Assert
+	blocked, err := storage.IsBlocked(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	*now = now.Add(time.Minute)
+	blocked, err = storage.IsBlocked(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, blocked)
+}
+
+func TestMemoryStorage_GetKeyState_ReportsBucketAndBlock(t *testing.T) {
+	// Arrange
+	storage, now := newTestStorage()
+	key := entity.NewTokenKey("abc123")
+	ctx := context.Background()
+
+	// Act - chave sem uso
+	state, err := storage.GetKeyState(ctx, key)
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, state.Exists)
+	assert.False(t, state.Blocked)
+
+	// Act - consome um token e bloqueia
+	_, err = storage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+	require.NoError(t, err)
+	info := entity.BlockInfo{Reason: entity.BlockReasonRateExceeded, Source: entity.BlockSourceRateLimiter, Limit: 10, Window: time.Second}
+	require.NoError(t, storage.SetBlock(ctx, key, time.Minute, info))
+	*now = now.Add(10 * time.Second)
+	state, err = storage.GetKeyState(ctx, key)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, state.Exists)
+	assert.Equal(t, 9.0, state.Tokens)
+	assert.Equal(t, now.Add(-10*time.Second), state.LastRefill)
+	assert.True(t, state.Blocked)
+	assert.Equal(t, 50*time.Second, state.BlockTTL)
+	assert.Equal(t, info, state.BlockInfo)
+}
+
+func TestMemoryStorage_ResetBucketAndUnblock(t *testing.T) {
+	// Arrange
+	storage, _ := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	_, err := storage.CheckAndConsume(ctx, key, 1, time.Minute, 1)
+	require.NoError(t, err)
+	require.NoError(t, storage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{}))
+
+	// Act
+	require.NoError(t, storage.ResetBucket(ctx, key))
+	require.NoError(t, storage.Unblock(ctx, key))
+
+	// Assert - bucket volta cheio e a chave não está bloqueada
+	state, err := storage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, state.Exists)
+	assert.False(t, state.Blocked)
+
+	result, err := storage.CheckAndConsume(ctx, key, 1, time.Minute, 1)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStorage_ListBlocked_ReturnsOnlyActiveBlocks(t *testing.T) {
+	// Arrange
+	storage, now := newTestStorage()
+	ctx := context.Background()
+
+	info := entity.BlockInfo{Reason: entity.BlockReasonDenylist, Source: "admin:alice", BlockedAt: *now}
+	require.NoError(t, storage.SetBlock(ctx, entity.NewIPKey("10.0.0.1"), time.Minute, entity.BlockInfo{}))
+	require.NoError(t, storage.SetBlock(ctx, entity.NewTokenKey("abc123"), 10*time.Minute, info))
+
+	// Act
+	*now = now.Add(2 * time.Minute)
+	blocked, err := storage.ListBlocked(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, blocked, 1)
+	assert.Equal(t, entity.NewTokenKey("abc123"), blocked[0].Key)
+	assert.Equal(t, 8*time.Minute, blocked[0].TTL)
+	assert.Equal(t, info, blocked[0].Info)
+}
+
+func TestMemoryStorage_CountDistinct_CountsWithinFixedWindow(t *testing.T) {
+	// Arrange
+	storage, now := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1").Scoped("invalid-tokens")
+	ctx := context.Background()
+
+	// Act & Assert - membros repetidos contam uma vez
+	for _, member := range []string{"a", "b", "a", "c"} {
+		_, err := storage.CountDistinct(ctx, key, member, time.Minute)
+		require.NoError(t, err)
+		*now = now.Add(10 * time.Second)
+	}
+	count, err := storage.CountDistinct(ctx, key, "b", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// A janela conta a partir do primeiro membro e não é renovada pelos seguintes
+	*now = now.Add(20 * time.Second)
+	count, err = storage.CountDistinct(ctx, key, "d", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestMemoryStorage_AddStrike_CountsUntilDecay(t *testing.T) {
+	// Arrange
+	storage, now := newTestStorage()
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	// Act & Assert - cada violação renova o decay
+	for expected := 1; expected <= 3; expected++ {
+		strikes, err := storage.AddStrike(ctx, key, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, expected, strikes)
+		*now = now.Add(50 * time.Minute)
+	}
+
+	state, err := storage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 3, state.Strikes)
+
+	*now = now.Add(time.Hour)
+	strikes, err := storage.AddStrike(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strikes, "strikes esquecidos após o decay")
+
+	require.NoError(t, storage.ResetStrikes(ctx, key))
+	state, err = storage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+	assert.Zero(t, state.Strikes)
+}
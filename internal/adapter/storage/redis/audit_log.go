@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// RedisAuditLog implementa repository.AuditLog usando um Redis Stream (XADD)
+// O stream é compartilhado por todas as instâncias; maxLen limita a retenção (aproximada, MAXLEN ~)
+type RedisAuditLog struct {
+	client redis.UniversalClient
+	stream string
+	maxLen int64
+}
+
+// NewRedisAuditLog cria o audit log sobre o stream informado
+// maxLen <= 0 mantém todos os eventos
+func NewRedisAuditLog(client redis.UniversalClient, stream string, maxLen int64) *RedisAuditLog {
+	return &RedisAuditLog{
+		client: client,
+		stream: stream,
+		maxLen: maxLen,
+	}
+}
+
+// Append implementa o método da interface AuditLog
+// Cada evento vira uma entrada do stream com campos planos (legíveis via XRANGE/redis-cli)
+func (a *RedisAuditLog) Append(ctx context.Context, event entity.AuditEvent) error {
+	info, err := encodeBlockInfo(event.Info)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+
+	args := &redis.XAddArgs{
+		Stream: a.stream,
+		Values: []interface{}{
+			"action", string(event.Action),
+			"key", event.Key.String(),
+			"time", event.Time.UnixMilli(),
+			"duration_ms", event.Duration.Milliseconds(),
+			"actor", event.Actor,
+			"info", info,
+		},
+	}
+	if a.maxLen > 0 {
+		args.MaxLen = a.maxLen
+		args.Approx = true
+	}
+
+	if err := a.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to append audit event: %w", err)
+	}
+	return nil
+}
+
+// Recent implementa o método da interface AuditLog (XREVRANGE: mais recentes primeiro)
+func (a *RedisAuditLog) Recent(ctx context.Context, limit int) ([]entity.AuditEvent, error) {
+	messages, err := a.client.XRevRangeN(ctx, a.stream, "+", "-", int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit events: %w", err)
+	}
+
+	events := make([]entity.AuditEvent, 0, len(messages))
+	for _, message := range messages {
+		event, err := decodeAuditEvent(message.Values)
+		if err != nil {
+			continue // Ignora entradas fora do formato
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// decodeAuditEvent converte os campos de uma entrada do stream para o evento
+func decodeAuditEvent(values map[string]interface{}) (entity.AuditEvent, error) {
+	field := func(name string) string {
+		value, _ := values[name].(string)
+		return value
+	}
+
+	key, err := entity.ParseLimiterKey(field("key"))
+	if err != nil {
+		return entity.AuditEvent{}, err
+	}
+	millis, _ := strconv.ParseInt(field("time"), 10, 64)
+	duration, _ := strconv.ParseInt(field("duration_ms"), 10, 64)
+
+	return entity.AuditEvent{
+		Action:   entity.AuditAction(field("action")),
+		Key:      key,
+		Time:     time.UnixMilli(millis),
+		Duration: time.Duration(duration) * time.Millisecond,
+		Info:     decodeBlockInfo(field("info")),
+		Actor:    field("actor"),
+	}, nil
+}
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// storedBlockInfo é o formato JSON guardado como valor da chave de bloqueio
+// Durações e datas são gravadas em milissegundos para manter o valor compacto
+type storedBlockInfo struct {
+	Reason    string `json:"reason,omitempty"`
+	Source    string `json:"source,omitempty"`
+	BlockedAt int64  `json:"blocked_at,omitempty"` // Unix em milissegundos
+	Policy    string `json:"policy,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	WindowMs  int64  `json:"window_ms,omitempty"`
+	Strikes   int    `json:"strikes,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// encodeBlockInfo serializa o motivo do bloqueio para o valor da chave
+func encodeBlockInfo(info entity.BlockInfo) (string, error) {
+	stored := storedBlockInfo{
+		Reason:   string(info.Reason),
+		Source:   info.Source,
+		Policy:   info.Policy,
+		Limit:    info.Limit,
+		WindowMs: info.Window.Milliseconds(),
+		Strikes:  info.Strikes,
+		Note:     info.Note,
+	}
+	if !info.BlockedAt.IsZero() {
+		stored.BlockedAt = info.BlockedAt.UnixMilli()
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decodeBlockInfo lê o motivo do bloqueio do valor da chave
+// Bloqueios antigos (valor "1") ou ilegíveis resultam em BlockInfo vazio
+func decodeBlockInfo(value string) entity.BlockInfo {
+	var stored storedBlockInfo
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return entity.BlockInfo{}
+	}
+
+	info := entity.BlockInfo{
+		Reason:  entity.BlockReason(stored.Reason),
+		Source:  stored.Source,
+		Policy:  stored.Policy,
+		Limit:   stored.Limit,
+		Window:  time.Duration(stored.WindowMs) * time.Millisecond,
+		Strikes: stored.Strikes,
+		Note:    stored.Note,
+	}
+	if stored.BlockedAt > 0 {
+		info.BlockedAt = time.UnixMilli(stored.BlockedAt)
+	}
+	return info
+}
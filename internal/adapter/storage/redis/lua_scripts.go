@@ -9,13 +9,15 @@ import "github.com/redis/go-redis/v9"
 // quando múltiplas requisições simultâneas tentam consumir tokens.
 //
 // Estrutura das KEYS:
-// - KEYS[1]: tokens_key - armazena o número atual de tokens (ex: "rate_limit:ip:192.168.1.1:tokens")
-// - KEYS[2]: last_refill_key - armazena o timestamp do último refill (ex: "rate_limit:ip:192.168.1.1:last_refill")
+// - KEYS[1]: tokens_key - armazena o número atual de tokens (ex: "{rate_limit:ip:192.168.1.1}:tokens")
+// - KEYS[2]: last_refill_key - armazena o timestamp do último refill (ex: "{rate_limit:ip:192.168.1.1}:last_refill")
+// As duas chaves compartilham a hash tag {rate_limit:...} para ficarem no mesmo slot do Redis Cluster.
 //
 // Estrutura dos ARGV:
 // - ARGV[1]: capacity - capacidade máxima do bucket (ex: 10 tokens)
 // - ARGV[2]: window_seconds - duração da janela em segundos (ex: 1 segundo)
-// - ARGV[3]: now - timestamp atual em segundos (ex: 1729252800)
+// - ARGV[3]: now - timestamp atual em segundos, com precisão de milissegundos (ex: 1729252800.123)
+// - ARGV[4]: cost - tokens consumidos pela requisição (1 numa requisição comum)
 //
 // Retorno: [allowed, current_tokens, capacity]
 // - allowed: 1 se permitido, 0 se bloqueado
@@ -31,7 +33,7 @@ var tokenBucketScript = redis.NewScript(`
 -- Algoritmo Token Bucket:
 -- 1. O bucket tem uma capacidade máxima (ex: 10 tokens)
 -- 2. Tokens são adicionados continuamente a uma taxa fixa (ex: 10 tokens/segundo)
--- 3. Cada requisição consome 1 token
+-- 3. Cada requisição consome cost tokens (1 numa requisição comum)
 -- 4. Se não há tokens disponíveis, a requisição é bloqueada
 -- ============================================================================
 
@@ -40,13 +42,14 @@ var tokenBucketScript = redis.NewScript(`
 -- ============================================================================
 
 -- Chaves Redis onde serão armazenados os dados do rate limiter
-local tokens_key = KEYS[1]       -- Chave para armazenar tokens atuais (ex: "rate_limit:ip:192.168.1.1:tokens")
-local last_refill_key = KEYS[2]  -- Chave para armazenar timestamp do último refill (ex: "rate_limit:ip:192.168.1.1:last_refill")
+local tokens_key = KEYS[1]       -- Chave para armazenar tokens atuais (ex: "{rate_limit:ip:192.168.1.1}:tokens")
+local last_refill_key = KEYS[2]  -- Chave para armazenar timestamp do último refill (ex: "{rate_limit:ip:192.168.1.1}:last_refill")
 
 -- Parâmetros de configuração do rate limiter
 local capacity = tonumber(ARGV[1])      -- Capacidade máxima do bucket (ex: 10 tokens)
 local window_seconds = tonumber(ARGV[2]) -- Janela de tempo em segundos (ex: 1 segundo)
-local now = tonumber(ARGV[3])           -- Timestamp atual em segundos (ex: 1729252800)
+local now = tonumber(ARGV[3])           -- Timestamp atual em segundos (ex: 1729252800.123)
+local cost = tonumber(ARGV[4]) or 1     -- Tokens consumidos pela requisição (ex: 1)
 
 -- ============================================================================
 -- RECUPERAÇÃO DO ESTADO ATUAL
@@ -66,7 +69,10 @@ local last_refill = tonumber(redis.call('GET', last_refill_key)) or now
 
 -- PASSO 1: Calcula o tempo decorrido desde o último refill em segundos
 -- Esta é a base para calcular quantos tokens devem ser adicionados
-local elapsed = now - last_refill
+-- Relógios de instâncias diferentes podem divergir: o timestamp salvo nunca volta no tempo
+-- e um relógio atrasado não retira tokens do bucket
+local elapsed = math.max(0, now - last_refill)
+now = math.max(now, last_refill)
 
 -- PASSO 2: Calcula a taxa de refill (tokens adicionados por segundo)
 -- Exemplo: se capacity=10 e window_seconds=1, então refill_rate=10 tokens/segundo
@@ -84,14 +90,14 @@ tokens = math.min(capacity, tokens + tokens_to_add)
 -- DECISÃO DE PERMISSÃO E CONSUMO DE TOKEN
 -- ============================================================================
 
--- PASSO 5: Tenta consumir 1 token para esta requisição
-if tokens >= 1 then
+-- PASSO 5: Tenta consumir cost tokens para esta requisição
+if tokens >= cost then
     -- ========================================================================
     -- ✅ REQUISIÇÃO PERMITIDA: há tokens suficientes
     -- ========================================================================
     
-    -- Consome 1 token do bucket
-    tokens = tokens - 1
+    -- Consome cost tokens do bucket
+    tokens = tokens - cost
     
     -- Salva o novo estado no Redis com TTL de 1 hora para evitar acúmulo de chaves órfãs
     -- TTL de 3600 segundos (1 hora) é suficiente para a maioria dos casos de uso
@@ -106,8 +112,9 @@ else
     -- ❌ REQUISIÇÃO BLOQUEADA: não há tokens disponíveis
     -- ========================================================================
     
-    -- Mesmo quando bloqueado, atualiza o timestamp para calcular corretamente
-    -- o próximo refill na próxima requisição
+    -- Mesmo quando bloqueado, salva os tokens já reabastecidos junto com o timestamp:
+    -- uma requisição com cost alto negada não pode descartar o refill acumulado
+    redis.call('SETEX', tokens_key, 3600, tostring(tokens))
     redis.call('SETEX', last_refill_key, 3600, tostring(now))
     
     -- Retorna resultado de bloqueio: [allowed=0, current_tokens, capacity]
@@ -115,3 +122,116 @@ else
     return {0, tokens, capacity}
 end
 `)
+
+// reserveScript reserva tokens do mesmo Token Bucket do tokenBucketScript, aceitando saldo negativo
+// Em vez de negar, a reserva deixa o bucket "devendo" e devolve quanto tempo o refill leva para
+// quitar a dívida; quem reservou espera esse tempo antes de usar os tokens.
+// Se a espera passar de max_wait_ms nada é consumido (apenas o refill é salvo).
+//
+// KEYS: as mesmas do tokenBucketScript (tokens_key, last_refill_key)
+//
+// Estrutura dos ARGV:
+// - ARGV[1]: capacity - capacidade máxima do bucket
+// - ARGV[2]: window_seconds - duração da janela em segundos
+// - ARGV[3]: now_ms - timestamp atual em milissegundos (o delay é calculado em milissegundos)
+// - ARGV[4]: cost - tokens reservados
+// - ARGV[5]: max_wait_ms - espera máxima aceita em milissegundos
+//
+// Retorno: [ok, current_tokens, delay_ms]
+// - ok: 1 se reservado, 0 se a espera excederia max_wait_ms
+// - current_tokens: tokens no bucket após a reserva, como string (pode ser negativo/fracionário)
+// - delay_ms: milissegundos até os tokens reservados estarem disponíveis
+var reserveScript = redis.NewScript(`
+local tokens_key = KEYS[1]
+local last_refill_key = KEYS[2]
+
+local capacity = tonumber(ARGV[1])
+local window_seconds = tonumber(ARGV[2])
+local now = tonumber(ARGV[3]) / 1000 -- last_refill é compartilhado com o tokenBucketScript, em segundos
+local cost = tonumber(ARGV[4])
+local max_wait_ms = tonumber(ARGV[5])
+
+-- Refill idêntico ao tokenBucketScript (bucket novo começa cheio)
+local tokens = tonumber(redis.call('GET', tokens_key)) or capacity
+local last_refill = tonumber(redis.call('GET', last_refill_key)) or now
+local refill_rate = capacity / window_seconds
+tokens = math.min(capacity, tokens + math.max(0, now - last_refill) * refill_rate)
+now = math.max(now, last_refill)
+
+-- Tempo até o refill cobrir o déficit
+local delay_ms = 0
+local deficit = cost - tokens
+if deficit > 0 then
+    delay_ms = math.ceil(deficit / refill_rate * 1000)
+end
+
+local ok = 0
+if delay_ms <= max_wait_ms then
+    tokens = tokens - cost
+    ok = 1
+end
+
+redis.call('SETEX', tokens_key, 3600, tostring(tokens))
+redis.call('SETEX', last_refill_key, 3600, tostring(now))
+
+return {ok, tostring(tokens), delay_ms}
+`)
+
+// refundScript devolve tokens de uma reserva não usada (ver reserveScript)
+// Aplica o mesmo refill antes de devolver e nunca passa da capacidade.
+// Sem bucket salvo (expirado ou resetado) nada é feito: ele já começaria cheio.
+//
+// KEYS: as mesmas do tokenBucketScript (tokens_key, last_refill_key)
+//
+// Estrutura dos ARGV:
+// - ARGV[1]: capacity - capacidade máxima do bucket
+// - ARGV[2]: window_seconds - duração da janela em segundos
+// - ARGV[3]: now_ms - timestamp atual em milissegundos
+// - ARGV[4]: cost - tokens devolvidos
+//
+// Retorno: current_tokens como string, ou false quando não há bucket
+var refundScript = redis.NewScript(`
+local tokens_key = KEYS[1]
+local last_refill_key = KEYS[2]
+
+local capacity = tonumber(ARGV[1])
+local window_seconds = tonumber(ARGV[2])
+local now = tonumber(ARGV[3]) / 1000
+local cost = tonumber(ARGV[4])
+
+local tokens = tonumber(redis.call('GET', tokens_key))
+if not tokens then
+    return false
+end
+local last_refill = tonumber(redis.call('GET', last_refill_key)) or now
+local refill_rate = capacity / window_seconds
+tokens = math.min(capacity, tokens + math.max(0, now - last_refill) * refill_rate + cost)
+now = math.max(now, last_refill)
+
+redis.call('SETEX', tokens_key, 3600, tostring(tokens))
+redis.call('SETEX', last_refill_key, 3600, tostring(now))
+
+return tostring(tokens)
+`)
+
+// countDistinctScript adiciona um membro ao HyperLogLog da chave e retorna a contagem de distintos
+// O TTL é definido apenas quando a chave é criada: a janela é fixa a partir do primeiro membro.
+// O HyperLogLog ocupa no máximo ~12KB por chave e não guarda os membros (ex: API keys).
+//
+// KEYS[1]: distinct_key (ex: "{rate_limit:ip:invalid-tokens|1.2.3.4}:distinct")
+//
+// Estrutura dos ARGV:
+// - ARGV[1]: member - membro a ser contado
+// - ARGV[2]: window_ms - duração da janela em milissegundos
+//
+// Retorno: contagem aproximada de membros distintos na janela
+var countDistinctScript = redis.NewScript(`
+local distinct_key = KEYS[1]
+
+redis.call('PFADD', distinct_key, ARGV[1])
+if redis.call('PTTL', distinct_key) < 0 then
+    redis.call('PEXPIRE', distinct_key, ARGV[2])
+end
+
+return redis.call('PFCOUNT', distinct_key)
+`)
@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// tokenKeyPattern é o padrão SCAN de todas as chaves de tokens ("{rate_limit:token:abc}:tokens", ":blocked"...)
+const tokenKeyPattern = "{rate_limit:token:*}:*"
+
+// MigrateTokenKeys renomeia as chaves criadas com o token em texto puro para o digest HMAC
+// (buckets e bloqueios ativos são preservados com o TTL restante)
+// Chaves já migradas são ignoradas, então a migração pode ser executada mais de uma vez.
+// Com dryRun apenas conta as chaves que seriam migradas.
+func (r *RedisStorage) MigrateTokenKeys(ctx context.Context, hasher entity.TokenHasher, dryRun bool) (int, error) {
+	if !hasher.Enabled() {
+		return 0, fmt.Errorf("token hashing is disabled: TOKEN_KEY_SECRET is required")
+	}
+
+	var legacyKeys []string
+	err := r.scan(ctx, tokenKeyPattern, func(_ redis.UniversalClient, redisKey string) error {
+		legacyKeys = append(legacyKeys, redisKey)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan token keys: %w", err)
+	}
+
+	migrated := 0
+	for _, redisKey := range legacyKeys {
+		newKey, ok := hashedRedisKey(hasher, redisKey)
+		if !ok {
+			continue
+		}
+		if dryRun {
+			migrated++
+			continue
+		}
+
+		moved, err := r.moveKey(ctx, redisKey, newKey)
+		if err != nil {
+			return migrated, err
+		}
+		if moved {
+			migrated++
+		}
+	}
+
+	return migrated, nil
+}
+
+// hashedRedisKey calcula o nome da chave com o digest; false se a chave não guarda um token em texto puro
+func hashedRedisKey(hasher entity.TokenHasher, redisKey string) (string, bool) {
+	inner, suffix, found := strings.Cut(strings.TrimPrefix(redisKey, "{"), "}:")
+	if !found {
+		return "", false
+	}
+
+	key, err := entity.ParseLimiterKey(inner)
+	if err != nil {
+		return "", false
+	}
+
+	hashed, ok := hasher.HashKey(key)
+	if !ok {
+		return "", false
+	}
+	return hashTag(hashed) + ":" + suffix, true
+}
+
+// moveKey copia o valor e o TTL para a nova chave e remove a antiga
+// As chaves ficam em slots diferentes no Cluster, por isso não é usado RENAME.
+// Se a nova chave já existir (tráfego com o segredo ativo), ela prevalece.
+func (r *RedisStorage) moveKey(ctx context.Context, oldKey, newKey string) (bool, error) {
+	value, err := r.client.Get(ctx, oldKey).Result()
+	if err == redis.Nil {
+		return false, nil // Expirou durante a migração
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read key %s: %w", oldKey, err)
+	}
+
+	ttl, err := r.client.PTTL(ctx, oldKey).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to get ttl for key %s: %w", oldKey, err)
+	}
+	if ttl == -2 {
+		return false, nil // Expirou entre o GET e o PTTL
+	}
+	if ttl < 0 {
+		ttl = 0 // Sem expiração
+	}
+
+	if err := r.client.SetNX(ctx, newKey, value, ttl).Err(); err != nil {
+		return false, fmt.Errorf("failed to write key %s: %w", newKey, err)
+	}
+	if err := r.client.Del(ctx, oldKey).Err(); err != nil {
+		return false, fmt.Errorf("failed to delete key %s: %w", oldKey, err)
+	}
+
+	return true, nil
+}
@@ -3,22 +3,27 @@ package redis
 import (
 	"context"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/tracing"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
 )
 
 // RedisStorage implementa a interface repository.Storage usando Redis como backend
+// Aceita qualquer redis.UniversalClient: nó único, Sentinel (failover) ou Cluster
 type RedisStorage struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
 // NewRedisStorage cria uma nova instância de RedisStorage usando dependency injection
-func NewRedisStorage(client *redis.Client) *RedisStorage {
+func NewRedisStorage(client redis.UniversalClient) *RedisStorage {
 	return &RedisStorage{
 		client: client,
 	}
@@ -29,6 +34,11 @@ func (r *RedisStorage) Close() error {
 	return r.client.Close()
 }
 
+// Ping verifica se o Redis está respondendo (usado em health checks)
+func (r *RedisStorage) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // CheckAndConsume implementa o método da interface Storage
 // Executa o algoritmo Token Bucket usando script Lua para operação atômica
 func (r *RedisStorage) CheckAndConsume(
@@ -36,31 +46,39 @@ func (r *RedisStorage) CheckAndConsume(
 	key entity.LimiterKey,
 	limit int,
 	window time.Duration,
-) (*repository.CheckResult, error) {
+	cost int,
+) (result *repository.CheckResult, err error) {
+	ctx, span := startSpan(ctx, "redis.token_bucket", "EVALSHA", key)
+	defer func() { endSpan(span, err) }()
+
 	if limit <= 0 {
 		return nil, fmt.Errorf("limit must be positive, got: %d", limit)
 	}
 	if window <= 0 {
 		return nil, fmt.Errorf("window must be positive, got: %v", window)
 	}
+	if cost <= 0 {
+		return nil, fmt.Errorf("cost must be positive, got: %d", cost)
+	}
 
-	now := time.Now().Unix()
+	now := float64(time.Now().UnixMilli()) / 1000
 	keyStr := key.String()
 
 	// Chaves para tokens e timestamp
-	tokensKey, lastRefillKey := r.generateTokenKeys(keyStr)
+	tokensKey, lastRefillKey := r.generateTokenKeys(key)
 
 	// Executa Lua script atomicamente
-	result, err := r.executeTokenBucketScript(ctx, tokensKey, lastRefillKey, limit, window, now)
+	scriptResult, err := r.executeTokenBucketScript(ctx, tokensKey, lastRefillKey, limit, window, now, cost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute token bucket script for key %s: %w", keyStr, err)
 	}
 
 	// Parseia resultado do Lua: {allowed, tokens, capacity}
-	allowed, tokens, err := r.parseScriptResult(result)
+	allowed, tokens, err := r.parseScriptResult(scriptResult)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse script result for key %s: %w", keyStr, err)
 	}
+	span.SetAttributes(tracing.AttrTokensRemaining.Float64(tokens), tracing.AttrLimit.Int(limit))
 
 	return &repository.CheckResult{
 		Allowed:       allowed,
@@ -69,9 +87,113 @@ func (r *RedisStorage) CheckAndConsume(
 	}, nil
 }
 
+// Reserve implementa o método da interface Storage
+// Reserva tokens com saldo negativo usando script Lua atômico (ver reserveScript)
+func (r *RedisStorage) Reserve(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+	maxWait time.Duration,
+) (result *repository.ReserveResult, err error) {
+	ctx, span := startSpan(ctx, "redis.reserve", "EVALSHA", key)
+	defer func() { endSpan(span, err) }()
+
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got: %d", limit)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive, got: %v", window)
+	}
+	if cost <= 0 {
+		return nil, fmt.Errorf("cost must be positive, got: %d", cost)
+	}
+
+	tokensKey, lastRefillKey := r.generateTokenKeys(key)
+
+	scriptResult, err := reserveScript.Run(
+		ctx,
+		r.client,
+		[]string{tokensKey, lastRefillKey},                                            // KEYS
+		limit, window.Seconds(), time.Now().UnixMilli(), cost, maxWait.Milliseconds(), // ARGV
+	).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute reserve script for key %s: %w", key.String(), err)
+	}
+
+	// Parseia resultado do Lua: {ok, tokens, delay_ms}
+	values, ok := scriptResult.([]interface{})
+	if !ok || len(values) != 3 {
+		return nil, fmt.Errorf("unexpected reserve script result for key %s: %v", key.String(), scriptResult)
+	}
+	reserved, okFlag := values[0].(int64)
+	delayMs, okDelay := values[2].(int64)
+	if !okFlag || !okDelay {
+		return nil, fmt.Errorf("unexpected reserve script result for key %s: %v", key.String(), scriptResult)
+	}
+	tokens, err := r.parseTokensValue(values[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reserve result for key %s: %w", key.String(), err)
+	}
+	span.SetAttributes(tracing.AttrTokensRemaining.Float64(tokens), tracing.AttrLimit.Int(limit))
+
+	return &repository.ReserveResult{
+		OK:            reserved == 1,
+		Delay:         time.Duration(delayMs) * time.Millisecond,
+		CurrentTokens: tokens,
+		Limit:         limit,
+	}, nil
+}
+
+// Refund implementa o método da interface Storage
+// Devolve tokens de uma reserva não usada usando script Lua atômico (ver refundScript)
+func (r *RedisStorage) Refund(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+) (err error) {
+	ctx, span := startSpan(ctx, "redis.refund", "EVALSHA", key)
+	defer func() { endSpan(span, err) }()
+
+	if limit <= 0 {
+		return fmt.Errorf("limit must be positive, got: %d", limit)
+	}
+	if window <= 0 {
+		return fmt.Errorf("window must be positive, got: %v", window)
+	}
+	if cost <= 0 {
+		return fmt.Errorf("cost must be positive, got: %d", cost)
+	}
+
+	tokensKey, lastRefillKey := r.generateTokenKeys(key)
+
+	err = refundScript.Run(
+		ctx,
+		r.client,
+		[]string{tokensKey, lastRefillKey},                    // KEYS
+		limit, window.Seconds(), time.Now().UnixMilli(), cost, // ARGV
+	).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to execute refund script for key %s: %w", key.String(), err)
+	}
+	return nil
+}
+
 // generateTokenKeys gera as chaves Redis necessárias para o algoritmo Token Bucket
-func (r *RedisStorage) generateTokenKeys(keyStr string) (tokensKey, lastRefillKey string) {
-	return keyStr + ":tokens", keyStr + ":last_refill"
+// Ambas compartilham a mesma hash tag para caírem no mesmo slot do Redis Cluster,
+// requisito para o script Lua acessar as duas chaves atomicamente
+func (r *RedisStorage) generateTokenKeys(key entity.LimiterKey) (tokensKey, lastRefillKey string) {
+	tag := hashTag(key)
+	return tag + ":tokens", tag + ":last_refill"
+}
+
+// hashTag envolve a chave em {} para que o Redis Cluster calcule o slot apenas sobre ela
+// Ex: "rate_limit:ip:192.168.1.1" → "{rate_limit:ip:192.168.1.1}"
+func hashTag(key entity.LimiterKey) string {
+	return "{" + key.String() + "}"
 }
 
 // executeTokenBucketScript executa o script Lua do Token Bucket
@@ -80,13 +202,14 @@ func (r *RedisStorage) executeTokenBucketScript(
 	tokensKey, lastRefillKey string,
 	limit int,
 	window time.Duration,
-	now int64,
+	now float64,
+	cost int,
 ) (interface{}, error) {
 	result, err := tokenBucketScript.Run(
 		ctx,
 		r.client,
 		[]string{tokensKey, lastRefillKey}, // KEYS
-		limit, window.Seconds(), now,       // ARGV
+		limit, window.Seconds(), now, cost, // ARGV
 	).Result()
 
 	if err != nil {
@@ -144,14 +267,23 @@ func (r *RedisStorage) parseTokensValue(value interface{}) (float64, error) {
 
 // SetBlock implementa o método da interface Storage
 // Bloqueia uma chave por um período específico usando TTL do Redis
-func (r *RedisStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration) error {
+// O valor da chave guarda o motivo do bloqueio em JSON (ver block_info.go)
+func (r *RedisStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration, info entity.BlockInfo) (err error) {
+	ctx, span := startSpan(ctx, "redis.set_block", "SET", key)
+	defer func() { endSpan(span, err) }()
+
 	if blockTime <= 0 {
 		return fmt.Errorf("block time must be positive, got: %v", blockTime)
 	}
 
 	blockKey := r.generateBlockKey(key)
 
-	err := r.client.Set(ctx, blockKey, "1", blockTime).Err()
+	value, err := encodeBlockInfo(info)
+	if err != nil {
+		return fmt.Errorf("failed to encode block info for key %s: %w", key.String(), err)
+	}
+
+	err = r.client.Set(ctx, blockKey, value, blockTime).Err()
 	if err != nil {
 		return fmt.Errorf("failed to set block for key %s: %w", key.String(), err)
 	}
@@ -161,7 +293,10 @@ func (r *RedisStorage) SetBlock(ctx context.Context, key entity.LimiterKey, bloc
 
 // IsBlocked implementa o método da interface Storage
 // Verifica se uma chave está bloqueada consultando o Redis
-func (r *RedisStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (bool, error) {
+func (r *RedisStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (blocked bool, err error) {
+	ctx, span := startSpan(ctx, "redis.is_blocked", "EXISTS", key)
+	defer func() { endSpan(span, err) }()
+
 	blockKey := r.generateBlockKey(key)
 
 	result, err := r.client.Exists(ctx, blockKey).Result()
@@ -173,6 +308,195 @@ func (r *RedisStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (bo
 }
 
 // generateBlockKey gera a chave Redis para bloqueio
+// Usa a mesma hash tag das chaves do bucket para manter todo o estado da chave no mesmo slot
 func (r *RedisStorage) generateBlockKey(key entity.LimiterKey) string {
-	return key.String() + ":blocked"
+	return hashTag(key) + ":blocked"
+}
+
+// generateStrikesKey gera a chave do contador de violações ("{rate_limit:ip:1.2.3.4}:strikes")
+func (r *RedisStorage) generateStrikesKey(key entity.LimiterKey) string {
+	return hashTag(key) + ":strikes"
+}
+
+// AddStrike implementa o método da interface Storage
+// INCR e PEXPIRE em uma transação: cada violação renova o prazo de decay do contador
+func (r *RedisStorage) AddStrike(ctx context.Context, key entity.LimiterKey, decay time.Duration) (int, error) {
+	strikesKey := r.generateStrikesKey(key)
+
+	pipe := r.client.TxPipeline()
+	incrCmd := pipe.Incr(ctx, strikesKey)
+	pipe.PExpire(ctx, strikesKey, decay)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to add strike for key %s: %w", key.String(), err)
+	}
+
+	return int(incrCmd.Val()), nil
+}
+
+// ResetStrikes implementa o método da interface Storage
+func (r *RedisStorage) ResetStrikes(ctx context.Context, key entity.LimiterKey) error {
+	if err := r.client.Del(ctx, r.generateStrikesKey(key)).Err(); err != nil {
+		return fmt.Errorf("failed to reset strikes for key %s: %w", key.String(), err)
+	}
+
+	return nil
+}
+
+// generateDistinctKey gera a chave do HyperLogLog de membros distintos ("{rate_limit:ip:1.2.3.4}:distinct")
+func (r *RedisStorage) generateDistinctKey(key entity.LimiterKey) string {
+	return hashTag(key) + ":distinct"
+}
+
+// CountDistinct implementa o método da interface Storage
+// PFADD, PEXPIRE na criação e PFCOUNT em um script Lua atômico (ver countDistinctScript)
+func (r *RedisStorage) CountDistinct(ctx context.Context, key entity.LimiterKey, member string, window time.Duration) (int, error) {
+	if window <= 0 {
+		return 0, fmt.Errorf("window must be positive, got: %v", window)
+	}
+
+	count, err := countDistinctScript.Run(
+		ctx,
+		r.client,
+		[]string{r.generateDistinctKey(key)}, // KEYS
+		member, window.Milliseconds(),        // ARGV
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count distinct members for key %s: %w", key.String(), err)
+	}
+
+	return count, nil
+}
+
+// GetKeyState implementa o método da interface Storage
+// Lê tokens, último refill, strikes e TTL do bloqueio em um único pipeline (todas as chaves no mesmo slot)
+func (r *RedisStorage) GetKeyState(ctx context.Context, key entity.LimiterKey) (*repository.KeyState, error) {
+	tokensKey, lastRefillKey := r.generateTokenKeys(key)
+	blockKey := r.generateBlockKey(key)
+
+	pipe := r.client.Pipeline()
+	tokensCmd := pipe.Get(ctx, tokensKey)
+	lastRefillCmd := pipe.Get(ctx, lastRefillKey)
+	blockTTLCmd := pipe.PTTL(ctx, blockKey)
+	blockInfoCmd := pipe.Get(ctx, blockKey)
+	strikesCmd := pipe.Get(ctx, r.generateStrikesKey(key))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get state for key %s: %w", key.String(), err)
+	}
+
+	state := &repository.KeyState{Key: key}
+
+	// Bucket: ausência da chave de tokens significa bucket cheio (ainda não usado ou expirado)
+	if tokens, err := tokensCmd.Float64(); err == nil {
+		state.Exists = true
+		state.Tokens = tokens
+	}
+	if lastRefill, err := lastRefillCmd.Float64(); err == nil {
+		state.LastRefill = time.UnixMilli(int64(math.Round(lastRefill * 1000)))
+	}
+
+	if strikes, err := strikesCmd.Int(); err == nil {
+		state.Strikes = strikes
+	}
+
+	// PTTL retorna valor negativo quando a chave não existe
+	if ttl := blockTTLCmd.Val(); ttl > 0 {
+		state.Blocked = true
+		state.BlockTTL = ttl
+		state.BlockInfo = decodeBlockInfo(blockInfoCmd.Val())
+	}
+
+	return state, nil
+}
+
+// ResetBucket implementa o método da interface Storage
+// Remove tokens e último refill; o próximo request encontra o bucket cheio
+func (r *RedisStorage) ResetBucket(ctx context.Context, key entity.LimiterKey) error {
+	tokensKey, lastRefillKey := r.generateTokenKeys(key)
+
+	if err := r.client.Del(ctx, tokensKey, lastRefillKey).Err(); err != nil {
+		return fmt.Errorf("failed to reset bucket for key %s: %w", key.String(), err)
+	}
+
+	return nil
+}
+
+// Unblock implementa o método da interface Storage
+func (r *RedisStorage) Unblock(ctx context.Context, key entity.LimiterKey) error {
+	if err := r.client.Del(ctx, r.generateBlockKey(key)).Err(); err != nil {
+		return fmt.Errorf("failed to unblock key %s: %w", key.String(), err)
+	}
+
+	return nil
+}
+
+// ListBlocked implementa o método da interface Storage
+// Usa SCAN (não bloqueia o Redis como KEYS); no Cluster percorre todos os masters
+func (r *RedisStorage) ListBlocked(ctx context.Context) ([]repository.BlockedKey, error) {
+	var (
+		mu      sync.Mutex
+		blocked []repository.BlockedKey
+	)
+
+	err := r.scan(ctx, blockKeyPattern, func(client redis.UniversalClient, redisKey string) error {
+		key, err := parseBlockKey(redisKey)
+		if err != nil {
+			return nil // Ignora chaves fora do padrão
+		}
+
+		pipe := client.Pipeline()
+		ttlCmd := pipe.PTTL(ctx, redisKey)
+		valueCmd := pipe.Get(ctx, redisKey)
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to get block for key %s: %w", key.String(), err)
+		}
+		ttl := ttlCmd.Val()
+		if ttl <= 0 {
+			return nil // Expirou durante o scan
+		}
+
+		mu.Lock()
+		blocked = append(blocked, repository.BlockedKey{Key: key, TTL: ttl, Info: decodeBlockInfo(valueCmd.Val())})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked keys: %w", err)
+	}
+
+	return blocked, nil
+}
+
+// blockKeyPattern é o padrão SCAN das chaves de bloqueio ("{rate_limit:ip:1.2.3.4}:blocked")
+const blockKeyPattern = "{rate_limit:*}:blocked"
+
+// parseBlockKey extrai a LimiterKey de uma chave de bloqueio
+func parseBlockKey(redisKey string) (entity.LimiterKey, error) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(redisKey, "{"), "}:blocked")
+	return entity.ParseLimiterKey(inner)
+}
+
+// scan percorre as chaves que casam com o padrão
+// No Redis Cluster cada master tem seu próprio keyspace, então o SCAN é feito em todos
+func (r *RedisStorage) scan(
+	ctx context.Context,
+	pattern string,
+	fn func(client redis.UniversalClient, redisKey string) error,
+) error {
+	scanNode := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			if err := fn(client, iter.Val()); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scanNode(ctx, client)
+		})
+	}
+
+	return scanNode(ctx, r.client)
 }
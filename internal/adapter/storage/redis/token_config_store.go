@@ -0,0 +1,249 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+const (
+	// tokenConfigsKey é o hash que guarda as configurações (campo = digest HMAC do token, ou o token
+	// em texto puro sem TOKEN_KEY_SECRET; valor = JSON)
+	tokenConfigsKey = "rate_limit:token_configs"
+	// tokenConfigsChannel é o canal pub/sub usado para invalidar o cache das instâncias
+	tokenConfigsChannel = "rate_limit:token_configs:changed"
+)
+
+// tokenConfigRecord é o formato JSON persistido no Redis
+type tokenConfigRecord struct {
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	BlockTime string `json:"block_time"`
+}
+
+// RedisTokenConfigStore implementa repository.TokenConfigStore usando um hash do Redis
+// e pub/sub para notificar as demais instâncias sobre alterações
+// Com um TokenHasher habilitado os tokens não são gravados em texto puro: List retorna os digests
+type RedisTokenConfigStore struct {
+	client redis.UniversalClient
+	hasher entity.TokenHasher
+}
+
+// NewRedisTokenConfigStore cria uma nova instância de RedisTokenConfigStore
+func NewRedisTokenConfigStore(client redis.UniversalClient) *RedisTokenConfigStore {
+	return &RedisTokenConfigStore{
+		client: client,
+	}
+}
+
+// WithTokenHasher grava o digest HMAC no lugar do token (TOKEN_KEY_SECRET)
+// Campos antigos em texto puro continuam sendo lidos até a migração (ver MigrateTokens)
+func (s *RedisTokenConfigStore) WithTokenHasher(hasher entity.TokenHasher) *RedisTokenConfigStore {
+	s.hasher = hasher
+	return s
+}
+
+// Close fecha a conexão com o Redis
+func (s *RedisTokenConfigStore) Close() error {
+	return s.client.Close()
+}
+
+// Get implementa o método da interface TokenConfigStore
+// O token pode ser informado em texto puro ou como digest ("hmac:..."): usado apenas pela Admin API,
+// nunca no caminho das requisições (ver tokenconfig.Cache.Lookup)
+func (s *RedisTokenConfigStore) Get(ctx context.Context, token string) (*entity.TokenConfig, error) {
+	field := s.hasher.StoredToken(token)
+	raw, err := s.client.HGet(ctx, tokenConfigsKey, field).Result()
+	if errors.Is(err, redis.Nil) && field != token {
+		// Campo gravado antes do TOKEN_KEY_SECRET
+		field = token
+		raw, err = s.client.HGet(ctx, tokenConfigsKey, field).Result()
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, repository.ErrTokenConfigNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token config: %w", err)
+	}
+
+	cfg, err := decodeTokenConfig(field, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// List implementa o método da interface TokenConfigStore
+func (s *RedisTokenConfigStore) List(ctx context.Context) ([]entity.TokenConfig, error) {
+	raw, err := s.client.HGetAll(ctx, tokenConfigsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list token configs: %w", err)
+	}
+
+	configs := make([]entity.TokenConfig, 0, len(raw))
+	for token, value := range raw {
+		cfg, err := decodeTokenConfig(token, value)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// Save implementa o método da interface TokenConfigStore
+func (s *RedisTokenConfigStore) Save(ctx context.Context, cfg entity.TokenConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(tokenConfigRecord{
+		Limit:     cfg.Limit,
+		Window:    cfg.Window.String(),
+		BlockTime: cfg.BlockTime.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode token config: %w", err)
+	}
+
+	// Grava pelo digest e remove o campo antigo em texto puro, se existir
+	field := s.hasher.StoredToken(cfg.Token)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, tokenConfigsKey, field, raw)
+		if field != cfg.Token {
+			pipe.HDel(ctx, tokenConfigsKey, cfg.Token)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save token config: %w", err)
+	}
+
+	return s.notify(ctx)
+}
+
+// Delete implementa o método da interface TokenConfigStore
+func (s *RedisTokenConfigStore) Delete(ctx context.Context, token string) error {
+	fields := []string{s.hasher.StoredToken(token)}
+	if fields[0] != token {
+		fields = append(fields, token) // Campo gravado antes do TOKEN_KEY_SECRET
+	}
+	deleted, err := s.client.HDel(ctx, tokenConfigsKey, fields...).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete token config: %w", err)
+	}
+	if deleted == 0 {
+		return repository.ErrTokenConfigNotFound
+	}
+
+	return s.notify(ctx)
+}
+
+// Watch implementa o método da interface TokenConfigStore
+// Mensagens perdidas durante reconexões são cobertas pela ressincronização periódica do cache
+func (s *RedisTokenConfigStore) Watch(ctx context.Context, onChange func()) error {
+	pubsub := s.client.Subscribe(ctx, tokenConfigsChannel)
+	defer pubsub.Close()
+
+	// Aguarda a confirmação da inscrição para não perder alterações logo após o Watch
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to token config changes: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-messages:
+			if !ok {
+				return errors.New("token config subscription closed")
+			}
+			onChange()
+		}
+	}
+}
+
+// MigrateTokens troca os campos gravados com o token em texto puro pelo digest HMAC
+// Campos já migrados são ignorados; se o digest já existir, ele prevalece.
+// Com dryRun apenas conta os campos que seriam migrados.
+func (s *RedisTokenConfigStore) MigrateTokens(ctx context.Context, dryRun bool) (int, error) {
+	if !s.hasher.Enabled() {
+		return 0, fmt.Errorf("token hashing is disabled: TOKEN_KEY_SECRET is required")
+	}
+
+	raw, err := s.client.HGetAll(ctx, tokenConfigsKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list token configs: %w", err)
+	}
+
+	migrated := 0
+	for token, value := range raw {
+		if entity.IsTokenDigest(token) {
+			continue
+		}
+		if !dryRun {
+			_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSetNX(ctx, tokenConfigsKey, s.hasher.Digest(token), value)
+				pipe.HDel(ctx, tokenConfigsKey, token)
+				return nil
+			})
+			if err != nil {
+				return migrated, fmt.Errorf("failed to migrate token config %s: %w", entity.RedactToken(token), err)
+			}
+		}
+		migrated++
+	}
+
+	if migrated > 0 && !dryRun {
+		return migrated, s.notify(ctx)
+	}
+	return migrated, nil
+}
+
+// notify publica a invalidação para todas as instâncias
+func (s *RedisTokenConfigStore) notify(ctx context.Context) error {
+	if err := s.client.Publish(ctx, tokenConfigsChannel, time.Now().Unix()).Err(); err != nil {
+		return fmt.Errorf("failed to publish token config change: %w", err)
+	}
+	return nil
+}
+
+// decodeTokenConfig converte o JSON persistido em entity.TokenConfig
+func decodeTokenConfig(token, raw string) (entity.TokenConfig, error) {
+	var record tokenConfigRecord
+	if err := json.Unmarshal([]byte(raw), &record); err != nil {
+		return entity.TokenConfig{}, fmt.Errorf("invalid token config for %s: %w", redactStoredToken(token), err)
+	}
+
+	window, err := time.ParseDuration(record.Window)
+	if err != nil {
+		return entity.TokenConfig{}, fmt.Errorf("invalid window for token %s: %w", redactStoredToken(token), err)
+	}
+	blockTime, err := time.ParseDuration(record.BlockTime)
+	if err != nil {
+		return entity.TokenConfig{}, fmt.Errorf("invalid block time for token %s: %w", redactStoredToken(token), err)
+	}
+
+	return entity.TokenConfig{
+		Token:     token,
+		Limit:     record.Limit,
+		Window:    window,
+		BlockTime: blockTime,
+	}, nil
+}
+
+// redactStoredToken identifica o campo nas mensagens de erro sem expor tokens em texto puro
+func redactStoredToken(token string) string {
+	if entity.IsTokenDigest(token) {
+		return token
+	}
+	return entity.RedactToken(token)
+}
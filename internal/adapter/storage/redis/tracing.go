@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/tracing"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// startSpan cria o span de uma chamada ao Redis (script Lua ou comando)
+// O valor da chave não é registrado, apenas o tipo
+func startSpan(ctx context.Context, name, operation string, key entity.LimiterKey) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemRedis,
+			semconv.DBOperationName(operation),
+			tracing.AttrKeyType.String(string(key.Type)),
+		),
+	)
+}
+
+// endSpan registra o erro (se houver) e finaliza o span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,478 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dgryski/go-rendezvous"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// ErrNoHealthyShards é retornado quando todos os nós estão marcados como indisponíveis
+var ErrNoHealthyShards = errors.New("no healthy shards available")
+
+// Shard representa um nó Redis independente que participa do anel
+type Shard struct {
+	Name    string             // Identificador estável do nó (ex: "redis-1:6379"), usado no hash
+	Storage repository.Storage // Storage do nó (ex: RedisStorage com client standalone)
+}
+
+// pinger é implementado por storages que suportam health check ativo (ex: RedisStorage)
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// shardState guarda o estado de saúde de cada nó
+// Os campos são atômicos para que o resultado de cada operação seja registrado sem lock;
+// healthy só muda com o lock do storage, junto com a reconstrução do anel
+type shardState struct {
+	Shard
+	healthy  atomic.Bool
+	failures atomic.Int64 // Falhas consecutivas
+}
+
+// ring é uma versão imutável do anel, trocada atomicamente a cada mudança de nós saudáveis
+type ring struct {
+	table  *rendezvous.Rendezvous
+	shards map[string]*shardState // Apenas os nós saudáveis
+}
+
+// ShardedStorage implementa repository.Storage distribuindo as chaves entre vários
+// Redis independentes usando rendezvous hashing (Highest Random Weight).
+//
+// Com rendezvous hashing cada chave escolhe o nó de maior peso hash(chave, nó).
+// Ao adicionar ou remover um nó, apenas as chaves que passam a ter (ou tinham)
+// aquele nó como maior peso são remapeadas (~1/N das chaves).
+//
+// Nós com falhas consecutivas são retirados do anel até voltarem a responder ao
+// health check, e suas chaves são redistribuídas entre os nós saudáveis.
+// Retirar um nó remapeia suas chaves: enquanto ele estiver fora, os clientes dessas
+// chaves recomeçam com o bucket cheio em outro nó e os bloqueios gravados nele não são
+// aplicados. Ao voltar, as chaves retornam ao estado que ficou no nó (ou expiram).
+// Operações interrompidas pelo contexto do chamador (cancelamento ou deadline) não
+// contam como falha do nó.
+//
+// O caminho de cada operação não usa lock: o anel é lido atomicamente e o resultado é
+// registrado em contadores atômicos; o lock só é usado quando o anel muda.
+type ShardedStorage struct {
+	mu               sync.Mutex // Serializa mudanças no anel
+	shards           map[string]*shardState
+	ring             atomic.Pointer[ring]
+	failureThreshold int
+}
+
+// NewShardedStorage cria o storage distribuído
+// failureThreshold é o número de falhas consecutivas para marcar um nó como indisponível
+func NewShardedStorage(shards []Shard, failureThreshold int) (*ShardedStorage, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("at least one shard is required")
+	}
+	if failureThreshold <= 0 {
+		return nil, fmt.Errorf("failure threshold must be positive, got: %d", failureThreshold)
+	}
+
+	s := &ShardedStorage{
+		shards:           make(map[string]*shardState, len(shards)),
+		failureThreshold: failureThreshold,
+	}
+	for _, shard := range shards {
+		if err := s.addShard(shard); err != nil {
+			return nil, err
+		}
+	}
+	s.rebuildRing()
+
+	return s, nil
+}
+
+// AddShard adiciona um nó ao anel; apenas ~1/N das chaves são remapeadas para ele
+func (s *ShardedStorage) AddShard(shard Shard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.addShard(shard); err != nil {
+		return err
+	}
+	s.rebuildRing()
+	return nil
+}
+
+// RemoveShard remove um nó do anel e fecha seu storage
+// Apenas as chaves que pertenciam a ele são remapeadas
+func (s *ShardedStorage) RemoveShard(name string) error {
+	s.mu.Lock()
+	state, exists := s.shards[name]
+	if exists {
+		delete(s.shards, name)
+		s.rebuildRing()
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("shard %s not found", name)
+	}
+	return state.Storage.Close()
+}
+
+// addShard registra o nó como saudável (deve ser chamado com lock ou na construção)
+func (s *ShardedStorage) addShard(shard Shard) error {
+	if shard.Name == "" || shard.Storage == nil {
+		return errors.New("shard name and storage are required")
+	}
+	if _, exists := s.shards[shard.Name]; exists {
+		return fmt.Errorf("duplicate shard %s", shard.Name)
+	}
+	state := &shardState{Shard: shard}
+	state.healthy.Store(true)
+	s.shards[shard.Name] = state
+	return nil
+}
+
+// rebuildRing recria a tabela de rendezvous com os nós saudáveis (deve ser chamado com lock)
+// A ordem é determinística para que todas as instâncias escolham o mesmo nó
+func (s *ShardedStorage) rebuildRing() {
+	names := make([]string, 0, len(s.shards))
+	healthy := make(map[string]*shardState, len(s.shards))
+	for name, state := range s.shards {
+		if state.healthy.Load() {
+			names = append(names, name)
+			healthy[name] = state
+		}
+	}
+	sort.Strings(names)
+	s.ring.Store(&ring{table: rendezvous.New(names, xxhash.Sum64String), shards: healthy})
+}
+
+// shardFor retorna o nó responsável pela chave
+func (s *ShardedStorage) shardFor(key entity.LimiterKey) (*shardState, error) {
+	r := s.ring.Load()
+
+	name := r.table.Lookup(key.String())
+	if name == "" {
+		return nil, ErrNoHealthyShards
+	}
+	return r.shards[name], nil
+}
+
+// ShardFor retorna o nome do nó responsável pela chave (útil para debug e admin)
+func (s *ShardedStorage) ShardFor(key entity.LimiterKey) (string, error) {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return "", err
+	}
+	return state.Name, nil
+}
+
+// report atualiza o estado de saúde do nó com base no resultado de uma operação
+// Erros de contexto (cancelamento ou deadline do chamador) não dizem nada sobre o nó e são ignorados
+func (s *ShardedStorage) report(state *shardState, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	s.record(state, err)
+}
+
+// record registra o resultado no nó; o lock só é usado quando o nó entra ou sai do anel
+func (s *ShardedStorage) record(state *shardState, err error) {
+	if err == nil {
+		if state.failures.Load() != 0 {
+			state.failures.Store(0)
+		}
+		if !state.healthy.Load() {
+			s.setHealthy(state, true)
+		}
+		return
+	}
+
+	if state.failures.Add(1) >= int64(s.failureThreshold) && state.healthy.Load() {
+		s.setHealthy(state, false)
+	}
+}
+
+// setHealthy muda o estado do nó e reconstrói o anel
+// Nós removidos com RemoveShard enquanto a operação estava em andamento são ignorados
+func (s *ShardedStorage) setHealthy(state *shardState, healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shards[state.Name] != state || state.healthy.Load() == healthy {
+		return
+	}
+	state.healthy.Store(healthy)
+	s.rebuildRing()
+}
+
+// CheckAndConsume implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) CheckAndConsume(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+) (*repository.CheckResult, error) {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := state.Storage.CheckAndConsume(ctx, key, limit, window, cost)
+	s.report(state, err)
+	if err != nil {
+		return nil, fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return result, nil
+}
+
+// Reserve implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) Reserve(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+	maxWait time.Duration,
+) (*repository.ReserveResult, error) {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := state.Storage.Reserve(ctx, key, limit, window, cost, maxWait)
+	s.report(state, err)
+	if err != nil {
+		return nil, fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return result, nil
+}
+
+// Refund implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) Refund(
+	ctx context.Context,
+	key entity.LimiterKey,
+	limit int,
+	window time.Duration,
+	cost int,
+) error {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return err
+	}
+
+	err = state.Storage.Refund(ctx, key, limit, window, cost)
+	s.report(state, err)
+	if err != nil {
+		return fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return nil
+}
+
+// SetBlock implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration, info entity.BlockInfo) error {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return err
+	}
+
+	err = state.Storage.SetBlock(ctx, key, blockTime, info)
+	s.report(state, err)
+	if err != nil {
+		return fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return nil
+}
+
+// IsBlocked implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (bool, error) {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return false, err
+	}
+
+	blocked, err := state.Storage.IsBlocked(ctx, key)
+	s.report(state, err)
+	if err != nil {
+		return false, fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return blocked, nil
+}
+
+// GetKeyState implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) GetKeyState(ctx context.Context, key entity.LimiterKey) (*repository.KeyState, error) {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyState, err := state.Storage.GetKeyState(ctx, key)
+	s.report(state, err)
+	if err != nil {
+		return nil, fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return keyState, nil
+}
+
+// ResetBucket implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) ResetBucket(ctx context.Context, key entity.LimiterKey) error {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return err
+	}
+
+	err = state.Storage.ResetBucket(ctx, key)
+	s.report(state, err)
+	if err != nil {
+		return fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return nil
+}
+
+// Unblock implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) Unblock(ctx context.Context, key entity.LimiterKey) error {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return err
+	}
+
+	err = state.Storage.Unblock(ctx, key)
+	s.report(state, err)
+	if err != nil {
+		return fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return nil
+}
+
+// AddStrike implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) AddStrike(ctx context.Context, key entity.LimiterKey, decay time.Duration) (int, error) {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return 0, err
+	}
+
+	strikes, err := state.Storage.AddStrike(ctx, key, decay)
+	s.report(state, err)
+	if err != nil {
+		return 0, fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return strikes, nil
+}
+
+// ResetStrikes implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) ResetStrikes(ctx context.Context, key entity.LimiterKey) error {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return err
+	}
+
+	err = state.Storage.ResetStrikes(ctx, key)
+	s.report(state, err)
+	if err != nil {
+		return fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return nil
+}
+
+// CountDistinct implementa o método da interface Storage no nó responsável pela chave
+func (s *ShardedStorage) CountDistinct(ctx context.Context, key entity.LimiterKey, member string, window time.Duration) (int, error) {
+	state, err := s.shardFor(key)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := state.Storage.CountDistinct(ctx, key, member, window)
+	s.report(state, err)
+	if err != nil {
+		return 0, fmt.Errorf("shard %s: %w", state.Name, err)
+	}
+	return count, nil
+}
+
+// ListBlocked implementa o método da interface Storage consultando todos os nós saudáveis
+// Um bloqueio gravado em um nó que está fora do anel não é aplicado, então também não é listado
+func (s *ShardedStorage) ListBlocked(ctx context.Context) ([]repository.BlockedKey, error) {
+	var blocked []repository.BlockedKey
+	for _, state := range s.ring.Load().shards {
+		keys, err := state.Storage.ListBlocked(ctx)
+		s.report(state, err)
+		if err != nil {
+			return nil, fmt.Errorf("shard %s: %w", state.Name, err)
+		}
+		blocked = append(blocked, keys...)
+	}
+	return blocked, nil
+}
+
+// CheckHealth executa o health check ativo em todos os nós que suportam Ping
+// Nós indisponíveis que voltam a responder são reincluídos no anel
+// Aqui o timeout do Ping conta como falha: é o próprio health check que limita a espera
+func (s *ShardedStorage) CheckHealth(ctx context.Context) {
+	s.mu.Lock()
+	states := make([]*shardState, 0, len(s.shards))
+	for _, state := range s.shards {
+		states = append(states, state)
+	}
+	s.mu.Unlock()
+
+	for _, state := range states {
+		p, ok := state.Storage.(pinger)
+		if !ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.record(state, p.Ping(ctx))
+	}
+}
+
+// StartHealthCheck executa CheckHealth periodicamente até o contexto ser cancelado
+func (s *ShardedStorage) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkCtx, cancel := context.WithTimeout(ctx, interval)
+				s.CheckHealth(checkCtx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Health retorna o estado de saúde de cada nó (true = saudável)
+func (s *ShardedStorage) Health() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := make(map[string]bool, len(s.shards))
+	for name, state := range s.shards {
+		health[name] = state.healthy.Load()
+	}
+	return health
+}
+
+// Close fecha o storage de todos os nós
+func (s *ShardedStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for name, state := range s.shards {
+		if err := state.Storage.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("shard %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
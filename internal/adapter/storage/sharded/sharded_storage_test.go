@@ -0,0 +1,416 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// fakeStorage simula um nó Redis, registrando quantas chamadas recebeu
+type fakeStorage struct {
+	mu      sync.Mutex
+	calls   int
+	fail    bool
+	failErr error // Erro retornado quando fail é true (padrão: connection refused)
+	blocked map[string]bool
+	closed  bool
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{blocked: make(map[string]bool)}
+}
+
+func (f *fakeStorage) err() error {
+	if f.fail && f.failErr != nil {
+		return f.failErr
+	}
+	if f.fail {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (f *fakeStorage) CheckAndConsume(ctx context.Context, key entity.LimiterKey, limit int, window time.Duration, cost int) (*repository.CheckResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return &repository.CheckResult{Allowed: true, CurrentTokens: float64(limit - cost), Limit: limit}, nil
+}
+
+func (f *fakeStorage) Reserve(ctx context.Context, key entity.LimiterKey, limit int, window time.Duration, cost int, maxWait time.Duration) (*repository.ReserveResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return &repository.ReserveResult{OK: true, CurrentTokens: float64(limit - cost), Limit: limit}, nil
+}
+
+func (f *fakeStorage) Refund(ctx context.Context, key entity.LimiterKey, limit int, window time.Duration, cost int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.err()
+}
+
+func (f *fakeStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration, info entity.BlockInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := f.err(); err != nil {
+		return err
+	}
+	f.blocked[key.String()] = true
+	return nil
+}
+
+func (f *fakeStorage) IsBlocked(ctx context.Context, key entity.LimiterKey) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := f.err(); err != nil {
+		return false, err
+	}
+	return f.blocked[key.String()], nil
+}
+
+func (f *fakeStorage) GetKeyState(ctx context.Context, key entity.LimiterKey) (*repository.KeyState, error) {
+	blocked, err := f.IsBlocked(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &repository.KeyState{Key: key, Blocked: blocked}, nil
+}
+
+func (f *fakeStorage) ResetBucket(ctx context.Context, key entity.LimiterKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.err()
+}
+
+func (f *fakeStorage) Unblock(ctx context.Context, key entity.LimiterKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := f.err(); err != nil {
+		return err
+	}
+	delete(f.blocked, key.String())
+	return nil
+}
+
+func (f *fakeStorage) AddStrike(ctx context.Context, key entity.LimiterKey, decay time.Duration) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := f.err(); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func (f *fakeStorage) ResetStrikes(ctx context.Context, key entity.LimiterKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.err()
+}
+
+func (f *fakeStorage) CountDistinct(ctx context.Context, key entity.LimiterKey, member string, window time.Duration) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := f.err(); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func (f *fakeStorage) ListBlocked(ctx context.Context) ([]repository.BlockedKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	var keys []repository.BlockedKey
+	for k := range f.blocked {
+		key, _ := entity.ParseLimiterKey(k)
+		keys = append(keys, repository.BlockedKey{Key: key, TTL: time.Minute})
+	}
+	return keys, nil
+}
+
+func (f *fakeStorage) Ping(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err()
+}
+
+func (f *fakeStorage) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeStorage) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+// newTestStorage cria um ShardedStorage com n nós fake
+func newTestStorage(t *testing.T, n int) (*ShardedStorage, map[string]*fakeStorage) {
+	t.Helper()
+
+	fakes := make(map[string]*fakeStorage, n)
+	shards := make([]Shard, 0, n)
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("redis-%d:6379", i)
+		fakes[name] = newFakeStorage()
+		shards = append(shards, Shard{Name: name, Storage: fakes[name]})
+	}
+
+	storage, err := NewShardedStorage(shards, 2)
+	require.NoError(t, err)
+	return storage, fakes
+}
+
+// assignments retorna o nó escolhido para cada uma das chaves de teste
+func assignments(t *testing.T, storage *ShardedStorage, keys int) map[string]string {
+	t.Helper()
+
+	result := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := entity.NewIPKey(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+		shard, err := storage.ShardFor(key)
+		require.NoError(t, err)
+		result[key.String()] = shard
+	}
+	return result
+}
+
+func TestNewShardedStorage_InvalidShards_ReturnsError(t *testing.T) {
+	_, err := NewShardedStorage(nil, 3)
+	assert.Error(t, err)
+
+	_, err = NewShardedStorage([]Shard{
+		{Name: "redis-1", Storage: newFakeStorage()},
+		{Name: "redis-1", Storage: newFakeStorage()},
+	}, 3)
+	assert.Error(t, err)
+
+	_, err = NewShardedStorage([]Shard{{Name: "redis-1", Storage: newFakeStorage()}}, 0)
+	assert.Error(t, err)
+}
+
+func TestShardedStorage_DistributesKeysAcrossShards(t *testing.T) {
+	// Arrange
+	storage, fakes := newTestStorage(t, 3)
+	ctx := context.Background()
+
+	// Act
+	for i := 0; i < 300; i++ {
+		key := entity.NewIPKey(fmt.Sprintf("192.168.0.%d", i))
+		_, err := storage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+		require.NoError(t, err)
+	}
+
+	// Assert - todos os nós recebem uma parte das chaves
+	for name, fake := range fakes {
+		assert.Greater(t, fake.calls, 50, "shard %s received too few keys", name)
+	}
+}
+
+func TestShardedStorage_SameKeyAlwaysHitsSameShard(t *testing.T) {
+	// Arrange
+	storage, fakes := newTestStorage(t, 3)
+	ctx := context.Background()
+	key := entity.NewTokenKey("abc123")
+
+	// Act
+	require.NoError(t, storage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{}))
+	blocked, err := storage.IsBlocked(ctx, key)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	shard, err := storage.ShardFor(key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fakes[shard].calls)
+}
+
+func TestShardedStorage_AddShard_RemapsOnlyKeysToNewShard(t *testing.T) {
+	// Arrange
+	storage, _ := newTestStorage(t, 3)
+	before := assignments(t, storage, 1000)
+
+	// Act
+	require.NoError(t, storage.AddShard(Shard{Name: "redis-4:6379", Storage: newFakeStorage()}))
+	after := assignments(t, storage, 1000)
+
+	// Assert - chaves só se movem para o novo nó, e cerca de 1/4 delas
+	moved := 0
+	for key, shard := range after {
+		if shard != before[key] {
+			assert.Equal(t, "redis-4:6379", shard)
+			moved++
+		}
+	}
+	assert.InDelta(t, 250, moved, 80)
+}
+
+func TestShardedStorage_UnhealthyShard_IsRemovedAndRestored(t *testing.T) {
+	// Arrange
+	storage, fakes := newTestStorage(t, 3)
+	ctx := context.Background()
+	key := entity.NewIPKey("192.168.1.1")
+
+	original, err := storage.ShardFor(key)
+	require.NoError(t, err)
+	fakes[original].setFail(true)
+
+	// Act - falhas consecutivas atingem o threshold (2)
+	for i := 0; i < 2; i++ {
+		_, err := storage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+		assert.Error(t, err)
+	}
+
+	// Assert - a chave passa para outro nó saudável
+	assert.False(t, storage.Health()[original])
+	failover, err := storage.ShardFor(key)
+	require.NoError(t, err)
+	assert.NotEqual(t, original, failover)
+
+	_, err = storage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+	assert.NoError(t, err)
+
+	// Act - o nó volta a responder ao health check
+	fakes[original].setFail(false)
+	storage.CheckHealth(ctx)
+
+	// Assert - a chave volta ao nó original
+	assert.True(t, storage.Health()[original])
+	restored, err := storage.ShardFor(key)
+	require.NoError(t, err)
+	assert.Equal(t, original, restored)
+}
+
+func TestShardedStorage_ContextErrors_DoNotEjectShard(t *testing.T) {
+	// Arrange - o nó responde, mas o chamador desiste antes (cancelamento ou deadline)
+	storage, fakes := newTestStorage(t, 3)
+	key := entity.NewIPKey("192.168.1.1")
+	original, err := storage.ShardFor(key)
+	require.NoError(t, err)
+
+	fakes[original].setFail(true)
+	fakes[original].failErr = fmt.Errorf("redis: %w", context.DeadlineExceeded)
+
+	// Act
+	for i := 0; i < 5; i++ {
+		_, err := storage.CheckAndConsume(context.Background(), key, 10, time.Second, 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+	fakes[original].failErr = context.Canceled
+	_, err = storage.IsBlocked(context.Background(), key)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Assert
+	assert.True(t, storage.Health()[original])
+	current, err := storage.ShardFor(key)
+	require.NoError(t, err)
+	assert.Equal(t, original, current)
+}
+
+func TestShardedStorage_ConcurrentFailures_EjectShardOnce(t *testing.T) {
+	// Arrange
+	storage, fakes := newTestStorage(t, 3)
+	key := entity.NewIPKey("192.168.1.1")
+	original, err := storage.ShardFor(key)
+	require.NoError(t, err)
+	fakes[original].setFail(true)
+
+	// Act - várias goroutines falham ao mesmo tempo no mesmo nó
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			storage.CheckAndConsume(context.Background(), key, 10, time.Second, 1)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.False(t, storage.Health()[original])
+	failover, err := storage.ShardFor(key)
+	require.NoError(t, err)
+	assert.NotEqual(t, original, failover)
+}
+
+func TestShardedStorage_NoHealthyShards_ReturnsError(t *testing.T) {
+	// Arrange
+	storage, fakes := newTestStorage(t, 1)
+	for _, fake := range fakes {
+		fake.setFail(true)
+	}
+	storage.CheckHealth(context.Background())
+	storage.CheckHealth(context.Background())
+
+	// Act
+	_, err := storage.IsBlocked(context.Background(), entity.NewIPKey("192.168.1.1"))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrNoHealthyShards)
+}
+
+func TestShardedStorage_RemoveShard_ClosesStorage(t *testing.T) {
+	// Arrange
+	storage, fakes := newTestStorage(t, 2)
+
+	// Act
+	err := storage.RemoveShard("redis-1:6379")
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, fakes["redis-1:6379"].closed)
+	assert.NotContains(t, storage.Health(), "redis-1:6379")
+	assert.Error(t, storage.RemoveShard("redis-1:6379"))
+}
+
+func TestShardedStorage_ListBlocked_MergesAllShards(t *testing.T) {
+	// Arrange
+	storage, _ := newTestStorage(t, 3)
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, storage.SetBlock(ctx, entity.NewIPKey(fmt.Sprintf("10.0.0.%d", i)), time.Minute, entity.BlockInfo{}))
+	}
+
+	// Act
+	blocked, err := storage.ListBlocked(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, blocked, 20)
+
+	// Act - desbloqueia uma chave no nó correto
+	require.NoError(t, storage.Unblock(ctx, entity.NewIPKey("10.0.0.0")))
+	blocked, err = storage.ListBlocked(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, blocked, 19)
+}
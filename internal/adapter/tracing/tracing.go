@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// instrumentationName identifica os spans criados pelo rate limiter
+const instrumentationName = "github.com/EuricoCruz/rate_limiter_challeng"
+
+// Atributos dos spans do rate limiter
+const (
+	AttrKeyType         = attribute.Key("ratelimit.key_type")
+	AttrPolicy          = attribute.Key("ratelimit.policy")
+	AttrDecision        = attribute.Key("ratelimit.decision")
+	AttrTokensRemaining = attribute.Key("ratelimit.tokens_remaining")
+	AttrLimit           = attribute.Key("ratelimit.limit")
+	AttrBlockTime       = attribute.Key("ratelimit.block_time_seconds")
+	AttrStrikes         = attribute.Key("ratelimit.strikes")
+	AttrQueueDelay      = attribute.Key("ratelimit.queue_delay_seconds")
+)
+
+// Tracer retorna o tracer do rate limiter a partir do TracerProvider global
+// Sem provider configurado (OTEL_TRACES_EXPORTER=none) os spans são no-op
+func Tracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(instrumentationName)
+}
+
+// InputAttributes descreve a chave e a política usadas na verificação
+// O valor da chave (IP ou token) não é registrado
+func InputAttributes(input check_rate_limit.Input) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrKeyType.String(string(input.Key.Type)),
+		AttrPolicy.String(input.Policy),
+		AttrLimit.Int(input.Limit),
+	}
+}
+
+// OutputAttributes descreve a decisão tomada
+// Quando a requisição causou o bloqueio, inclui a duração e os strikes (bloqueio progressivo);
+// quando esperou na fila (QUEUE_MAX_DELAY), inclui a espera
+func OutputAttributes(output *check_rate_limit.Output) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		AttrDecision.String(output.Decision()),
+		AttrTokensRemaining.Float64(output.CurrentTokens),
+	}
+	if output.BlockTime > 0 {
+		attrs = append(attrs, AttrBlockTime.Float64(output.BlockTime.Seconds()))
+	}
+	if output.Strikes > 0 {
+		attrs = append(attrs, AttrStrikes.Int(output.Strikes))
+	}
+	if output.QueueDelay > 0 {
+		attrs = append(attrs, AttrQueueDelay.Float64(output.QueueDelay.Seconds()))
+	}
+	return attrs
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// UseCase é o caso de uso de verificação de rate limit
+type UseCase interface {
+	Execute(ctx context.Context, input check_rate_limit.Input) (*check_rate_limit.Output, error)
+}
+
+// TracedUseCase decora o caso de uso criando o span UseCase.Execute
+type TracedUseCase struct {
+	useCase UseCase
+}
+
+// NewTracedUseCase decora o caso de uso
+func NewTracedUseCase(useCase UseCase) *TracedUseCase {
+	return &TracedUseCase{useCase: useCase}
+}
+
+func (u *TracedUseCase) Execute(ctx context.Context, input check_rate_limit.Input) (*check_rate_limit.Output, error) {
+	ctx, span := Tracer().Start(ctx, "UseCase.Execute",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(InputAttributes(input)...),
+	)
+	defer span.End()
+
+	output, err := u.useCase.Execute(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return output, err
+	}
+
+	span.SetAttributes(OutputAttributes(output)...)
+	return output, nil
+}
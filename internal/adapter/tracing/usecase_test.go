@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/usecase/check_rate_limit"
+)
+
+// useCaseStub retorna uma resposta fixa
+type useCaseStub struct {
+	output *check_rate_limit.Output
+	err    error
+}
+
+func (u *useCaseStub) Execute(ctx context.Context, input check_rate_limit.Input) (*check_rate_limit.Output, error) {
+	return u.output, u.err
+}
+
+// recordSpans instala um TracerProvider em memória durante o teste
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// spanAttributes converte os atributos do span em mapa para facilitar as asserções
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracedUseCase_Execute_RecordsDecisionAttributes(t *testing.T) {
+	// Arrange
+	recorder := recordSpans(t)
+	useCase := NewTracedUseCase(&useCaseStub{output: &check_rate_limit.Output{Allowed: true, CurrentTokens: 9, Limit: 10}})
+	input := check_rate_limit.Input{
+		Key:    entity.NewTokenKey("abc123"),
+		Limit:  10,
+		Window: time.Second,
+		Policy: check_rate_limit.PolicyToken,
+	}
+
+	// Act
+	_, err := useCase.Execute(context.Background(), input)
+
+	// Assert
+	require.NoError(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "UseCase.Execute", spans[0].Name())
+
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "token", attrs[AttrKeyType].AsString())
+	assert.Equal(t, "token", attrs[AttrPolicy].AsString())
+	assert.Equal(t, "allowed", attrs[AttrDecision].AsString())
+	assert.Equal(t, 9.0, attrs[AttrTokensRemaining].AsFloat64())
+	for _, kv := range spans[0].Attributes() {
+		assert.NotEqual(t, "abc123", kv.Value.Emit(), "o valor do token não deve ir para o trace")
+	}
+}
+
+func TestTracedUseCase_Execute_RecordsError(t *testing.T) {
+	// Arrange
+	recorder := recordSpans(t)
+	useCase := NewTracedUseCase(&useCaseStub{err: errors.New("redis down")})
+
+	// Act
+	_, err := useCase.Execute(context.Background(), check_rate_limit.Input{Key: entity.NewIPKey("10.0.0.1")})
+
+	// Assert
+	require.Error(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "redis down", spans[0].Status().Description)
+}
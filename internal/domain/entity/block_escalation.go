@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// BlockEscalation describes progressive penalties for repeat offenders.
+// Each consecutive violation (strike) within Decay multiplies the block time by Factor, up to MaxBlockTime:
+// with a 1m base, Factor 5 and MaxBlockTime 24h the blocks are 1m, 5m, 25m, 2h5m, 10h25m, 24h...
+// The zero value disables escalation (every block uses the base block time).
+type BlockEscalation struct {
+	Factor       float64       // Multiplier applied per strike (> 1 enables escalation)
+	MaxBlockTime time.Duration // Upper bound of every block while escalation is enabled (0 means no bound)
+	Decay        time.Duration // Strikes are forgotten after this period without violations
+}
+
+// Enabled reports whether blocks escalate
+func (e BlockEscalation) Enabled() bool {
+	return e.Factor > 1 && e.Decay > 0
+}
+
+// BlockTime returns the block duration for the given strike (1 is the first violation)
+// Every strike is capped by MaxBlockTime, including the first one when the base is already above it
+func (e BlockEscalation) BlockTime(base time.Duration, strike int) time.Duration {
+	if !e.Enabled() || base <= 0 {
+		return base
+	}
+
+	// Without a bound the cap only avoids overflowing time.Duration with many strikes
+	bound := maxDuration
+	if e.MaxBlockTime > 0 {
+		bound = e.MaxBlockTime
+	}
+
+	blockTime := float64(base)
+	for i := 1; i < strike && blockTime < float64(bound); i++ {
+		blockTime *= e.Factor
+	}
+	if blockTime >= float64(bound) {
+		return bound
+	}
+	return time.Duration(blockTime)
+}
+
+// maxDuration is the largest representable time.Duration
+const maxDuration = time.Duration(1<<63 - 1)
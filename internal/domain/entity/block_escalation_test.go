@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockEscalation_BlockTime_MultipliesPerStrikeUpToMax(t *testing.T) {
+	escalation := BlockEscalation{Factor: 5, MaxBlockTime: 24 * time.Hour, Decay: 48 * time.Hour}
+
+	assert.Equal(t, time.Minute, escalation.BlockTime(time.Minute, 1))
+	assert.Equal(t, 5*time.Minute, escalation.BlockTime(time.Minute, 2))
+	assert.Equal(t, 25*time.Minute, escalation.BlockTime(time.Minute, 3))
+	assert.Equal(t, 24*time.Hour, escalation.BlockTime(time.Minute, 10))
+	assert.Equal(t, 24*time.Hour, escalation.BlockTime(time.Minute, 1000))
+}
+
+func TestBlockEscalation_BlockTime_CapsBaseAboveMax(t *testing.T) {
+	escalation := BlockEscalation{Factor: 2, MaxBlockTime: time.Hour, Decay: 24 * time.Hour}
+
+	tests := map[string]struct {
+		strike   int
+		expected time.Duration
+	}{
+		"no strike recorded": {strike: 0, expected: time.Hour},
+		"first strike":       {strike: 1, expected: time.Hour},
+		"second strike":      {strike: 2, expected: time.Hour},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, escalation.BlockTime(2*time.Hour, tt.strike))
+		})
+	}
+}
+
+func TestBlockEscalation_Disabled_UsesBaseBlockTime(t *testing.T) {
+	assert.False(t, BlockEscalation{}.Enabled())
+	assert.Equal(t, time.Minute, BlockEscalation{}.BlockTime(time.Minute, 5))
+	assert.Equal(t, time.Duration(0), BlockEscalation{Factor: 2, Decay: time.Hour}.BlockTime(0, 5),
+		"blocking disabled stays disabled")
+}
+
+func TestBlockEscalation_WithoutMax_DoesNotOverflow(t *testing.T) {
+	escalation := BlockEscalation{Factor: 10, Decay: time.Hour}
+
+	assert.Equal(t, maxDuration, escalation.BlockTime(time.Hour, 100))
+}
@@ -0,0 +1,67 @@
+package entity
+
+import "time"
+
+// BlockReason describes why a key was blocked
+type BlockReason string
+
+const (
+	// BlockReasonRateExceeded is set when the key exceeded its rate limit
+	BlockReasonRateExceeded BlockReason = "rate_exceeded"
+	// BlockReasonManual is set when an operator blocked the key through the admin API
+	BlockReasonManual BlockReason = "manual"
+	// BlockReasonDenylist is set when an operator banned the key as part of a deny list
+	BlockReasonDenylist BlockReason = "denylist"
+	// BlockReasonBruteForce is set when an IP submitted too many invalid API keys
+	BlockReasonBruteForce BlockReason = "brute_force"
+)
+
+// IsValid reports whether the reason is one of the known reasons
+func (r BlockReason) IsValid() bool {
+	switch r {
+	case BlockReasonRateExceeded, BlockReasonManual, BlockReasonDenylist, BlockReasonBruteForce:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sources of automatic blocks (manual blocks use the operator identity, e.g. "admin:alice")
+const (
+	BlockSourceRateLimiter = "rate-limiter"
+	BlockSourceTokenAbuse  = "token-abuse-detector"
+)
+
+// BlockInfo describes a block: why it was set, by whom and which limit tripped.
+// Blocks created before this information existed have a zero BlockInfo.
+type BlockInfo struct {
+	Reason    BlockReason
+	Source    string    // Who or what set the block
+	BlockedAt time.Time // When the block was set
+
+	// Limit that tripped (automatic blocks only)
+	Policy  string
+	Limit   int
+	Window  time.Duration
+	Strikes int // Consecutive violations when block escalation is enabled
+
+	Note string // Free text from the operator (manual blocks only)
+}
+
+// AuditAction is the kind of change recorded in the audit trail
+type AuditAction string
+
+const (
+	AuditActionBlock   AuditAction = "block"
+	AuditActionUnblock AuditAction = "unblock"
+)
+
+// AuditEvent is an entry of the append-only audit trail of blocks and unblocks
+type AuditEvent struct {
+	Action   AuditAction
+	Key      LimiterKey
+	Time     time.Time
+	Duration time.Duration // Block duration (block events only)
+	Info     BlockInfo     // Block details (block events only)
+	Actor    string        // Who removed the block (unblock events only)
+}
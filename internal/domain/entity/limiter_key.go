@@ -1,6 +1,12 @@
 package entity
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+)
 
 // KeyType represents the type of limiter key
 type KeyType string
@@ -10,8 +16,15 @@ const (
 	KeyTypeIP KeyType = "ip"
 	// KeyTypeToken represents a token-based rate limit key
 	KeyTypeToken KeyType = "token"
+	// KeyTypeOutbound represents a client-side limit on outgoing calls (e.g. a third-party API host)
+	KeyTypeOutbound KeyType = "outbound"
 )
 
+// IsValid reports whether the key type is one of the known types
+func (t KeyType) IsValid() bool {
+	return t == KeyTypeIP || t == KeyTypeToken || t == KeyTypeOutbound
+}
+
 // LimiterKey is a value object that represents a rate limiter key
 type LimiterKey struct {
 	Type  KeyType // The type of key (IP or Token)
@@ -24,13 +37,93 @@ func NewIPKey(ip string) LimiterKey {
 }
 
 // NewTokenKey creates a new Token-based limiter key
+// The scope separator is escaped in the token, so a token containing '|' is never mistaken for a scoped key
 func NewTokenKey(token string) LimiterKey {
-	return LimiterKey{Type: KeyTypeToken, Value: token}
+	return LimiterKey{Type: KeyTypeToken, Value: tokenEscaper.Replace(token)}
+}
+
+// NewOutboundKey creates a key for outgoing calls to a destination (host or custom name)
+func NewOutboundKey(destination string) LimiterKey {
+	return LimiterKey{Type: KeyTypeOutbound, Value: destination}
+}
+
+// scopeSeparator separates the scope from the client identity in a scoped key value
+const scopeSeparator = "|"
+
+// tokenEscaper and tokenUnescaper make the scope separator unambiguous in token key values
+// '%' is escaped too, so the original token can always be recovered
+var (
+	tokenEscaper   = strings.NewReplacer("%", "%25", scopeSeparator, "%7C")
+	tokenUnescaper = strings.NewReplacer("%25", "%", "%7C", scopeSeparator)
+)
+
+// splitScope splits a key value into its scope and client identity
+// The identity never contains the separator (tokens are escaped), so the value is split on the last one
+func splitScope(value string) (scope, identity string, scoped bool) {
+	i := strings.LastIndex(value, scopeSeparator)
+	if i < 0 {
+		return "", value, false
+	}
+	return value[:i], value[i+len(scopeSeparator):], true
+}
+
+// Scoped returns a key with its own bucket for the given scope (e.g. a route name)
+// The scope is prepended to the value, so scoped buckets never share state with the client's global bucket
+func (k LimiterKey) Scoped(scope string) LimiterKey {
+	return LimiterKey{Type: k.Type, Value: scope + scopeSeparator + k.Value}
 }
 
+// keyPrefix is the namespace shared by every limiter key
+const keyPrefix = "rate_limit"
+
 // String returns the string representation for use as Redis key
 func (k LimiterKey) String() string {
-	return fmt.Sprintf("rate_limit:%s:%s", k.Type, k.Value)
+	return fmt.Sprintf("%s:%s:%s", keyPrefix, k.Type, k.Value)
+}
+
+// ParseLimiterKey parses the representation produced by String back into a LimiterKey
+// The value may contain ':' (e.g. IPv6 addresses), so only the first two separators are split
+func ParseLimiterKey(s string) (LimiterKey, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix {
+		return LimiterKey{}, fmt.Errorf("invalid limiter key %q", s)
+	}
+
+	key := LimiterKey{Type: KeyType(parts[1]), Value: parts[2]}
+	if !key.Type.IsValid() || !key.IsValid() {
+		return LimiterKey{}, fmt.Errorf("invalid limiter key %q", s)
+	}
+	return key, nil
+}
+
+// RedactToken returns a stable, non-reversible identifier of a token
+// It allows telling tokens apart in logs and diagnostics without exposing their value
+func RedactToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// Redacted returns a representation of the key that is safe to log
+// Raw token values are replaced by RedactToken (the scope of a scoped key stays readable);
+// IP keys and HMAC token digests are unchanged, so they can be matched against storage keys
+func (k LimiterKey) Redacted() string {
+	if k.Type != KeyTypeToken {
+		return string(k.Type) + ":" + k.Value
+	}
+
+	scope, token, scoped := splitScope(k.Value)
+	if !IsTokenDigest(token) {
+		token = RedactToken(tokenUnescaper.Replace(token))
+	}
+	if scoped {
+		return string(k.Type) + ":" + scope + scopeSeparator + token
+	}
+	return string(k.Type) + ":" + token
+}
+
+// LogValue implements slog.LogValuer so that logging a key never leaks the API token
+func (k LimiterKey) LogValue() slog.Value {
+	return slog.StringValue(k.Redacted())
 }
 
 // IsValid validates the value object
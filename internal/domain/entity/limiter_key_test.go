@@ -42,3 +42,61 @@ func TestLimiterKeyIsValid_ReturnsFalseForInvalid(t *testing.T) {
 		assert.False(t, c.IsValid())
 	}
 }
+
+func TestParseLimiterKey_RoundTripsString(t *testing.T) {
+	cases := []LimiterKey{
+		NewIPKey("192.168.1.1"),
+		NewIPKey("2001:db8::1"), // IPv6 contains ':'
+		NewTokenKey("abc123"),
+		NewOutboundKey("api.partner.com"),
+	}
+
+	for _, c := range cases {
+		parsed, err := ParseLimiterKey(c.String())
+		assert.NoError(t, err)
+		assert.Equal(t, c, parsed)
+	}
+}
+
+func TestParseLimiterKey_ReturnsErrorForInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		"rate_limit:ip",          // missing value
+		"other:ip:192.168.1.1",   // wrong prefix
+		"rate_limit:user:abc123", // unknown type
+		"rate_limit:ip:",         // empty value
+	}
+
+	for _, c := range cases {
+		_, err := ParseLimiterKey(c)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestLimiterKeyScoped_SeparatesBucketFromGlobalKey(t *testing.T) {
+	key := NewIPKey("192.168.1.1")
+
+	scoped := key.Scoped("upload")
+
+	assert.Equal(t, KeyTypeIP, scoped.Type)
+	assert.Equal(t, "rate_limit:ip:upload|192.168.1.1", scoped.String())
+	assert.NotEqual(t, key.String(), scoped.String())
+}
+
+func TestLimiterKeyRedacted_HidesTokenValue(t *testing.T) {
+	tokenKey := NewTokenKey("abc123")
+
+	assert.Equal(t, "ip:192.168.1.1", NewIPKey("192.168.1.1").Redacted())
+	assert.Equal(t, "token:"+RedactToken("abc123"), tokenKey.Redacted())
+	assert.Equal(t, "token:upload|"+RedactToken("abc123"), tokenKey.Scoped("upload").Redacted())
+	assert.NotContains(t, tokenKey.LogValue().String(), "abc123")
+}
+
+func TestLimiterKeyRedacted_TokenWithSeparator_NeverLeaksPrefix(t *testing.T) {
+	tokenKey := NewTokenKey("upload|abc123")
+
+	assert.NotEqual(t, NewTokenKey("abc123").Scoped("upload"), tokenKey, "o token não se confunde com uma chave com escopo")
+	assert.Equal(t, "token:"+RedactToken("upload|abc123"), tokenKey.Redacted())
+	assert.Equal(t, "token:login|"+RedactToken("upload|abc123"), tokenKey.Scoped("login").Redacted())
+	assert.NotContains(t, tokenKey.Scoped("login").Redacted(), "upload")
+}
@@ -38,13 +38,39 @@ func (r *RateLimit) CanConsume() bool {
 
 // ConsumeToken consumes one token from the bucket
 func (r *RateLimit) ConsumeToken() error {
-	if !r.CanConsume() {
+	return r.ConsumeTokens(1)
+}
+
+// ConsumeTokens consumes n tokens from the bucket (weighted requests)
+// Nothing is consumed when fewer than n tokens are available
+func (r *RateLimit) ConsumeTokens(n int) error {
+	if r.CurrentTokens < float64(n) {
 		return ErrRateLimitExceeded
 	}
-	r.CurrentTokens -= 1
+	r.CurrentTokens -= float64(n)
 	return nil
 }
 
+// ReserveTokens takes n tokens even when fewer are available, leaving the bucket in debt
+// Returns the time until the debt is paid off by the refill; when it exceeds maxWait nothing is taken
+func (r *RateLimit) ReserveTokens(n int, maxWait time.Duration) (time.Duration, bool) {
+	var delay time.Duration
+	if deficit := float64(n) - r.CurrentTokens; deficit > 0 {
+		refillRate := float64(r.Limit) / r.Window.Seconds()
+		delay = time.Duration(deficit / refillRate * float64(time.Second))
+	}
+	if delay > maxWait {
+		return delay, false
+	}
+	r.CurrentTokens -= float64(n)
+	return delay, true
+}
+
+// RefundTokens gives back n tokens taken by a reservation that was not used, up to the bucket capacity
+func (r *RateLimit) RefundTokens(n int) {
+	r.CurrentTokens = math.Min(float64(r.Limit), r.CurrentTokens+float64(n))
+}
+
 // RefillTokens calculates and adds tokens based on elapsed time using Token Bucket Algorithm
 //
 // This method implements the core logic of the Token Bucket algorithm:
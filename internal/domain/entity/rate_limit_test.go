@@ -44,6 +44,65 @@ func TestConsumeToken_FailsWhenNoTokens(t *testing.T) {
 	assert.Equal(t, 0.0, rateLimit.CurrentTokens)
 }
 
+func TestConsumeTokens_ConsumesNothingWhenInsufficient(t *testing.T) {
+	rateLimit := &RateLimit{
+		CurrentTokens: 2.5,
+	}
+
+	assert.Equal(t, ErrRateLimitExceeded, rateLimit.ConsumeTokens(3))
+	assert.Equal(t, 2.5, rateLimit.CurrentTokens)
+
+	assert.NoError(t, rateLimit.ConsumeTokens(2))
+	assert.Equal(t, 0.5, rateLimit.CurrentTokens)
+}
+
+func TestReserveTokens_TakesTokensIntoDebt(t *testing.T) {
+	rateLimit := &RateLimit{
+		Limit:         10,
+		Window:        time.Second,
+		CurrentTokens: 1,
+	}
+
+	delay, ok := rateLimit.ReserveTokens(1, 0)
+	assert.True(t, ok)
+	assert.Zero(t, delay)
+	assert.Equal(t, 0.0, rateLimit.CurrentTokens)
+
+	delay, ok = rateLimit.ReserveTokens(2, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 200*time.Millisecond, delay)
+	assert.Equal(t, -2.0, rateLimit.CurrentTokens)
+}
+
+func TestReserveTokens_TakesNothingWhenDelayExceedsMaxWait(t *testing.T) {
+	rateLimit := &RateLimit{
+		Limit:         10,
+		Window:        time.Second,
+		CurrentTokens: -5,
+	}
+
+	delay, ok := rateLimit.ReserveTokens(5, 500*time.Millisecond)
+
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, delay)
+	assert.Equal(t, -5.0, rateLimit.CurrentTokens)
+}
+
+func TestRefundTokens_GivesBackReservedTokensUpToCapacity(t *testing.T) {
+	rateLimit := &RateLimit{
+		Limit:         10,
+		Window:        time.Second,
+		CurrentTokens: -2,
+	}
+
+	rateLimit.RefundTokens(2)
+	assert.Equal(t, 0.0, rateLimit.CurrentTokens)
+
+	rateLimit.CurrentTokens = 9
+	rateLimit.RefundTokens(5)
+	assert.Equal(t, 10.0, rateLimit.CurrentTokens)
+}
+
 func TestRefillTokens_AddsTokensBasedOnElapsedTime(t *testing.T) {
 	now := time.Now()
 	rateLimit := &RateLimit{
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// TokenConfig holds the rate limiting rules of an API token
+type TokenConfig struct {
+	Token     string        // The API token value sent in the API_KEY header
+	Limit     int           // Requests allowed per window
+	Window    time.Duration // Time window (e.g., 1 second)
+	BlockTime time.Duration // Block time after exceeding
+}
+
+// Validate validates the token configuration
+func (c TokenConfig) Validate() error {
+	if c.Token == "" {
+		return errors.New("token cannot be empty")
+	}
+	if c.Limit <= 0 {
+		return errors.New("limit must be positive")
+	}
+	if c.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+	if c.BlockTime < 0 {
+		return errors.New("block time cannot be negative")
+	}
+	return nil
+}
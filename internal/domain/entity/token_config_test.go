@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenConfigValidate_WithValidData(t *testing.T) {
+	cfg := TokenConfig{Token: "abc123", Limit: 100, Window: time.Second, BlockTime: time.Minute}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestTokenConfigValidate_WithInvalidData(t *testing.T) {
+	cases := []TokenConfig{
+		{Token: "", Limit: 100, Window: time.Second},                                // empty token
+		{Token: "abc123", Limit: 0, Window: time.Second},                            // zero limit
+		{Token: "abc123", Limit: 100, Window: 0},                                    // zero window
+		{Token: "abc123", Limit: 100, Window: time.Second, BlockTime: -time.Second}, // negative block
+	}
+
+	for _, c := range cases {
+		assert.Error(t, c.Validate())
+	}
+}
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// TokenDigestPrefix marks token key values that hold an HMAC digest instead of the raw token
+const TokenDigestPrefix = "hmac:"
+
+// TokenHasher derives limiter keys from API tokens using HMAC-SHA256 with a server-side secret,
+// so raw tokens never appear in storage key names (and cannot be harvested with SCAN/KEYS).
+// The zero value (no secret) keeps the legacy behavior of using the raw token.
+type TokenHasher struct {
+	secret []byte
+}
+
+// NewTokenHasher creates a hasher with the given secret (empty disables hashing)
+func NewTokenHasher(secret string) TokenHasher {
+	return TokenHasher{secret: []byte(secret)}
+}
+
+// Enabled reports whether tokens are hashed
+func (h TokenHasher) Enabled() bool {
+	return len(h.secret) > 0
+}
+
+// Key returns the limiter key of an API token
+func (h TokenHasher) Key(token string) LimiterKey {
+	if !h.Enabled() {
+		return NewTokenKey(token)
+	}
+	return LimiterKey{Type: KeyTypeToken, Value: h.Digest(token)}
+}
+
+// Digest returns the keyed digest stored in place of the token
+func (h TokenHasher) Digest(token string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(token))
+	return TokenDigestPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashToken returns the identifier stored in place of a client-supplied API key: its digest when
+// hashing is enabled, or the key itself otherwise. A key that already looks like a digest is hashed too,
+// so a digest leaked from storage or logs never works as a credential.
+func (h TokenHasher) HashToken(token string) string {
+	if !h.Enabled() {
+		return token
+	}
+	return h.Digest(token)
+}
+
+// StoredToken is HashToken for values that may already be digests (e.g. entries read from storage,
+// admin and migration input): digests are returned unchanged. Never use it for client-supplied keys.
+func (h TokenHasher) StoredToken(token string) string {
+	if !h.Enabled() || IsTokenDigest(token) {
+		return token
+	}
+	return h.Digest(token)
+}
+
+// HashKey converts a legacy key that holds a raw token into its hashed equivalent, keeping the scope.
+// It returns false when the key is not a raw token key (IP keys or keys already hashed) or hashing is disabled.
+// Used to migrate buckets created before the secret was configured.
+// Legacy keys created before tokens were escaped are ambiguous when the token contains '|':
+// the part before the last separator is taken as the scope.
+func (h TokenHasher) HashKey(key LimiterKey) (LimiterKey, bool) {
+	if !h.Enabled() || key.Type != KeyTypeToken {
+		return LimiterKey{}, false
+	}
+
+	scope, token, scoped := splitScope(key.Value)
+	if IsTokenDigest(token) {
+		return LimiterKey{}, false
+	}
+
+	hashed := h.Key(tokenUnescaper.Replace(token))
+	if scoped {
+		hashed = hashed.Scoped(scope)
+	}
+	return hashed, true
+}
+
+// IsTokenDigest reports whether a token key value is an HMAC digest (and not a raw token)
+func IsTokenDigest(value string) bool {
+	return strings.HasPrefix(value, TokenDigestPrefix)
+}
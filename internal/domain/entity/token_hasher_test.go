@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenHasher_Key_UsesKeyedDigest(t *testing.T) {
+	hasher := NewTokenHasher("s3cret")
+
+	key := hasher.Key("abc123")
+
+	assert.Equal(t, KeyTypeToken, key.Type)
+	assert.True(t, IsTokenDigest(key.Value))
+	assert.NotContains(t, key.String(), "abc123")
+	assert.Equal(t, key, hasher.Key("abc123"), "o digest é determinístico")
+	assert.NotEqual(t, key, NewTokenHasher("other").Key("abc123"), "o digest depende do segredo")
+	assert.Equal(t, key.Value, key.Redacted()[len("token:"):], "digests aparecem nos logs como estão no storage")
+}
+
+func TestTokenHasher_HashToken_HashesValuesThatLookLikeDigests(t *testing.T) {
+	hasher := NewTokenHasher("s3cret")
+	digest := hasher.Digest("partner-secret")
+
+	assert.Equal(t, digest, hasher.HashToken("partner-secret"))
+	assert.NotEqual(t, digest, hasher.HashToken(digest), "um digest vazado não vale como API key")
+	assert.Equal(t, digest, hasher.StoredToken(digest))
+	assert.Equal(t, "partner-secret", NewTokenHasher("").HashToken("partner-secret"))
+}
+
+func TestTokenHasher_WithoutSecret_KeepsRawToken(t *testing.T) {
+	hasher := NewTokenHasher("")
+
+	assert.False(t, hasher.Enabled())
+	assert.Equal(t, NewTokenKey("abc123"), hasher.Key("abc123"))
+}
+
+func TestTokenHasher_HashKey_ConvertsLegacyKeys(t *testing.T) {
+	hasher := NewTokenHasher("s3cret")
+
+	hashed, ok := hasher.HashKey(NewTokenKey("abc123"))
+	assert.True(t, ok)
+	assert.Equal(t, hasher.Key("abc123"), hashed)
+
+	scoped, ok := hasher.HashKey(NewTokenKey("abc123").Scoped("upload"))
+	assert.True(t, ok)
+	assert.Equal(t, hasher.Key("abc123").Scoped("upload"), scoped)
+
+	_, ok = hasher.HashKey(hashed)
+	assert.False(t, ok, "chaves já migradas são ignoradas")
+	_, ok = hasher.HashKey(NewIPKey("10.0.0.1"))
+	assert.False(t, ok)
+	_, ok = NewTokenHasher("").HashKey(NewTokenKey("abc123"))
+	assert.False(t, ok)
+}
+
+func TestTokenHasher_HashKey_TokenWithSeparator_KeepsWholeToken(t *testing.T) {
+	hasher := NewTokenHasher("s3cret")
+
+	hashed, ok := hasher.HashKey(NewTokenKey("upload|abc123"))
+	assert.True(t, ok)
+	assert.Equal(t, hasher.Key("upload|abc123"), hashed)
+
+	scoped, ok := hasher.HashKey(NewTokenKey("a%7Cb|c").Scoped("login"))
+	assert.True(t, ok)
+	assert.Equal(t, hasher.Key("a%7Cb|c").Scoped("login"), scoped)
+}
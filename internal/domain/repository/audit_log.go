@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// AuditLog defines the contract for the append-only audit trail of block and unblock events.
+type AuditLog interface {
+	// Append records an event. Events are never modified or removed (except by retention).
+	Append(ctx context.Context, event entity.AuditEvent) error
+
+	// Recent returns up to limit events, newest first.
+	Recent(ctx context.Context, limit int) ([]entity.AuditEvent, error)
+}
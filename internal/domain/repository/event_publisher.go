@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// EventPublisher defines the contract for notifying external systems about block and unblock events.
+// Notifications are best effort: callers log a failure and never fail the request because of it.
+type EventPublisher interface {
+	// Publish delivers (or enqueues for delivery) an event.
+	Publish(ctx context.Context, event entity.AuditEvent) error
+}
@@ -11,28 +11,81 @@ import (
 // This interface allows the application business rules (use cases) to depend on abstractions
 // rather than concrete implementations, enabling easy swapping of storage mechanisms.
 type Storage interface {
-	// CheckAndConsume verifies if a request can consume a token and consumes it atomically.
+	// CheckAndConsume verifies if a request can consume cost tokens and consumes them atomically.
 	// This method implements the core Token Bucket algorithm check in a thread-safe manner.
+	// A denied request consumes nothing. Cost must be positive (1 for a regular request).
 	// Returns CheckResult with information about whether the request was allowed and current state.
 	CheckAndConsume(
 		ctx context.Context,
 		key entity.LimiterKey,
 		limit int,
 		window time.Duration,
+		cost int,
 	) (*CheckResult, error)
 
+	// Reserve takes cost tokens from the bucket even when fewer are available, leaving it in debt,
+	// unless paying the debt off would take longer than maxWait (nothing is reserved in that case).
+	// It never blocks the key: the caller waits ReserveResult.Delay before using the tokens.
+	Reserve(
+		ctx context.Context,
+		key entity.LimiterKey,
+		limit int,
+		window time.Duration,
+		cost int,
+		maxWait time.Duration,
+	) (*ReserveResult, error)
+
+	// Refund gives back cost tokens taken by Reserve when the caller gave up before using them.
+	// The bucket never goes above its capacity; refunding a key without a stored bucket does nothing.
+	Refund(
+		ctx context.Context,
+		key entity.LimiterKey,
+		limit int,
+		window time.Duration,
+		cost int,
+	) error
+
 	// SetBlock blocks a key for a specified duration when rate limit is exceeded.
 	// This prevents additional requests from the same key during the block period.
+	// The block info (reason, source, limit that tripped) is stored with the block.
 	SetBlock(
 		ctx context.Context,
 		key entity.LimiterKey,
 		blockTime time.Duration,
+		info entity.BlockInfo,
 	) error
 
 	// IsBlocked checks if a key is currently blocked due to rate limit violation.
 	// Returns true if the key is blocked, false otherwise.
 	IsBlocked(ctx context.Context, key entity.LimiterKey) (bool, error)
 
+	// GetKeyState returns the current bucket and block state of a key for inspection.
+	// A key without a stored bucket is reported with Exists=false (it would start full).
+	GetKeyState(ctx context.Context, key entity.LimiterKey) (*KeyState, error)
+
+	// ResetBucket discards the stored bucket so the key starts again with a full bucket.
+	// It does not affect an active block.
+	ResetBucket(ctx context.Context, key entity.LimiterKey) error
+
+	// Unblock removes an active block before its expiration.
+	// Unblocking a key that is not blocked is not an error.
+	Unblock(ctx context.Context, key entity.LimiterKey) error
+
+	// ListBlocked returns every key that is currently blocked with its remaining block time.
+	ListBlocked(ctx context.Context) ([]BlockedKey, error)
+
+	// AddStrike records a violation of the key and returns the number of consecutive violations.
+	// The counter is forgotten after decay without new violations (used by block escalation).
+	AddStrike(ctx context.Context, key entity.LimiterKey, decay time.Duration) (int, error)
+
+	// ResetStrikes forgets the violations of a key, so its next block uses the base block time.
+	ResetStrikes(ctx context.Context, key entity.LimiterKey) error
+
+	// CountDistinct adds member to the set of the key and returns how many distinct members it holds.
+	// The set lives for window from its first member (a fixed window), so it never grows past one window.
+	// The count may be approximate (e.g. a HyperLogLog in Redis); members cannot be read back.
+	CountDistinct(ctx context.Context, key entity.LimiterKey, member string, window time.Duration) (int, error)
+
 	// Close closes any connections or resources used by the storage implementation.
 	// Should be called during application shutdown for proper cleanup.
 	Close() error
@@ -44,3 +97,30 @@ type CheckResult struct {
 	CurrentTokens float64 // Current number of tokens available in the bucket
 	Limit         int     // The configured limit for this key
 }
+
+// ReserveResult contains the result of a reservation
+type ReserveResult struct {
+	OK            bool          // Whether the tokens were reserved (false when Delay would exceed maxWait)
+	Delay         time.Duration // Time until the reserved tokens are available (zero when they already were)
+	CurrentTokens float64       // Tokens in the bucket after the reservation (negative while in debt)
+	Limit         int           // The configured limit for this key
+}
+
+// KeyState describes the stored rate limiting state of a key
+type KeyState struct {
+	Key        entity.LimiterKey
+	Exists     bool             // Whether a bucket is stored for the key
+	Tokens     float64          // Tokens stored at LastRefill (refill is applied on the next check)
+	LastRefill time.Time        // Timestamp of the last refill
+	Blocked    bool             // Whether the key is currently blocked
+	BlockTTL   time.Duration    // Remaining block time when Blocked is true
+	BlockInfo  entity.BlockInfo // Why and by whom the key was blocked (zero for legacy blocks)
+	Strikes    int              // Consecutive violations not yet forgotten (block escalation)
+}
+
+// BlockedKey is a key that is currently blocked
+type BlockedKey struct {
+	Key  entity.LimiterKey
+	TTL  time.Duration    // Remaining block time
+	Info entity.BlockInfo // Why and by whom the key was blocked (zero for legacy blocks)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// ErrTokenConfigNotFound is returned when a token has no stored configuration
+var ErrTokenConfigNotFound = errors.New("token config not found")
+
+// TokenConfigStore defines the contract for token configurations managed at runtime.
+// Implementations must notify watchers of every change so all instances converge quickly.
+type TokenConfigStore interface {
+	// Get returns the configuration of a token or ErrTokenConfigNotFound.
+	Get(ctx context.Context, token string) (*entity.TokenConfig, error)
+
+	// List returns every stored token configuration.
+	List(ctx context.Context) ([]entity.TokenConfig, error)
+
+	// Save creates or replaces the configuration of a token and notifies watchers.
+	Save(ctx context.Context, cfg entity.TokenConfig) error
+
+	// Delete removes the configuration of a token and notifies watchers.
+	// Returns ErrTokenConfigNotFound if the token has no stored configuration.
+	Delete(ctx context.Context, token string) error
+
+	// Watch calls onChange whenever any configuration changes (in this or another instance).
+	// It blocks until the context is cancelled or the subscription fails.
+	Watch(ctx context.Context, onChange func()) error
+}
@@ -1,32 +1,166 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
 )
 
 type Config struct {
 	// Server
 	ServerPort int
 
+	// Admin API (porta separada; 0 desabilita)
+	AdminPort  int
+	AdminToken string
+
+	// Métricas Prometheus em /metrics (porta separada; 0 desabilita)
+	// O número de chaves bloqueadas é atualizado em background a cada MetricsBlockedKeysInterval
+	MetricsPort                int
+	MetricsBlockedKeysInterval time.Duration
+
+	// API de decisão POST /v1/check para uso como sidecar (porta separada; 0 desabilita)
+	// Token vazio deixa a API sem autenticação (gera um aviso)
+	DecisionAPIPort  int
+	DecisionAPIToken string
+
+	// Endpoint de forward auth (nginx auth_request / Traefik forwardAuth) em /auth (porta separada; 0 desabilita)
+	ForwardAuthPort int
+
+	// Exporter de traces OpenTelemetry ("none", "stdout" ou "otlp")
+	TracesExporter string
+
+	// Nível de log ("debug", "info", "warn" ou "error")
+	LogLevel slog.Level
+
+	// Segredo do HMAC aplicado às API keys antes de virarem chaves no storage
+	// Vazio mantém o token em texto puro no nome da chave (gera um aviso)
+	TokenKeySecret string
+
+	// Storage ("redis" ou "memory")
+	StorageBackend string
+
 	// Redis
 	RedisHost     string
 	RedisPort     int
 	RedisPassword string
 	RedisDB       int
 
+	// Redis Cluster / Sentinel
+	// RedisAddrs lista os nós (cluster) ou sentinels; vazio usa RedisHost:RedisPort
+	RedisAddrs       []string
+	RedisMasterName  string
+	RedisClusterMode bool
+
+	// Redis Sharding (nós independentes com consistent hashing)
+	RedisShardAddrs            []string
+	RedisShardHealthInterval   time.Duration
+	RedisShardFailureThreshold int
+
+	// Redis ACL / TLS
+	RedisUsername              string
+	RedisTLSEnabled            bool
+	RedisTLSCAFile             string
+	RedisTLSCertFile           string
+	RedisTLSKeyFile            string
+	RedisTLSInsecureSkipVerify bool // Apenas para ambiente local
+
+	// Redis Pool / Timeouts
+	RedisPoolSize     int
+	RedisMinIdleConns int
+	RedisDialTimeout  time.Duration
+	RedisReadTimeout  time.Duration
+	RedisWriteTimeout time.Duration
+
 	// IP Rate Limiting
 	IPLimit     int
 	IPWindow    time.Duration
 	IPBlockTime time.Duration
 
+	// Bloqueio progressivo: cada violação dentro do decay multiplica o tempo de bloqueio
+	// pelo fator, até o máximo (fator 0 desabilita)
+	BlockEscalationFactor float64
+	BlockEscalationMax    time.Duration
+	BlockEscalationDecay  time.Duration
+
+	// Fila do middleware: sem token, a requisição espera até QueueMaxDelay pelo próximo
+	// em vez de receber 429 (0 desabilita); no máximo QueueMaxPerKey esperando por chave
+	QueueMaxDelay  time.Duration
+	QueueMaxPerKey int
+
 	// Token Configs (mapa token → configuração)
 	TokenConfigs map[string]TokenConfig
+
+	// API keys desconhecidas ("anonymous", "reject" ou "limit")
+	// No modo "limit" usam um bucket próprio por IP
+	UnknownTokenPolicy    string
+	UnknownTokenLimit     int
+	UnknownTokenWindow    time.Duration
+	UnknownTokenBlockTime time.Duration
+
+	// Força bruta: IP com mais de N tokens inválidos distintos na janela é bloqueado (0 desabilita)
+	InvalidTokenMaxDistinct int
+	InvalidTokenWindow      time.Duration
+	InvalidTokenBlockTime   time.Duration
+
+	// Token Configs em runtime ("" desabilita, "redis" ou "file")
+	// Têm prioridade sobre os tokens definidos por variáveis de ambiente
+	TokenConfigSource         string
+	TokenConfigFile           string
+	TokenConfigResyncInterval time.Duration
+
+	// Trilha de auditoria de bloqueios/desbloqueios ("" desabilita, "redis" ou "file")
+	AuditLog       string
+	AuditLogFile   string
+	AuditLogStream string
+	AuditLogMaxLen int64
+
+	// Notificações de bloqueio/desbloqueio (cada sink é opcional)
+	EventWebhookURL         string
+	EventWebhookSecret      string
+	EventWebhookQueueSize   int
+	EventWebhookMaxAttempts int
+	EventWebhookTimeout     time.Duration
+	EventWebhookBackoff     time.Duration
+	EventRedisChannel       string
+	EventLog                bool
+
+	// Serviço gRPC compatível com o Rate Limit Service (RLS) do Envoy (porta separada; 0 desabilita)
+	// RLSDomain vazio aceita qualquer domínio; RLSDescriptor* nomeiam as entradas dos descriptors
+	RLSPort             int
+	RLSDomain           string
+	RLSDescriptorIP     string
+	RLSDescriptorToken  string
+	RLSDescriptorMethod string
+	RLSDescriptorPath   string
+
+	// Modo proxy reverso: com UPSTREAM_URL ou UPSTREAM_ROUTES as requisições permitidas são
+	// encaminhadas ao upstream (a primeira rota que casa com o path; senão UPSTREAM_URL)
+	UpstreamURL             string
+	UpstreamRoutes          []UpstreamRoute
+	UpstreamPreserveHost    bool
+	UpstreamDialTimeout     time.Duration
+	UpstreamResponseTimeout time.Duration
+
+	// Arquivo de política (YAML/JSON); variáveis de ambiente sobrescrevem seus valores
+	PolicyFile string
+	Routes     []RouteConfig
+	AllowList  AccessList
+	DenyList   AccessList
+
+	// Avisos de configuração ignorada (ex: tokens sem limite ou janela válidos)
+	Warnings []string
 }
 
 type TokenConfig struct {
@@ -53,7 +187,44 @@ func (c *Config) GetTokenConfig(token string) (TokenConfig, bool) {
 	return cfg, exists
 }
 
+// MatchRoute retorna a primeira rota da política que casa com a requisição
+func (c *Config) MatchRoute(method, path string) (RouteConfig, bool) {
+	for _, route := range c.Routes {
+		if route.Matches(method, path) {
+			return route, true
+		}
+	}
+	return RouteConfig{}, false
+}
+
+// ProxyEnabled indica se o servidor roda como proxy reverso (UPSTREAM_URL ou UPSTREAM_ROUTES)
+func (c *Config) ProxyEnabled() bool {
+	return c.UpstreamURL != "" || len(c.UpstreamRoutes) > 0
+}
+
+// IsDenied verifica se o IP ou o token estão na deny list
+func (c *Config) IsDenied(ip, token string) bool {
+	return c.DenyList.Contains(ip, token)
+}
+
+// IsAllowed verifica se o IP ou o token estão na allow list (não sofrem rate limiting)
+func (c *Config) IsAllowed(ip, token string) bool {
+	return c.AllowList.Contains(ip, token)
+}
+
+// Load carrega e valida a configuração
+// Todos os problemas encontrados são retornados juntos (errors.Join)
 func Load() (*Config, error) {
+	cfg, err := Inspect()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Inspect executa o mesmo parsing de Load, mas sempre retorna a configuração
+// (mesmo parcial) junto dos erros, para ferramentas de diagnóstico (ratelimiter config validate)
+func Inspect() (*Config, error) {
 	// Limpa configurações anteriores do viper
 	viper.Reset()
 
@@ -62,113 +233,338 @@ func Load() (*Config, error) {
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("")
 
+	viper.SetDefault("STORAGE_BACKEND", "redis")
+	viper.SetDefault("METRICS_BLOCKED_KEYS_INTERVAL", "30s")
+	viper.SetDefault("TOKEN_CONFIG_RESYNC_INTERVAL", "30s")
+	viper.SetDefault("OTEL_TRACES_EXPORTER", "none")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("UNKNOWN_TOKEN_POLICY", "anonymous")
+	viper.SetDefault("BLOCK_ESCALATION_MAX", "24h")
+	viper.SetDefault("BLOCK_ESCALATION_DECAY", "48h")
+	viper.SetDefault("QUEUE_MAX_PER_KEY", 10)
+	viper.SetDefault("INVALID_TOKEN_WINDOW", "1m")
+	viper.SetDefault("INVALID_TOKEN_BLOCK_TIME", "15m")
+	viper.SetDefault("AUDIT_LOG_STREAM", "rate_limit:audit")
+	viper.SetDefault("AUDIT_LOG_MAX_LEN", 100000)
+	viper.SetDefault("EVENT_WEBHOOK_QUEUE_SIZE", 1000)
+	viper.SetDefault("EVENT_WEBHOOK_MAX_ATTEMPTS", 5)
+	viper.SetDefault("EVENT_WEBHOOK_TIMEOUT", "5s")
+	viper.SetDefault("EVENT_WEBHOOK_BACKOFF", "1s")
+	viper.SetDefault("UPSTREAM_DIAL_TIMEOUT", "5s")
+	viper.SetDefault("UPSTREAM_RESPONSE_TIMEOUT", "30s")
+	viper.SetDefault("RLS_DESCRIPTOR_IP", "remote_address")
+	viper.SetDefault("RLS_DESCRIPTOR_TOKEN", "api_key")
+	viper.SetDefault("RLS_DESCRIPTOR_METHOD", "method")
+	viper.SetDefault("RLS_DESCRIPTOR_PATH", "path")
+
+	// Valores padrão de conexão com o Redis
+	viper.SetDefault("REDIS_POOL_SIZE", 10)
+	viper.SetDefault("REDIS_MIN_IDLE_CONNS", 0)
+	viper.SetDefault("REDIS_DIAL_TIMEOUT", "5s")
+	viper.SetDefault("REDIS_READ_TIMEOUT", "3s")
+	viper.SetDefault("REDIS_WRITE_TIMEOUT", "3s")
+	viper.SetDefault("REDIS_SHARD_HEALTH_INTERVAL", "5s")
+	viper.SetDefault("REDIS_SHARD_FAILURE_THRESHOLD", 3)
+
 	// Tenta ler .env (ignora erro se não existir, usa env vars)
 	_ = viper.ReadInConfig()
 
 	// Carrega configurações básicas
 	cfg := &Config{
-		ServerPort:    viper.GetInt("SERVER_PORT"),
-		RedisHost:     viper.GetString("REDIS_HOST"),
-		RedisPort:     viper.GetInt("REDIS_PORT"),
-		RedisPassword: viper.GetString("REDIS_PASSWORD"),
-		RedisDB:       viper.GetInt("REDIS_DB"),
-		IPLimit:       viper.GetInt("IP_RATE_LIMIT"),
-		IPWindow:      viper.GetDuration("IP_RATE_WINDOW"),
-		IPBlockTime:   viper.GetDuration("IP_BLOCK_TIME"),
-		TokenConfigs:  make(map[string]TokenConfig),
+		ServerPort:                 viper.GetInt("SERVER_PORT"),
+		AdminPort:                  viper.GetInt("ADMIN_PORT"),
+		AdminToken:                 viper.GetString("ADMIN_TOKEN"),
+		MetricsPort:                viper.GetInt("METRICS_PORT"),
+		MetricsBlockedKeysInterval: viper.GetDuration("METRICS_BLOCKED_KEYS_INTERVAL"),
+		DecisionAPIPort:            viper.GetInt("DECISION_API_PORT"),
+		DecisionAPIToken:           viper.GetString("DECISION_API_TOKEN"),
+		ForwardAuthPort:            viper.GetInt("FORWARD_AUTH_PORT"),
+		TracesExporter:             strings.ToLower(viper.GetString("OTEL_TRACES_EXPORTER")),
+		TokenKeySecret:             viper.GetString("TOKEN_KEY_SECRET"),
+		StorageBackend:             strings.ToLower(viper.GetString("STORAGE_BACKEND")),
+		RedisHost:                  viper.GetString("REDIS_HOST"),
+		RedisPort:                  viper.GetInt("REDIS_PORT"),
+		RedisPassword:              viper.GetString("REDIS_PASSWORD"),
+		RedisDB:                    viper.GetInt("REDIS_DB"),
+		RedisAddrs:                 parseList(viper.GetString("REDIS_ADDRS")),
+		RedisMasterName:            viper.GetString("REDIS_MASTER_NAME"),
+		RedisClusterMode:           viper.GetBool("REDIS_CLUSTER_MODE"),
+		RedisShardAddrs:            parseList(viper.GetString("REDIS_SHARD_ADDRS")),
+		RedisShardHealthInterval:   viper.GetDuration("REDIS_SHARD_HEALTH_INTERVAL"),
+		RedisShardFailureThreshold: viper.GetInt("REDIS_SHARD_FAILURE_THRESHOLD"),
+		RedisUsername:              viper.GetString("REDIS_USERNAME"),
+		RedisTLSEnabled:            viper.GetBool("REDIS_TLS_ENABLED"),
+		RedisTLSCAFile:             viper.GetString("REDIS_TLS_CA_FILE"),
+		RedisTLSCertFile:           viper.GetString("REDIS_TLS_CERT_FILE"),
+		RedisTLSKeyFile:            viper.GetString("REDIS_TLS_KEY_FILE"),
+		RedisTLSInsecureSkipVerify: viper.GetBool("REDIS_TLS_INSECURE_SKIP_VERIFY"),
+		RedisPoolSize:              viper.GetInt("REDIS_POOL_SIZE"),
+		RedisMinIdleConns:          viper.GetInt("REDIS_MIN_IDLE_CONNS"),
+		RedisDialTimeout:           viper.GetDuration("REDIS_DIAL_TIMEOUT"),
+		RedisReadTimeout:           viper.GetDuration("REDIS_READ_TIMEOUT"),
+		RedisWriteTimeout:          viper.GetDuration("REDIS_WRITE_TIMEOUT"),
+		IPLimit:                    viper.GetInt("IP_RATE_LIMIT"),
+		IPWindow:                   viper.GetDuration("IP_RATE_WINDOW"),
+		IPBlockTime:                viper.GetDuration("IP_BLOCK_TIME"),
+		BlockEscalationFactor:      viper.GetFloat64("BLOCK_ESCALATION_FACTOR"),
+		BlockEscalationMax:         viper.GetDuration("BLOCK_ESCALATION_MAX"),
+		BlockEscalationDecay:       viper.GetDuration("BLOCK_ESCALATION_DECAY"),
+		QueueMaxDelay:              viper.GetDuration("QUEUE_MAX_DELAY"),
+		QueueMaxPerKey:             viper.GetInt("QUEUE_MAX_PER_KEY"),
+		TokenConfigs:               make(map[string]TokenConfig),
+		UnknownTokenPolicy:         strings.ToLower(viper.GetString("UNKNOWN_TOKEN_POLICY")),
+		UnknownTokenLimit:          viper.GetInt("UNKNOWN_TOKEN_LIMIT"),
+		UnknownTokenWindow:         viper.GetDuration("UNKNOWN_TOKEN_WINDOW"),
+		UnknownTokenBlockTime:      viper.GetDuration("UNKNOWN_TOKEN_BLOCK_TIME"),
+		InvalidTokenMaxDistinct:    viper.GetInt("INVALID_TOKEN_MAX_DISTINCT"),
+		InvalidTokenWindow:         viper.GetDuration("INVALID_TOKEN_WINDOW"),
+		InvalidTokenBlockTime:      viper.GetDuration("INVALID_TOKEN_BLOCK_TIME"),
+		TokenConfigSource:          strings.ToLower(viper.GetString("TOKEN_CONFIG_SOURCE")),
+		TokenConfigFile:            viper.GetString("TOKEN_CONFIG_FILE"),
+		TokenConfigResyncInterval:  viper.GetDuration("TOKEN_CONFIG_RESYNC_INTERVAL"),
+		AuditLog:                   strings.ToLower(viper.GetString("AUDIT_LOG")),
+		AuditLogFile:               viper.GetString("AUDIT_LOG_FILE"),
+		AuditLogStream:             viper.GetString("AUDIT_LOG_STREAM"),
+		AuditLogMaxLen:             viper.GetInt64("AUDIT_LOG_MAX_LEN"),
+		EventWebhookURL:            viper.GetString("EVENT_WEBHOOK_URL"),
+		EventWebhookSecret:         viper.GetString("EVENT_WEBHOOK_SECRET"),
+		EventWebhookQueueSize:      viper.GetInt("EVENT_WEBHOOK_QUEUE_SIZE"),
+		EventWebhookMaxAttempts:    viper.GetInt("EVENT_WEBHOOK_MAX_ATTEMPTS"),
+		EventWebhookTimeout:        viper.GetDuration("EVENT_WEBHOOK_TIMEOUT"),
+		EventWebhookBackoff:        viper.GetDuration("EVENT_WEBHOOK_BACKOFF"),
+		EventRedisChannel:          viper.GetString("EVENT_REDIS_CHANNEL"),
+		EventLog:                   viper.GetBool("EVENT_LOG"),
+		UpstreamURL:                viper.GetString("UPSTREAM_URL"),
+		UpstreamPreserveHost:       viper.GetBool("UPSTREAM_PRESERVE_HOST"),
+		UpstreamDialTimeout:        viper.GetDuration("UPSTREAM_DIAL_TIMEOUT"),
+		UpstreamResponseTimeout:    viper.GetDuration("UPSTREAM_RESPONSE_TIMEOUT"),
+		RLSPort:                    viper.GetInt("RLS_PORT"),
+		RLSDomain:                  viper.GetString("RLS_DOMAIN"),
+		RLSDescriptorIP:            viper.GetString("RLS_DESCRIPTOR_IP"),
+		RLSDescriptorToken:         viper.GetString("RLS_DESCRIPTOR_TOKEN"),
+		RLSDescriptorMethod:        viper.GetString("RLS_DESCRIPTOR_METHOD"),
+		RLSDescriptorPath:          viper.GetString("RLS_DESCRIPTOR_PATH"),
+		PolicyFile:                 viper.GetString("POLICY_FILE"),
+	}
+
+	var errs []error
+
+	// Aplica o arquivo de política antes das validações (env continua com prioridade)
+	if cfg.PolicyFile != "" {
+		if p, err := loadPolicy(cfg.PolicyFile); err != nil {
+			errs = append(errs, err)
+		} else {
+			applyPolicy(cfg, p)
+		}
 	}
 
 	// Valida campos obrigatórios
 	if cfg.ServerPort <= 0 {
-		return nil, fmt.Errorf("SERVER_PORT is required and must be positive")
+		errs = append(errs, fmt.Errorf("SERVER_PORT is required and must be positive"))
+	}
+	if cfg.AdminPort < 0 {
+		errs = append(errs, fmt.Errorf("ADMIN_PORT cannot be negative"))
+	}
+	if cfg.AdminPort > 0 && cfg.AdminPort == cfg.ServerPort {
+		errs = append(errs, fmt.Errorf("ADMIN_PORT must be different from SERVER_PORT"))
+	}
+	if cfg.AdminPort > 0 && cfg.AdminToken == "" {
+		errs = append(errs, fmt.Errorf("ADMIN_TOKEN is required when ADMIN_PORT is set"))
+	}
+	if cfg.MetricsPort < 0 {
+		errs = append(errs, fmt.Errorf("METRICS_PORT cannot be negative"))
+	}
+	if cfg.MetricsPort > 0 && (cfg.MetricsPort == cfg.ServerPort || cfg.MetricsPort == cfg.AdminPort) {
+		errs = append(errs, fmt.Errorf("METRICS_PORT must be different from SERVER_PORT and ADMIN_PORT"))
+	}
+	if cfg.MetricsBlockedKeysInterval <= 0 {
+		errs = append(errs, fmt.Errorf("METRICS_BLOCKED_KEYS_INTERVAL must be a positive duration"))
+	}
+	if cfg.DecisionAPIPort < 0 {
+		errs = append(errs, fmt.Errorf("DECISION_API_PORT cannot be negative"))
+	}
+	if cfg.DecisionAPIPort > 0 && (cfg.DecisionAPIPort == cfg.ServerPort || cfg.DecisionAPIPort == cfg.AdminPort || cfg.DecisionAPIPort == cfg.MetricsPort) {
+		errs = append(errs, fmt.Errorf("DECISION_API_PORT must be different from SERVER_PORT, ADMIN_PORT and METRICS_PORT"))
+	}
+	if cfg.ForwardAuthPort < 0 {
+		errs = append(errs, fmt.Errorf("FORWARD_AUTH_PORT cannot be negative"))
+	}
+	if cfg.ForwardAuthPort > 0 && (cfg.ForwardAuthPort == cfg.ServerPort || cfg.ForwardAuthPort == cfg.AdminPort ||
+		cfg.ForwardAuthPort == cfg.MetricsPort || cfg.ForwardAuthPort == cfg.DecisionAPIPort) {
+		errs = append(errs, fmt.Errorf("FORWARD_AUTH_PORT must be different from SERVER_PORT, ADMIN_PORT, METRICS_PORT and DECISION_API_PORT"))
+	}
+	if err := cfg.LogLevel.UnmarshalText([]byte(viper.GetString("LOG_LEVEL"))); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be 'debug', 'info', 'warn' or 'error', got: %s", viper.GetString("LOG_LEVEL")))
+	}
+	switch cfg.TracesExporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_EXPORTER must be 'none', 'stdout' or 'otlp', got: %s", cfg.TracesExporter))
 	}
-	if cfg.RedisHost == "" {
-		return nil, fmt.Errorf("REDIS_HOST is required")
+	switch cfg.StorageBackend {
+	case "redis":
+		errs = append(errs, validateRedis(cfg)...)
+	case "memory":
+		// Sem dependências externas
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_BACKEND must be 'redis' or 'memory', got: %s", cfg.StorageBackend))
 	}
+	errs = append(errs, validateTokenConfigSource(cfg)...)
+	errs = append(errs, validateAuditLog(cfg)...)
+	errs = append(errs, validateEvents(cfg)...)
+	errs = append(errs, validateRLS(cfg)...)
+	errs = append(errs, loadUpstreams(cfg)...)
 	if cfg.IPLimit <= 0 {
-		return nil, fmt.Errorf("IP_RATE_LIMIT must be positive")
+		errs = append(errs, fmt.Errorf("IP_RATE_LIMIT must be positive"))
 	}
 	if cfg.IPWindow <= 0 {
-		return nil, fmt.Errorf("IP_RATE_WINDOW must be positive")
+		errs = append(errs, fmt.Errorf("IP_RATE_WINDOW must be positive"))
 	}
+	errs = append(errs, validateUnknownTokens(cfg)...)
+	errs = append(errs, validateBlockEscalation(cfg)...)
+	errs = append(errs, validateQueue(cfg)...)
 
-	// Carrega tokens configurados dinamicamente
-	// Formato: TOKEN_{nome}_LIMIT, TOKEN_{nome}_WINDOW, TOKEN_{nome}_BLOCK_TIME
+	// Tokens definidos por variáveis de ambiente sobrescrevem os do arquivo de política
+	cfg.Warnings = loadTokenEnv(cfg)
+	cfg.Warnings = append(cfg.Warnings, tokenKeySecretWarnings(cfg)...)
+	if cfg.EventWebhookURL != "" && cfg.EventWebhookSecret == "" {
+		cfg.Warnings = append(cfg.Warnings, "EVENT_WEBHOOK_SECRET is not set: webhook deliveries are not signed")
+	}
+	if cfg.DecisionAPIPort > 0 && cfg.DecisionAPIToken == "" {
+		cfg.Warnings = append(cfg.Warnings, "DECISION_API_TOKEN is not set: the decision API accepts unauthenticated requests and explicit limits are disabled")
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// minTokenKeySecretLength é o tamanho mínimo recomendado para o segredo do HMAC (256 bits)
+const minTokenKeySecretLength = 32
+
+// tokenKeySecretWarnings avisa quando as API keys ficam expostas ou mal protegidas no storage
+func tokenKeySecretWarnings(cfg *Config) []string {
+	switch {
+	case cfg.TokenKeySecret == "":
+		return []string{"TOKEN_KEY_SECRET is not set: API keys are stored in plain text in storage key names"}
+	case len(cfg.TokenKeySecret) < minTokenKeySecretLength:
+		return []string{fmt.Sprintf("TOKEN_KEY_SECRET should have at least %d characters", minTokenKeySecretLength)}
+	default:
+		return nil
+	}
+}
+
+// TokenHasher retorna o hasher das API keys configurado por TOKEN_KEY_SECRET
+func (c *Config) TokenHasher() entity.TokenHasher {
+	return entity.NewTokenHasher(c.TokenKeySecret)
+}
+
+// applyPolicy aplica o arquivo de política na configuração
+// Valores de IP só são usados quando a variável de ambiente correspondente não foi definida
+func applyPolicy(cfg *Config, p *policy) {
+	if p.IP != nil {
+		if !viper.IsSet("IP_RATE_LIMIT") {
+			cfg.IPLimit = p.IP.Limit
+		}
+		if !viper.IsSet("IP_RATE_WINDOW") {
+			cfg.IPWindow = p.IP.Window
+		}
+		if !viper.IsSet("IP_BLOCK_TIME") {
+			cfg.IPBlockTime = p.IP.BlockTime
+		}
+	}
+
+	for token, tokenCfg := range p.Tokens {
+		cfg.TokenConfigs[token] = tokenCfg
+	}
+	cfg.Routes = p.Routes
+	cfg.AllowList = p.AllowList
+	cfg.DenyList = p.DenyList
+}
+
+// tokenEnvSuffixes são os sufixos das variáveis de configuração de um token
+var tokenEnvSuffixes = []string{"_LIMIT", "_WINDOW", "_BLOCK_TIME"}
+
+// reservedTokenEnvKeys são variáveis com prefixo TOKEN_ que não descrevem tokens
+var reservedTokenEnvKeys = map[string]bool{
+	"TOKEN_CONFIG_SOURCE":          true,
+	"TOKEN_CONFIG_FILE":            true,
+	"TOKEN_CONFIG_RESYNC_INTERVAL": true,
+	"TOKEN_KEY_SECRET":             true,
+}
+
+// tokenNameFromEnvKey extrai o nome do token de TOKEN_{nome}[_LIMIT|_WINDOW|_BLOCK_TIME]
+// O nome pode conter underscores (ex: TOKEN_PARTNER_ACME_LIMIT → PARTNER_ACME)
+func tokenNameFromEnvKey(key string) (string, bool) {
+	if reservedTokenEnvKeys[strings.ToUpper(key)] {
+		return "", false
+	}
+
+	name, ok := strings.CutPrefix(key, "TOKEN_")
+	if !ok {
+		return "", false
+	}
+	for _, suffix := range tokenEnvSuffixes {
+		if trimmed, found := strings.CutSuffix(name, suffix); found {
+			name = trimmed
+			break
+		}
+	}
+	return name, name != ""
+}
+
+// loadTokenEnv carrega os tokens configurados por variáveis de ambiente
+// Formato: TOKEN_{nome}={valor}, TOKEN_{nome}_LIMIT, TOKEN_{nome}_WINDOW, TOKEN_{nome}_BLOCK_TIME
+// Retorna avisos para os tokens ignorados por configuração inválida
+func loadTokenEnv(cfg *Config) []string {
+	var warnings []string
 	tokenNames := make(map[string]bool)
 
 	// Busca todas as variáveis de ambiente que começam com TOKEN_
 	for _, env := range os.Environ() {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		key := parts[0]
-
-		if strings.HasPrefix(key, "TOKEN_") {
-			keyParts := strings.Split(key, "_")
-			if len(keyParts) >= 3 {
-				tokenName := strings.ToLower(keyParts[1])
-				// Detecta qualquer variável TOKEN_* que tenha pelo menos 3 partes (TOKEN_abc123_*)
-				// Isso inclui TOKEN_abc123=abc123 e TOKEN_abc123_LIMIT=100, etc.
-				tokenNames[tokenName] = true
-			}
+		key, _, _ := strings.Cut(env, "=")
+		if name, ok := tokenNameFromEnvKey(key); ok {
+			tokenNames[name] = true
 		}
 	}
 
-	// Fallback: tenta buscar via viper.AllKeys() se não encontrou via os.Environ()
-	if len(tokenNames) == 0 {
-		for _, key := range viper.AllKeys() {
-			upperKey := strings.ToUpper(key)
-			if strings.HasPrefix(upperKey, "TOKEN_") && (strings.HasSuffix(upperKey, "_LIMIT") || strings.HasSuffix(upperKey, "_WINDOW") || strings.HasSuffix(upperKey, "_BLOCK_TIME")) {
-				parts := strings.Split(upperKey, "_")
-				if len(parts) >= 3 {
-					tokenName := strings.ToLower(parts[1])
-					tokenNames[tokenName] = true
-				}
-			}
+	// Variáveis do arquivo .env (viper guarda as chaves em minúsculas)
+	for _, key := range viper.AllKeys() {
+		if name, ok := tokenNameFromEnvKey(strings.ToUpper(key)); ok {
+			tokenNames[name] = true
 		}
 	}
 
 	// Para cada token descoberto, carrega sua configuração
 	for tokenName := range tokenNames {
-		prefix := fmt.Sprintf("TOKEN_%s", strings.ToUpper(tokenName))
-
-		// Busca diretamente pelas variáveis de ambiente usando os.Getenv
-		// que funciona melhor em produção e com t.Setenv() dos testes
-		limitStr := os.Getenv(prefix + "_LIMIT")
-		windowStr := os.Getenv(prefix + "_WINDOW")
-		blockTimeStr := os.Getenv(prefix + "_BLOCK_TIME")
+		prefix := "TOKEN_" + tokenName
 
-		// Fallback para viper se os.Getenv não retornar valores
-		if limitStr == "" {
-			limitStr = viper.GetString(prefix + "_LIMIT")
-		}
-		if windowStr == "" {
-			windowStr = viper.GetString(prefix + "_WINDOW")
-		}
-		if blockTimeStr == "" {
-			blockTimeStr = viper.GetString(prefix + "_BLOCK_TIME")
-		}
+		limitStr := lookupEnv(prefix + "_LIMIT")
+		windowStr := lookupEnv(prefix + "_WINDOW")
+		blockTimeStr := lookupEnv(prefix + "_BLOCK_TIME")
 
 		limit := parseInt(limitStr)
 		window := parseDuration(windowStr)
 		blockTime := parseDuration(blockTimeStr)
 
-		// Valida configuração do token
-		if limit <= 0 || window <= 0 {
-			continue // Ignora tokens mal configurados
+		// Valida configuração do token (tokens mal configurados são ignorados com aviso)
+		if limit <= 0 {
+			warnings = append(warnings, fmt.Sprintf("%s skipped: %s_LIMIT must be a positive integer, got %q", prefix, prefix, limitStr))
+			continue
 		}
-
-		// Busca o valor real do token (ex: TOKEN_test123=test123)
-		tokenValue := os.Getenv(prefix)
-		if tokenValue == "" {
-			// Fallback para viper se os.Getenv não retornar valores
-			tokenValue = viper.GetString(prefix)
+		if window <= 0 {
+			warnings = append(warnings, fmt.Sprintf("%s skipped: %s_WINDOW must be a positive duration, got %q", prefix, prefix, windowStr))
+			continue
+		}
+		// Block time inválido ou negativo vira zero: acima do limite rejeita sem bloquear
+		if _, err := time.ParseDuration(blockTimeStr); blockTimeStr != "" && (err != nil || blockTime < 0) {
+			warnings = append(warnings, fmt.Sprintf("%s: %s_BLOCK_TIME is invalid (%q), blocking disabled", prefix, prefix, blockTimeStr))
+			blockTime = 0
 		}
 
+		// Busca o valor real do token (ex: TOKEN_test123=test123)
 		// Se não encontrou o valor, usa o nome como fallback
+		tokenValue := lookupEnv(prefix)
 		if tokenValue == "" {
-			tokenValue = tokenName
+			tokenValue = strings.ToLower(tokenName)
 		}
 
 		// Usa o valor real do token como chave
@@ -179,7 +575,343 @@ func Load() (*Config, error) {
 		}
 	}
 
-	return cfg, nil
+	sort.Strings(warnings)
+	return slices.Compact(warnings)
+}
+
+// lookupEnv busca a variável no ambiente (funciona com t.Setenv() dos testes)
+// e usa o viper (.env) como fallback
+func lookupEnv(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return viper.GetString(key)
+}
+
+// validateRedis valida endereços, TLS, pool e timeouts da conexão com o Redis
+// Retorna todos os problemas encontrados
+func validateRedis(cfg *Config) []error {
+	var errs []error
+
+	if cfg.RedisHost == "" && len(cfg.RedisAddrs) == 0 && len(cfg.RedisShardAddrs) == 0 {
+		errs = append(errs, fmt.Errorf("REDIS_HOST, REDIS_ADDRS or REDIS_SHARD_ADDRS is required"))
+	}
+	if cfg.RedisMasterName != "" && len(cfg.RedisAddrs) == 0 {
+		errs = append(errs, fmt.Errorf("REDIS_ADDRS must list the sentinel addresses when REDIS_MASTER_NAME is set"))
+	}
+
+	// Sharding usa nós standalone, não pode ser combinado com Cluster/Sentinel
+	if len(cfg.RedisShardAddrs) > 0 {
+		if len(cfg.RedisAddrs) > 0 || cfg.RedisMasterName != "" || cfg.RedisClusterMode {
+			errs = append(errs, fmt.Errorf("REDIS_SHARD_ADDRS cannot be combined with REDIS_ADDRS, REDIS_MASTER_NAME or REDIS_CLUSTER_MODE"))
+		}
+		if cfg.RedisShardHealthInterval <= 0 {
+			errs = append(errs, fmt.Errorf("REDIS_SHARD_HEALTH_INTERVAL must be a positive duration"))
+		}
+		if cfg.RedisShardFailureThreshold <= 0 {
+			errs = append(errs, fmt.Errorf("REDIS_SHARD_FAILURE_THRESHOLD must be positive"))
+		}
+	}
+
+	// Pool de conexões
+	if cfg.RedisPoolSize <= 0 {
+		errs = append(errs, fmt.Errorf("REDIS_POOL_SIZE must be positive"))
+	}
+	if cfg.RedisMinIdleConns < 0 || cfg.RedisMinIdleConns > cfg.RedisPoolSize {
+		errs = append(errs, fmt.Errorf("REDIS_MIN_IDLE_CONNS must be between 0 and REDIS_POOL_SIZE (%d)", cfg.RedisPoolSize))
+	}
+
+	// Timeouts (valores inválidos viram 0 no viper)
+	if cfg.RedisDialTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("REDIS_DIAL_TIMEOUT must be a positive duration"))
+	}
+	if cfg.RedisReadTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("REDIS_READ_TIMEOUT must be a positive duration"))
+	}
+	if cfg.RedisWriteTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("REDIS_WRITE_TIMEOUT must be a positive duration"))
+	}
+
+	// TLS: arquivos só fazem sentido com TLS habilitado e precisam existir
+	tlsFiles := []struct{ name, path string }{
+		{"REDIS_TLS_CA_FILE", cfg.RedisTLSCAFile},
+		{"REDIS_TLS_CERT_FILE", cfg.RedisTLSCertFile},
+		{"REDIS_TLS_KEY_FILE", cfg.RedisTLSKeyFile},
+	}
+	for _, file := range tlsFiles {
+		if file.path == "" {
+			continue
+		}
+		if !cfg.RedisTLSEnabled {
+			errs = append(errs, fmt.Errorf("%s is set but REDIS_TLS_ENABLED is false", file.name))
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.name, err))
+		}
+	}
+	if (cfg.RedisTLSCertFile == "") != (cfg.RedisTLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("REDIS_TLS_CERT_FILE and REDIS_TLS_KEY_FILE must be set together"))
+	}
+	if cfg.RedisTLSInsecureSkipVerify && !cfg.RedisTLSEnabled {
+		errs = append(errs, fmt.Errorf("REDIS_TLS_INSECURE_SKIP_VERIFY requires REDIS_TLS_ENABLED"))
+	}
+
+	return errs
+}
+
+// validateBlockEscalation valida o bloqueio progressivo
+// O decay conta a partir da violação, então precisa ser maior que o bloqueio máximo;
+// caso contrário os strikes expirariam durante o próprio bloqueio
+func validateBlockEscalation(cfg *Config) []error {
+	if cfg.BlockEscalationFactor == 0 {
+		return nil
+	}
+
+	var errs []error
+	if cfg.BlockEscalationFactor <= 1 {
+		errs = append(errs, fmt.Errorf("BLOCK_ESCALATION_FACTOR must be greater than 1 (or 0 to disable), got: %v", cfg.BlockEscalationFactor))
+	}
+	if cfg.BlockEscalationMax <= 0 {
+		errs = append(errs, fmt.Errorf("BLOCK_ESCALATION_MAX must be a positive duration"))
+	}
+	if cfg.BlockEscalationDecay <= cfg.BlockEscalationMax {
+		errs = append(errs, fmt.Errorf("BLOCK_ESCALATION_DECAY must be greater than BLOCK_ESCALATION_MAX"))
+	}
+	return errs
+}
+
+// validateQueue valida a fila do middleware (QUEUE_*)
+func validateQueue(cfg *Config) []error {
+	var errs []error
+	if cfg.QueueMaxDelay < 0 {
+		errs = append(errs, fmt.Errorf("QUEUE_MAX_DELAY cannot be negative"))
+	}
+	if cfg.QueueMaxDelay > 0 && cfg.QueueMaxPerKey <= 0 {
+		errs = append(errs, fmt.Errorf("QUEUE_MAX_PER_KEY must be positive when QUEUE_MAX_DELAY is set"))
+	}
+	return errs
+}
+
+// QueueEnabled informa se o middleware segura requisições na fila em vez de rejeitá-las
+func (c *Config) QueueEnabled() bool {
+	return c.QueueMaxDelay > 0
+}
+
+// BlockEscalation retorna a política de bloqueio progressivo (desabilitada com BLOCK_ESCALATION_FACTOR=0)
+func (c *Config) BlockEscalation() entity.BlockEscalation {
+	if c.BlockEscalationFactor == 0 {
+		return entity.BlockEscalation{}
+	}
+	return entity.BlockEscalation{
+		Factor:       c.BlockEscalationFactor,
+		MaxBlockTime: c.BlockEscalationMax,
+		Decay:        c.BlockEscalationDecay,
+	}
+}
+
+// validateUnknownTokens valida a política de API keys desconhecidas e a detecção de força bruta
+func validateUnknownTokens(cfg *Config) []error {
+	var errs []error
+
+	switch cfg.UnknownTokenPolicy {
+	case "anonymous", "reject":
+	case "limit":
+		if cfg.UnknownTokenLimit <= 0 {
+			errs = append(errs, fmt.Errorf("UNKNOWN_TOKEN_LIMIT must be positive when UNKNOWN_TOKEN_POLICY=limit"))
+		}
+		if cfg.UnknownTokenWindow <= 0 {
+			errs = append(errs, fmt.Errorf("UNKNOWN_TOKEN_WINDOW must be positive when UNKNOWN_TOKEN_POLICY=limit"))
+		}
+		if cfg.UnknownTokenBlockTime < 0 {
+			errs = append(errs, fmt.Errorf("UNKNOWN_TOKEN_BLOCK_TIME cannot be negative"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("UNKNOWN_TOKEN_POLICY must be 'anonymous', 'reject' or 'limit', got: %s", cfg.UnknownTokenPolicy))
+	}
+
+	if cfg.InvalidTokenMaxDistinct < 0 {
+		errs = append(errs, fmt.Errorf("INVALID_TOKEN_MAX_DISTINCT cannot be negative"))
+	}
+	if cfg.InvalidTokenMaxDistinct > 0 {
+		if cfg.InvalidTokenWindow <= 0 {
+			errs = append(errs, fmt.Errorf("INVALID_TOKEN_WINDOW must be a positive duration"))
+		}
+		if cfg.InvalidTokenBlockTime <= 0 {
+			errs = append(errs, fmt.Errorf("INVALID_TOKEN_BLOCK_TIME must be a positive duration"))
+		}
+	}
+
+	return errs
+}
+
+// validateTokenConfigSource valida a origem das configurações de token em runtime
+func validateTokenConfigSource(cfg *Config) []error {
+	var errs []error
+
+	switch cfg.TokenConfigSource {
+	case "":
+		return nil
+	case "redis":
+		if cfg.StorageBackend != "redis" {
+			errs = append(errs, fmt.Errorf("TOKEN_CONFIG_SOURCE=redis requires STORAGE_BACKEND=redis"))
+		}
+		// No modo sharded as configurações ficam em um único nó (REDIS_HOST)
+		if len(cfg.RedisShardAddrs) > 0 && cfg.RedisHost == "" {
+			errs = append(errs, fmt.Errorf("TOKEN_CONFIG_SOURCE=redis with REDIS_SHARD_ADDRS requires REDIS_HOST"))
+		}
+	case "file":
+		if cfg.TokenConfigFile == "" {
+			errs = append(errs, fmt.Errorf("TOKEN_CONFIG_FILE is required when TOKEN_CONFIG_SOURCE=file"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TOKEN_CONFIG_SOURCE must be 'redis' or 'file', got: %s", cfg.TokenConfigSource))
+	}
+
+	if cfg.TokenConfigResyncInterval <= 0 {
+		errs = append(errs, fmt.Errorf("TOKEN_CONFIG_RESYNC_INTERVAL must be a positive duration"))
+	}
+	return errs
+}
+
+// validateAuditLog valida o destino da trilha de auditoria
+func validateAuditLog(cfg *Config) []error {
+	var errs []error
+
+	switch cfg.AuditLog {
+	case "":
+		return nil
+	case "redis":
+		if cfg.StorageBackend != "redis" {
+			errs = append(errs, fmt.Errorf("AUDIT_LOG=redis requires STORAGE_BACKEND=redis"))
+		}
+		// No modo sharded o stream fica em um único nó (REDIS_HOST)
+		if len(cfg.RedisShardAddrs) > 0 && cfg.RedisHost == "" {
+			errs = append(errs, fmt.Errorf("AUDIT_LOG=redis with REDIS_SHARD_ADDRS requires REDIS_HOST"))
+		}
+		if cfg.AuditLogStream == "" {
+			errs = append(errs, fmt.Errorf("AUDIT_LOG_STREAM is required when AUDIT_LOG=redis"))
+		}
+		if cfg.AuditLogMaxLen < 0 {
+			errs = append(errs, fmt.Errorf("AUDIT_LOG_MAX_LEN must be zero (unlimited) or positive"))
+		}
+	case "file":
+		if cfg.AuditLogFile == "" {
+			errs = append(errs, fmt.Errorf("AUDIT_LOG_FILE is required when AUDIT_LOG=file"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("AUDIT_LOG must be 'redis' or 'file', got: %s", cfg.AuditLog))
+	}
+	return errs
+}
+
+// validateEvents valida os sinks de notificação de bloqueios
+// loadUpstreams lê UPSTREAM_ROUTES ("/api/*=http://api:8080,/legacy=http://legacy") e valida os upstreams
+func loadUpstreams(cfg *Config) []error {
+	var errs []error
+
+	validateURL := func(name, raw string) {
+		if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s must be an absolute http(s) URL, got: %s", name, raw))
+		}
+	}
+
+	if cfg.UpstreamURL != "" {
+		validateURL("UPSTREAM_URL", cfg.UpstreamURL)
+	}
+	for _, item := range parseList(viper.GetString("UPSTREAM_ROUTES")) {
+		path, target, found := strings.Cut(item, "=")
+		path, target = strings.TrimSpace(path), strings.TrimSpace(target)
+		if !found || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("UPSTREAM_ROUTES entries must be in the form /path=url, got: %s", item))
+			continue
+		}
+		validateURL("UPSTREAM_ROUTES target for "+path, target)
+		cfg.UpstreamRoutes = append(cfg.UpstreamRoutes, UpstreamRoute{Path: path, URL: target})
+	}
+
+	if cfg.ProxyEnabled() {
+		if cfg.UpstreamDialTimeout <= 0 {
+			errs = append(errs, fmt.Errorf("UPSTREAM_DIAL_TIMEOUT must be a positive duration"))
+		}
+		if cfg.UpstreamResponseTimeout <= 0 {
+			errs = append(errs, fmt.Errorf("UPSTREAM_RESPONSE_TIMEOUT must be a positive duration"))
+		}
+	}
+	return errs
+}
+
+// validateRLS valida o serviço gRPC do Envoy (RLS_*)
+func validateRLS(cfg *Config) []error {
+	var errs []error
+
+	if cfg.RLSPort < 0 {
+		errs = append(errs, fmt.Errorf("RLS_PORT cannot be negative"))
+	}
+	if cfg.RLSPort > 0 {
+		for _, port := range []int{cfg.ServerPort, cfg.AdminPort, cfg.MetricsPort, cfg.DecisionAPIPort, cfg.ForwardAuthPort} {
+			if cfg.RLSPort == port {
+				errs = append(errs, fmt.Errorf("RLS_PORT must be different from SERVER_PORT, ADMIN_PORT, METRICS_PORT, DECISION_API_PORT and FORWARD_AUTH_PORT"))
+				break
+			}
+		}
+		if cfg.RLSDescriptorIP == "" && cfg.RLSDescriptorToken == "" {
+			errs = append(errs, fmt.Errorf("RLS_DESCRIPTOR_IP or RLS_DESCRIPTOR_TOKEN is required when RLS_PORT is set"))
+		}
+	}
+	return errs
+}
+
+func validateEvents(cfg *Config) []error {
+	var errs []error
+
+	if cfg.EventWebhookURL != "" {
+		if u, err := url.Parse(cfg.EventWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("EVENT_WEBHOOK_URL must be an absolute http(s) URL, got: %s", cfg.EventWebhookURL))
+		}
+		if cfg.EventWebhookQueueSize <= 0 {
+			errs = append(errs, fmt.Errorf("EVENT_WEBHOOK_QUEUE_SIZE must be positive"))
+		}
+		if cfg.EventWebhookMaxAttempts <= 0 {
+			errs = append(errs, fmt.Errorf("EVENT_WEBHOOK_MAX_ATTEMPTS must be positive"))
+		}
+		if cfg.EventWebhookTimeout <= 0 {
+			errs = append(errs, fmt.Errorf("EVENT_WEBHOOK_TIMEOUT must be a positive duration"))
+		}
+		if cfg.EventWebhookBackoff <= 0 {
+			errs = append(errs, fmt.Errorf("EVENT_WEBHOOK_BACKOFF must be a positive duration"))
+		}
+	}
+
+	if cfg.EventRedisChannel != "" {
+		if cfg.StorageBackend != "redis" {
+			errs = append(errs, fmt.Errorf("EVENT_REDIS_CHANNEL requires STORAGE_BACKEND=redis"))
+		}
+		// No modo sharded o canal fica em um único nó (REDIS_HOST)
+		if len(cfg.RedisShardAddrs) > 0 && cfg.RedisHost == "" {
+			errs = append(errs, fmt.Errorf("EVENT_REDIS_CHANNEL with REDIS_SHARD_ADDRS requires REDIS_HOST"))
+		}
+	}
+	return errs
+}
+
+// GetRedisAddrs retorna os endereços Redis a serem usados pelo client
+// Se REDIS_ADDRS não foi informado, usa REDIS_HOST:REDIS_PORT
+func (c *Config) GetRedisAddrs() []string {
+	if len(c.RedisAddrs) > 0 {
+		return c.RedisAddrs
+	}
+	return []string{fmt.Sprintf("%s:%d", c.RedisHost, c.RedisPort)}
+}
+
+// parseList converte uma lista separada por vírgulas, ignorando itens vazios
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // parseInt converte string para int, retorna 0 se falhar
@@ -0,0 +1,401 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// policySchemaJSON é o JSON Schema do arquivo de política (POLICY_FILE)
+//
+//go:embed policy.schema.json
+var policySchemaJSON []byte
+
+// policySchema compila o schema uma única vez
+var policySchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(policySchemaJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("policy.schema.json", doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile("policy.schema.json")
+})
+
+// RouteConfig define um limite específico para um conjunto de rotas
+// O bucket é separado por rota (Name) e por cliente (token ou IP)
+type RouteConfig struct {
+	Name      string
+	Path      string   // Exato ou prefixo terminado em "/*"
+	Methods   []string // Vazio casa com qualquer método
+	Limit     int
+	Window    time.Duration
+	BlockTime time.Duration
+}
+
+// Matches verifica se a rota casa com o método e o path da requisição
+func (r RouteConfig) Matches(method, path string) bool {
+	if len(r.Methods) > 0 && !slices.Contains(r.Methods, method) {
+		return false
+	}
+
+	return matchPath(r.Path, path)
+}
+
+// UpstreamRoute encaminha as requisições cujo path casa com Path para URL (modo proxy reverso)
+type UpstreamRoute struct {
+	Path string // Exato ou prefixo terminado em "/*", como nas rotas da política
+	URL  string
+}
+
+// matchPath casa o path com um padrão exato ou com prefixo terminado em "/*"
+func matchPath(pattern, path string) bool {
+	if base, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return path == base || strings.HasPrefix(path, base+"/")
+	}
+	return path == pattern
+}
+
+// AccessList agrupa IPs (ou faixas CIDR) e tokens de uma lista de allow/deny
+type AccessList struct {
+	IPs    []netip.Prefix
+	Tokens []string
+}
+
+// Contains verifica se o IP ou o token estão na lista
+func (l AccessList) Contains(ip, token string) bool {
+	if token != "" && slices.Contains(l.Tokens, token) {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(ip, "[]"))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l.IPs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyError descreve um problema do arquivo de política com a posição de origem
+type PolicyError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // JSON Pointer do valor (ex: /tokens/0/limit)
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Path, e.Message)
+}
+
+// policyFile é o formato do arquivo de política (YAML ou JSON)
+type policyFile struct {
+	Version  int              `yaml:"version"`
+	Defaults policyDefaults   `yaml:"defaults"`
+	Tokens   []policyToken    `yaml:"tokens"`
+	Routes   []policyRoute    `yaml:"routes"`
+	Allow    policyAccessList `yaml:"allow"`
+	Deny     policyAccessList `yaml:"deny"`
+}
+
+type policyLimit struct {
+	Algorithm string `yaml:"algorithm"`
+	Limit     int    `yaml:"limit"`
+	Window    string `yaml:"window"`
+	BlockTime string `yaml:"block_time"`
+}
+
+type policyDefaults struct {
+	Algorithm string       `yaml:"algorithm"`
+	IP        *policyLimit `yaml:"ip"`
+}
+
+type policyToken struct {
+	Name        string `yaml:"name"`
+	Token       string `yaml:"token"`
+	policyLimit `yaml:",inline"`
+}
+
+type policyRoute struct {
+	Name        string   `yaml:"name"`
+	Path        string   `yaml:"path"`
+	Methods     []string `yaml:"methods"`
+	policyLimit `yaml:",inline"`
+}
+
+type policyAccessList struct {
+	IPs    []string `yaml:"ips"`
+	Tokens []string `yaml:"tokens"`
+}
+
+// policy é o resultado do arquivo já validado e convertido
+type policy struct {
+	IP        *TokenConfig // nil quando defaults.ip não foi definido
+	Tokens    map[string]TokenConfig
+	Routes    []RouteConfig
+	AllowList AccessList
+	DenyList  AccessList
+}
+
+// loadPolicy lê, valida (JSON Schema + regras semânticas) e converte o arquivo de política
+// Todos os problemas encontrados são retornados juntos, cada um com linha e coluna
+func loadPolicy(path string) (*policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	// YAML é superconjunto de JSON: o mesmo parser atende os dois formatos e guarda as posições
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("%s: policy file is empty", path)
+	}
+	doc := root.Content[0]
+
+	if errs := validatePolicySchema(path, doc); len(errs) > 0 {
+		return nil, joinPolicyErrors(errs)
+	}
+
+	var file policyFile
+	if err := doc.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	p, errs := convertPolicy(path, doc, &file)
+	if len(errs) > 0 {
+		return nil, joinPolicyErrors(errs)
+	}
+	return p, nil
+}
+
+// validatePolicySchema valida o documento contra o JSON Schema embutido
+func validatePolicySchema(path string, doc *yaml.Node) []*PolicyError {
+	schema, err := policySchema()
+	if err != nil {
+		return []*PolicyError{{File: path, Line: doc.Line, Column: doc.Column, Path: "/", Message: err.Error()}}
+	}
+
+	instance, err := toJSONValue(doc)
+	if err != nil {
+		return []*PolicyError{{File: path, Line: doc.Line, Column: doc.Column, Path: "/", Message: err.Error()}}
+	}
+
+	err = schema.Validate(instance)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	var errs []*PolicyError
+	for _, leaf := range leafValidationErrors(validationErr) {
+		node := nodeAt(doc, leaf.InstanceLocation)
+		errs = append(errs, &PolicyError{
+			File:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Path:    pointer(leaf.InstanceLocation),
+			Message: leaf.BasicOutput().Error.String(),
+		})
+	}
+	return errs
+}
+
+// convertPolicy converte o arquivo para os tipos de configuração, aplicando as regras
+// que o schema não expressa (durações positivas, IPs válidos, duplicidades)
+func convertPolicy(path string, doc *yaml.Node, file *policyFile) (*policy, []*PolicyError) {
+	var errs []*PolicyError
+	fail := func(message string, location ...string) {
+		node := nodeAt(doc, location)
+		errs = append(errs, &PolicyError{File: path, Line: node.Line, Column: node.Column, Path: pointer(location), Message: message})
+	}
+
+	// converte limit/window/block_time de uma regra; block_time ausente ou zero
+	// rejeita acima do limite sem bloquear a chave
+	convertLimit := func(l policyLimit, location ...string) TokenConfig {
+		cfg := TokenConfig{Limit: l.Limit}
+		if l.Window != "" {
+			if cfg.Window = parseDuration(l.Window); cfg.Window <= 0 {
+				fail("window must be a positive duration", append(location, "window")...)
+			}
+		}
+		if l.BlockTime != "" {
+			cfg.BlockTime = parseDuration(l.BlockTime)
+		}
+		return cfg
+	}
+
+	p := &policy{Tokens: make(map[string]TokenConfig)}
+
+	if file.Defaults.IP != nil {
+		ip := convertLimit(*file.Defaults.IP, "defaults", "ip")
+		p.IP = &ip
+	}
+
+	for i, token := range file.Tokens {
+		index := strconv.Itoa(i)
+		if _, exists := p.Tokens[token.Token]; exists {
+			fail("duplicate token", "tokens", index, "token")
+		}
+		p.Tokens[token.Token] = convertLimit(token.policyLimit, "tokens", index)
+	}
+
+	routeNames := make(map[string]bool)
+	for i, route := range file.Routes {
+		index := strconv.Itoa(i)
+		if routeNames[route.Name] {
+			fail("duplicate route name", "routes", index, "name")
+		}
+		routeNames[route.Name] = true
+
+		limit := convertLimit(route.policyLimit, "routes", index)
+		p.Routes = append(p.Routes, RouteConfig{
+			Name:      route.Name,
+			Path:      route.Path,
+			Methods:   route.Methods,
+			Limit:     limit.Limit,
+			Window:    limit.Window,
+			BlockTime: limit.BlockTime,
+		})
+	}
+
+	convertList := func(list policyAccessList, name string) AccessList {
+		result := AccessList{Tokens: list.Tokens}
+		for i, ip := range list.IPs {
+			prefix, err := parseIPOrPrefix(ip)
+			if err != nil {
+				fail("invalid IP or CIDR: "+ip, name, "ips", strconv.Itoa(i))
+				continue
+			}
+			result.IPs = append(result.IPs, prefix)
+		}
+		return result
+	}
+	p.AllowList = convertList(file.Allow, "allow")
+	p.DenyList = convertList(file.Deny, "deny")
+
+	return p, errs
+}
+
+// parseIPOrPrefix aceita tanto um IP isolado quanto uma faixa CIDR
+func parseIPOrPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// toJSONValue converte o nó YAML para o modelo de valores usado pelo validador
+func toJSONValue(node *yaml.Node) (any, error) {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("policy must be representable as JSON: %w", err)
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+}
+
+// leafValidationErrors retorna as causas finais de um erro de validação (as que apontam o problema)
+func leafValidationErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, leafValidationErrors(cause)...)
+	}
+	return leaves
+}
+
+// nodeAt navega pelo documento até o valor indicado
+// Se o caminho não existir (ex: propriedade obrigatória ausente), retorna o nó mais próximo
+func nodeAt(node *yaml.Node, location []string) *yaml.Node {
+	for _, token := range location {
+		next := childNode(node, token)
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return node
+}
+
+// childNode retorna o valor de uma chave (mapping) ou de um índice (sequence)
+func childNode(node *yaml.Node, token string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == token {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// pointer formata o caminho como JSON Pointer
+func pointer(location []string) string {
+	if len(location) == 0 {
+		return "/"
+	}
+	return "/" + strings.Join(location, "/")
+}
+
+// joinPolicyErrors ordena os erros pela posição no arquivo e os junta em um único erro
+func joinPolicyErrors(errs []*PolicyError) error {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+
+	joined := make([]error, len(errs))
+	for i, err := range errs {
+		joined[i] = err
+	}
+	return errors.Join(joined...)
+}
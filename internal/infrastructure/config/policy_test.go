@@ -0,0 +1,262 @@
+package config
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validPolicyYAML = `version: 1
+defaults:
+  algorithm: token_bucket
+  ip:
+    limit: 20
+    window: 1s
+    block_time: 5m
+tokens:
+  - name: partner_acme
+    token: acme-123
+    limit: 500
+    window: 1s
+    block_time: 10m
+routes:
+  - name: upload
+    path: /api/upload/*
+    methods: [POST, PUT]
+    limit: 5
+    window: 1m
+    block_time: 15m
+allow:
+  ips: [10.0.0.0/8]
+  tokens: [internal-job]
+deny:
+  ips: [203.0.113.7]
+`
+
+// writePolicy grava o conteúdo em um arquivo temporário e retorna o caminho
+func writePolicy(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_WithPolicyFile_LoadsDefaultsTokensRoutesAndLists(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("POLICY_FILE", writePolicy(t, "policy.yaml", validPolicyYAML))
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 20, cfg.IPLimit)
+	assert.Equal(t, time.Second, cfg.IPWindow)
+	assert.Equal(t, 5*time.Minute, cfg.IPBlockTime)
+	assert.Equal(t, TokenConfig{Limit: 500, Window: time.Second, BlockTime: 10 * time.Minute}, cfg.TokenConfigs["acme-123"])
+
+	route, ok := cfg.MatchRoute("POST", "/api/upload/avatar")
+	require.True(t, ok)
+	assert.Equal(t, "upload", route.Name)
+	assert.Equal(t, 5, route.Limit)
+	assert.Equal(t, time.Minute, route.Window)
+
+	assert.True(t, cfg.IsAllowed("10.1.2.3", ""))
+	assert.True(t, cfg.IsAllowed("192.168.0.1", "internal-job"))
+	assert.True(t, cfg.IsDenied("203.0.113.7", ""))
+	assert.False(t, cfg.IsDenied("203.0.113.8", ""))
+}
+
+func TestLoad_WithJSONPolicyFile_LoadsCorrectly(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("POLICY_FILE", writePolicy(t, "policy.json", `{
+  "version": 1,
+  "defaults": {"ip": {"limit": 15, "window": "2s"}},
+  "tokens": [{"token": "abc123", "limit": 100, "window": "1s"}]
+}`))
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 15, cfg.IPLimit)
+	assert.Equal(t, 2*time.Second, cfg.IPWindow)
+	assert.Equal(t, 100, cfg.TokenConfigs["abc123"].Limit)
+}
+
+func TestLoad_WithPolicyFile_EnvOverridesFile(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("POLICY_FILE", writePolicy(t, "policy.yaml", validPolicyYAML))
+	t.Setenv("IP_RATE_LIMIT", "7")
+	t.Setenv("TOKEN_PARTNER_ACME", "acme-123")
+	t.Setenv("TOKEN_PARTNER_ACME_LIMIT", "50")
+	t.Setenv("TOKEN_PARTNER_ACME_WINDOW", "1s")
+
+	// Act
+	cfg, err := Load()
+
+	// Assert - env vence; o que não foi sobrescrito vem do arquivo
+	require.NoError(t, err)
+	assert.Equal(t, 7, cfg.IPLimit)
+	assert.Equal(t, time.Second, cfg.IPWindow)
+	assert.Equal(t, 50, cfg.TokenConfigs["acme-123"].Limit)
+}
+
+func TestLoad_WithTokenNameContainingUnderscores_LoadsToken(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("IP_RATE_LIMIT", "10")
+	t.Setenv("IP_RATE_WINDOW", "1s")
+	t.Setenv("TOKEN_MOBILE_APP_V2", "mobile-key")
+	t.Setenv("TOKEN_MOBILE_APP_V2_LIMIT", "30")
+	t.Setenv("TOKEN_MOBILE_APP_V2_WINDOW", "1s")
+	t.Setenv("TOKEN_MOBILE_APP_V2_BLOCK_TIME", "2m")
+	t.Setenv("TOKEN_MOBILE_APP", "other-key")
+	t.Setenv("TOKEN_MOBILE_APP_LIMIT", "5")
+	t.Setenv("TOKEN_MOBILE_APP_WINDOW", "1s")
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, TokenConfig{Limit: 30, Window: time.Second, BlockTime: 2 * time.Minute}, cfg.TokenConfigs["mobile-key"])
+	assert.Equal(t, TokenConfig{Limit: 5, Window: time.Second}, cfg.TokenConfigs["other-key"])
+}
+
+func TestLoadPolicy_WithSchemaViolations_ReportsAllErrorsWithLines(t *testing.T) {
+	// Arrange
+	path := writePolicy(t, "policy.yaml", `version: 1
+defaults:
+  ip:
+    limit: 0
+    window: 1s
+tokens:
+  - token: abc123
+    limit: 10
+    window: soon
+routes:
+  - name: upload
+    path: /api/upload
+    limit: 5
+    window: 1m
+    burst: 10
+`)
+
+	// Act
+	_, err := loadPolicy(path)
+
+	// Assert
+	require.Error(t, err)
+
+	var policyErrs []*PolicyError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var policyErr *PolicyError
+		require.True(t, errors.As(e, &policyErr))
+		policyErrs = append(policyErrs, policyErr)
+	}
+	require.Len(t, policyErrs, 3)
+
+	assert.Equal(t, 4, policyErrs[0].Line)
+	assert.Equal(t, "/defaults/ip/limit", policyErrs[0].Path)
+	assert.Equal(t, 9, policyErrs[1].Line)
+	assert.Equal(t, "/tokens/0/window", policyErrs[1].Path)
+	assert.Equal(t, 11, policyErrs[2].Line)
+	assert.Contains(t, policyErrs[2].Message, "burst")
+	assert.Contains(t, err.Error(), path+":4:12: /defaults/ip/limit")
+}
+
+func TestLoadPolicy_WithSemanticErrors_ReportsLines(t *testing.T) {
+	// Arrange
+	path := writePolicy(t, "policy.yaml", `version: 1
+tokens:
+  - token: abc123
+    limit: 10
+    window: 1s
+  - token: abc123
+    limit: 20
+    window: 0s
+deny:
+  ips: [10.0.0.0/33]
+`)
+
+	// Act
+	_, err := loadPolicy(path)
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path+":6:12: /tokens/1/token: duplicate token")
+	assert.Contains(t, err.Error(), path+":8:13: /tokens/1/window: window must be a positive duration")
+	assert.Contains(t, err.Error(), path+":10:9: /deny/ips/0: invalid IP or CIDR")
+}
+
+func TestLoadPolicy_WithoutBlockTime_LoadsZeroBlockTime(t *testing.T) {
+	// Arrange
+	path := writePolicy(t, "policy.yaml", `version: 1
+tokens:
+  - token: omitted
+    limit: 10
+    window: 1s
+  - token: zero
+    limit: 10
+    window: 1s
+    block_time: 0s
+`)
+
+	// Act
+	p, err := loadPolicy(path)
+
+	// Assert - zero desativa o bloqueio em vez de invalidar o arquivo
+	require.NoError(t, err)
+	assert.Zero(t, p.Tokens["omitted"].BlockTime)
+	assert.Zero(t, p.Tokens["zero"].BlockTime)
+}
+
+func TestLoadPolicy_WithSyntaxError_ReturnsError(t *testing.T) {
+	path := writePolicy(t, "policy.yaml", "version: 1\ntokens: [\n")
+
+	_, err := loadPolicy(path)
+
+	assert.ErrorContains(t, err, "line")
+}
+
+func TestRouteConfig_Matches(t *testing.T) {
+	exact := RouteConfig{Path: "/login", Methods: []string{"POST"}}
+	prefix := RouteConfig{Path: "/api/upload/*"}
+
+	assert.True(t, exact.Matches("POST", "/login"))
+	assert.False(t, exact.Matches("GET", "/login"))
+	assert.False(t, exact.Matches("POST", "/login/extra"))
+	assert.True(t, prefix.Matches("GET", "/api/upload"))
+	assert.True(t, prefix.Matches("PUT", "/api/upload/a/b"))
+	assert.False(t, prefix.Matches("PUT", "/api/uploads"))
+}
+
+func TestAccessList_Contains(t *testing.T) {
+	list := AccessList{
+		IPs:    []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16"), netip.MustParsePrefix("2001:db8::/32")},
+		Tokens: []string{"abc123"},
+	}
+
+	assert.True(t, list.Contains("192.168.10.1", ""))
+	assert.True(t, list.Contains("[2001:db8::1]", ""))
+	assert.True(t, list.Contains("::ffff:192.168.0.1", ""))
+	assert.True(t, list.Contains("", "abc123"))
+	assert.False(t, list.Contains("10.0.0.1", "other"))
+	assert.False(t, list.Contains("not-an-ip", ""))
+}
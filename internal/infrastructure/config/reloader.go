@@ -0,0 +1,270 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce agrupa os vários eventos que um editor gera ao salvar um arquivo
+const reloadDebounce = 200 * time.Millisecond
+
+// envFile é o arquivo .env lido por Load
+const envFile = ".env"
+
+// ReloadStats acumula o resultado das recargas de configuração
+type ReloadStats struct {
+	Successes  uint64
+	Failures   uint64
+	LastReload time.Time // Última recarga bem-sucedida
+}
+
+// Reloader mantém a configuração atual e a recarrega sem reiniciar o processo
+//
+// A recarga acontece quando o .env ou o arquivo de política mudam (fsnotify) ou ao
+// receber SIGHUP. A nova configuração só substitui a atual (troca atômica) depois de
+// validada; em caso de erro a configuração anterior continua valendo.
+type Reloader struct {
+	current  atomic.Pointer[Config]
+	load     func() (*Config, error)
+	logger   *slog.Logger
+	logLevel *slog.LevelVar // Nível do logger da aplicação, atualizado a cada recarga (opcional)
+
+	mu         sync.Mutex // Serializa as recargas (Load usa o viper global)
+	successes  atomic.Uint64
+	failures   atomic.Uint64
+	lastReload atomic.Int64
+}
+
+// NewReloader cria o reloader a partir da configuração já carregada
+// load é a função usada nas recargas (normalmente config.Load)
+func NewReloader(initial *Config, load func() (*Config, error), logger *slog.Logger) *Reloader {
+	r := &Reloader{load: load, logger: logger}
+	r.current.Store(initial)
+	return r
+}
+
+// WithLogLevel aplica LOG_LEVEL no nível do logger a cada recarga bem-sucedida
+func (r *Reloader) WithLogLevel(level *slog.LevelVar) *Reloader {
+	r.logLevel = level
+	return r
+}
+
+// Current retorna a configuração em uso
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Stats retorna os contadores de recarga
+func (r *Reloader) Stats() ReloadStats {
+	stats := ReloadStats{
+		Successes: r.successes.Load(),
+		Failures:  r.failures.Load(),
+	}
+	if last := r.lastReload.Load(); last > 0 {
+		stats.LastReload = time.Unix(0, last)
+	}
+	return stats
+}
+
+// Reload carrega e valida a configuração e, se tudo estiver certo, troca a atual
+func (r *Reloader) Reload(trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		failures := r.failures.Add(1)
+		r.logger.Error("Configuration reload failed, keeping previous configuration",
+			"trigger", trigger, "error", err, "failures", failures)
+		return err
+	}
+
+	previous := r.current.Swap(cfg)
+	successes := r.successes.Add(1)
+	r.lastReload.Store(time.Now().UnixNano())
+	if r.logLevel != nil {
+		r.logLevel.Set(cfg.LogLevel)
+	}
+
+	r.logger.Info("Configuration reloaded",
+		"trigger", trigger,
+		"ip_limit", cfg.IPLimit,
+		"tokens_configured", len(cfg.TokenConfigs),
+		"routes_configured", len(cfg.Routes),
+		"successes", successes,
+	)
+	for _, warning := range cfg.Warnings {
+		r.logger.Warn("Configuration warning", "warning", warning)
+	}
+	if changed := restartRequiredFields(previous, cfg); len(changed) > 0 {
+		r.logger.Warn("Configuration changes that require a restart were ignored", "fields", changed)
+	}
+	return nil
+}
+
+// Watch recarrega a configuração quando o .env ou o arquivo de política mudam
+// e ao receber SIGHUP, até o contexto ser cancelado
+func (r *Reloader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	files, err := r.watchFiles(watcher)
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+		defer signal.Stop(hup)
+
+		// Debounce: várias escritas seguidas geram uma única recarga
+		pending := time.NewTimer(reloadDebounce)
+		pending.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				pending.Stop()
+				return
+			case <-hup:
+				r.Reload("sighup")
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if slices.Contains(files, filepath.Clean(event.Name)) {
+					pending.Reset(reloadDebounce)
+				}
+			case <-pending.C:
+				if r.Reload("file") == nil {
+					// O arquivo de política pode ter mudado de caminho no .env
+					if updated, err := r.watchFiles(watcher); err == nil {
+						files = updated
+					}
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Error("Config watcher error", "error", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchFiles observa os diretórios dos arquivos de configuração
+// Observar o diretório (e não o arquivo) cobre editores que salvam via rename
+func (r *Reloader) watchFiles(watcher *fsnotify.Watcher) ([]string, error) {
+	files := []string{filepath.Clean(envFile)}
+	if policyFile := r.Current().PolicyFile; policyFile != "" {
+		files = append(files, filepath.Clean(policyFile))
+	}
+
+	for _, file := range files {
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			return nil, fmt.Errorf("failed to watch %s: %w", file, err)
+		}
+	}
+	return files, nil
+}
+
+// restartRequiredFields lista as configurações alteradas que só valem após reiniciar
+// (portas, storage e conexões são criados apenas na inicialização)
+func restartRequiredFields(previous, next *Config) []string {
+	var changed []string
+	if previous.ServerPort != next.ServerPort {
+		changed = append(changed, "SERVER_PORT")
+	}
+	if previous.AdminPort != next.AdminPort || previous.AdminToken != next.AdminToken {
+		changed = append(changed, "ADMIN_PORT/ADMIN_TOKEN")
+	}
+	if previous.MetricsPort != next.MetricsPort || previous.MetricsBlockedKeysInterval != next.MetricsBlockedKeysInterval {
+		changed = append(changed, "METRICS_PORT/METRICS_BLOCKED_KEYS_INTERVAL")
+	}
+	if previous.DecisionAPIPort != next.DecisionAPIPort || previous.DecisionAPIToken != next.DecisionAPIToken {
+		changed = append(changed, "DECISION_API_PORT/DECISION_API_TOKEN")
+	}
+	if previous.ForwardAuthPort != next.ForwardAuthPort {
+		changed = append(changed, "FORWARD_AUTH_PORT")
+	}
+	if previous.TracesExporter != next.TracesExporter {
+		changed = append(changed, "OTEL_TRACES_EXPORTER")
+	}
+	if previous.TokenKeySecret != next.TokenKeySecret {
+		changed = append(changed, "TOKEN_KEY_SECRET")
+	}
+	if previous.BlockEscalation() != next.BlockEscalation() {
+		changed = append(changed, "BLOCK_ESCALATION_*")
+	}
+	if previous.QueueMaxDelay != next.QueueMaxDelay || previous.QueueMaxPerKey != next.QueueMaxPerKey {
+		changed = append(changed, "QUEUE_*")
+	}
+	if previous.StorageBackend != next.StorageBackend {
+		changed = append(changed, "STORAGE_BACKEND")
+	}
+	if redisChanged(previous, next) {
+		changed = append(changed, "REDIS_*")
+	}
+	if previous.TokenConfigSource != next.TokenConfigSource || previous.TokenConfigFile != next.TokenConfigFile ||
+		previous.TokenConfigResyncInterval != next.TokenConfigResyncInterval {
+		changed = append(changed, "TOKEN_CONFIG_*")
+	}
+	if previous.AuditLog != next.AuditLog || previous.AuditLogFile != next.AuditLogFile ||
+		previous.AuditLogStream != next.AuditLogStream || previous.AuditLogMaxLen != next.AuditLogMaxLen {
+		changed = append(changed, "AUDIT_LOG_*")
+	}
+	if previous.EventWebhookURL != next.EventWebhookURL || previous.EventWebhookSecret != next.EventWebhookSecret ||
+		previous.EventWebhookQueueSize != next.EventWebhookQueueSize || previous.EventWebhookMaxAttempts != next.EventWebhookMaxAttempts ||
+		previous.EventWebhookTimeout != next.EventWebhookTimeout || previous.EventWebhookBackoff != next.EventWebhookBackoff ||
+		previous.EventRedisChannel != next.EventRedisChannel || previous.EventLog != next.EventLog {
+		changed = append(changed, "EVENT_*")
+	}
+	if previous.RLSPort != next.RLSPort || previous.RLSDomain != next.RLSDomain ||
+		previous.RLSDescriptorIP != next.RLSDescriptorIP || previous.RLSDescriptorToken != next.RLSDescriptorToken ||
+		previous.RLSDescriptorMethod != next.RLSDescriptorMethod || previous.RLSDescriptorPath != next.RLSDescriptorPath {
+		changed = append(changed, "RLS_*")
+	}
+	if previous.UpstreamURL != next.UpstreamURL || !slices.Equal(previous.UpstreamRoutes, next.UpstreamRoutes) ||
+		previous.UpstreamPreserveHost != next.UpstreamPreserveHost || previous.UpstreamDialTimeout != next.UpstreamDialTimeout ||
+		previous.UpstreamResponseTimeout != next.UpstreamResponseTimeout {
+		changed = append(changed, "UPSTREAM_*")
+	}
+	return changed
+}
+
+// redisChanged compara as configurações usadas para criar os clientes Redis
+// (endereços, credenciais, TLS, pool, timeouts e o monitoramento dos shards)
+func redisChanged(previous, next *Config) bool {
+	return !slices.Equal(previous.GetRedisAddrs(), next.GetRedisAddrs()) ||
+		previous.RedisDB != next.RedisDB || previous.RedisMasterName != next.RedisMasterName ||
+		previous.RedisClusterMode != next.RedisClusterMode ||
+		!slices.Equal(previous.RedisShardAddrs, next.RedisShardAddrs) ||
+		previous.RedisShardHealthInterval != next.RedisShardHealthInterval ||
+		previous.RedisShardFailureThreshold != next.RedisShardFailureThreshold ||
+		previous.RedisUsername != next.RedisUsername || previous.RedisPassword != next.RedisPassword ||
+		previous.RedisTLSEnabled != next.RedisTLSEnabled || previous.RedisTLSCAFile != next.RedisTLSCAFile ||
+		previous.RedisTLSCertFile != next.RedisTLSCertFile || previous.RedisTLSKeyFile != next.RedisTLSKeyFile ||
+		previous.RedisTLSInsecureSkipVerify != next.RedisTLSInsecureSkipVerify ||
+		previous.RedisPoolSize != next.RedisPoolSize || previous.RedisMinIdleConns != next.RedisMinIdleConns ||
+		previous.RedisDialTimeout != next.RedisDialTimeout || previous.RedisReadTimeout != next.RedisReadTimeout ||
+		previous.RedisWriteTimeout != next.RedisWriteTimeout
+}
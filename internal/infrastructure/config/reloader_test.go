@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPolicyEnv define as variáveis mínimas e aponta POLICY_FILE para um arquivo temporário
+func setupPolicyEnv(t *testing.T, limit string) string {
+	t.Helper()
+
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("REDIS_HOST", "localhost")
+	path := writePolicy(t, "policy.yaml", "version: 1\ndefaults:\n  ip:\n    limit: "+limit+"\n    window: 1s\n")
+	t.Setenv("POLICY_FILE", path)
+	return path
+}
+
+func newTestReloader(t *testing.T) *Reloader {
+	t.Helper()
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	return NewReloader(cfg, Load, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestReloader_Reload_SwapsConfig(t *testing.T) {
+	// Arrange
+	path := setupPolicyEnv(t, "10")
+	reloader := newTestReloader(t)
+	previous := reloader.Current()
+
+	require.NoError(t, os.WriteFile(path, []byte("version: 1\ndefaults:\n  ip:\n    limit: 20\n    window: 1s\n"), 0o600))
+
+	// Act
+	err := reloader.Reload("test")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 20, reloader.Current().IPLimit)
+	assert.Equal(t, 10, previous.IPLimit, "snapshots em uso não são alterados")
+	assert.Equal(t, uint64(1), reloader.Stats().Successes)
+	assert.False(t, reloader.Stats().LastReload.IsZero())
+}
+
+func TestReloader_Reload_AppliesLogLevel(t *testing.T) {
+	// Arrange
+	setupPolicyEnv(t, "10")
+	level := new(slog.LevelVar)
+	reloader := newTestReloader(t).WithLogLevel(level)
+	previous := reloader.Current()
+
+	t.Setenv("LOG_LEVEL", "debug")
+
+	// Act
+	err := reloader.Reload("test")
+
+	// Assert - a mudança vale sem reiniciar
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+	assert.Empty(t, restartRequiredFields(previous, reloader.Current()))
+}
+
+func TestReloader_Reload_InvalidConfigKeepsPrevious(t *testing.T) {
+	// Arrange
+	path := setupPolicyEnv(t, "10")
+	reloader := newTestReloader(t)
+
+	require.NoError(t, os.WriteFile(path, []byte("version: 1\ndefaults:\n  ip:\n    limit: -1\n"), 0o600))
+
+	// Act
+	err := reloader.Reload("test")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 10, reloader.Current().IPLimit)
+	assert.Equal(t, uint64(1), reloader.Stats().Failures)
+	assert.Equal(t, uint64(0), reloader.Stats().Successes)
+}
+
+func TestReloader_Watch_ReloadsWhenPolicyFileChanges(t *testing.T) {
+	// Arrange
+	path := setupPolicyEnv(t, "10")
+	reloader := newTestReloader(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, reloader.Watch(ctx))
+
+	// Act
+	require.NoError(t, os.WriteFile(path, []byte("version: 1\ndefaults:\n  ip:\n    limit: 30\n    window: 1s\n"), 0o600))
+
+	// Assert
+	assert.Eventually(t, func() bool {
+		return reloader.Current().IPLimit == 30
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestRestartRequiredFields_ReportsChangedConnections(t *testing.T) {
+	previous := &Config{ServerPort: 8080, StorageBackend: "redis", RedisHost: "a", RedisPort: 6379}
+	next := &Config{ServerPort: 9090, StorageBackend: "redis", RedisHost: "b", RedisPort: 6379, IPLimit: 50}
+
+	assert.Equal(t, []string{"SERVER_PORT", "REDIS_*"}, restartRequiredFields(previous, next))
+	assert.Empty(t, restartRequiredFields(previous, previous))
+}
+
+func TestRestartRequiredFields_ReportsRedisClientAndResyncSettings(t *testing.T) {
+	base := Config{StorageBackend: "redis", RedisHost: "a", RedisPort: 6379}
+
+	tests := map[string]struct {
+		change   func(cfg *Config)
+		expected string
+	}{
+		"TLS":                 {change: func(cfg *Config) { cfg.RedisTLSEnabled = true }, expected: "REDIS_*"},
+		"TLS CA file":         {change: func(cfg *Config) { cfg.RedisTLSCAFile = "/etc/ca.pem" }, expected: "REDIS_*"},
+		"username":            {change: func(cfg *Config) { cfg.RedisUsername = "app" }, expected: "REDIS_*"},
+		"password":            {change: func(cfg *Config) { cfg.RedisPassword = "s3cret" }, expected: "REDIS_*"},
+		"pool size":           {change: func(cfg *Config) { cfg.RedisPoolSize = 50 }, expected: "REDIS_*"},
+		"read timeout":        {change: func(cfg *Config) { cfg.RedisReadTimeout = time.Second }, expected: "REDIS_*"},
+		"shard health check":  {change: func(cfg *Config) { cfg.RedisShardHealthInterval = time.Second }, expected: "REDIS_*"},
+		"shard failures":      {change: func(cfg *Config) { cfg.RedisShardFailureThreshold = 5 }, expected: "REDIS_*"},
+		"token config resync": {change: func(cfg *Config) { cfg.TokenConfigResyncInterval = time.Minute }, expected: "TOKEN_CONFIG_*"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			previous, next := base, base
+			tt.change(&next)
+
+			assert.Equal(t, []string{tt.expected}, restartRequiredFields(&previous, &next))
+		})
+	}
+}
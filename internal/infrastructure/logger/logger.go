@@ -6,9 +6,10 @@ import (
 )
 
 // New cria um logger estruturado para produção
-func New() *slog.Logger {
+// level pode ser um *slog.LevelVar, permitindo ajustar o nível depois de carregar a configuração
+func New(level slog.Leveler) *slog.Logger {
 	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: level,
 	}))
 }
 
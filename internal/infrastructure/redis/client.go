@@ -2,34 +2,128 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-	"time"
+	"os"
+	"strings"
 
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/infrastructure/config"
 	"github.com/redis/go-redis/v9"
 )
 
 // NewClient cria e testa conexão com Redis
-func NewClient(cfg *config.Config) (*redis.Client, error) {
-	addr := fmt.Sprintf("%s:%d", cfg.RedisHost, cfg.RedisPort)
-
-	client := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     cfg.RedisPassword,
-		DB:           cfg.RedisDB,
-		DialTimeout:  5 * time.Second,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
-		PoolSize:     10,
-	})
-
-	// Testa conexão com timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+//
+// O tipo de client é escolhido a partir da configuração:
+// - REDIS_MASTER_NAME definido: Sentinel (failover), REDIS_ADDRS lista os sentinels
+// - REDIS_ADDRS com 2+ nós ou REDIS_CLUSTER_MODE=true: Redis Cluster
+// - Caso contrário: nó único (REDIS_HOST:REDIS_PORT)
+func NewClient(cfg *config.Config) (redis.UniversalClient, error) {
+	addrs := cfg.GetRedisAddrs()
+
+	opts, err := newUniversalOptions(cfg, addrs)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to redis at %s: %w", addr, err)
+	client := redis.NewUniversalClient(opts)
+	if err := ping(cfg, client); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", strings.Join(addrs, ","), err)
 	}
 
 	return client, nil
 }
+
+// NewShardClients cria um client standalone para cada nó de REDIS_SHARD_ADDRS
+// Cada nó é um Redis independente (sem cluster); a distribuição é feita pelo storage sharded
+func NewShardClients(cfg *config.Config) (map[string]*redis.Client, error) {
+	clients := make(map[string]*redis.Client, len(cfg.RedisShardAddrs))
+
+	for _, addr := range cfg.RedisShardAddrs {
+		opts, err := newUniversalOptions(cfg, []string{addr})
+		if err != nil {
+			return nil, err
+		}
+
+		client := redis.NewClient(opts.Simple())
+		if err := ping(cfg, client); err != nil {
+			client.Close()
+			for _, c := range clients {
+				c.Close()
+			}
+			return nil, fmt.Errorf("failed to connect to redis shard at %s: %w", addr, err)
+		}
+		clients[addr] = client
+	}
+
+	return clients, nil
+}
+
+// newUniversalOptions monta as opções de conexão comuns a todos os modos
+func newUniversalOptions(cfg *config.Config, addrs []string) (*redis.UniversalOptions, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redis.UniversalOptions{
+		Addrs:         addrs,
+		MasterName:    cfg.RedisMasterName,
+		IsClusterMode: cfg.RedisClusterMode,
+		Username:      cfg.RedisUsername,
+		Password:      cfg.RedisPassword,
+		DB:            cfg.RedisDB,
+		TLSConfig:     tlsConfig,
+		DialTimeout:   cfg.RedisDialTimeout,
+		ReadTimeout:   cfg.RedisReadTimeout,
+		WriteTimeout:  cfg.RedisWriteTimeout,
+		PoolSize:      cfg.RedisPoolSize,
+		MinIdleConns:  cfg.RedisMinIdleConns,
+	}, nil
+}
+
+// ping testa a conexão com timeout
+func ping(cfg *config.Config, client redis.UniversalClient) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.RedisDialTimeout)
+	defer cancel()
+
+	return client.Ping(ctx).Err()
+}
+
+// newTLSConfig monta a configuração TLS a partir do config
+// Retorna nil quando REDIS_TLS_ENABLED=false (conexão sem TLS)
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.RedisTLSEnabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.RedisTLSInsecureSkipVerify, //nolint:gosec // opt-in para ambiente local
+	}
+
+	// CA customizada (ex: Redis gerenciado com CA privada)
+	if cfg.RedisTLSCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.RedisTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in redis CA file %s", cfg.RedisTLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// Certificado de cliente (mTLS)
+	if cfg.RedisTLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.RedisTLSCertFile, cfg.RedisTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
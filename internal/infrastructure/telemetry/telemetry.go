@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporters suportados em OTEL_TRACES_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// defaultServiceName é usado quando OTEL_SERVICE_NAME não está definido
+const defaultServiceName = "rate-limiter"
+
+// Shutdown envia os spans pendentes e libera o exporter
+type Shutdown func(ctx context.Context) error
+
+// Setup configura o TracerProvider global e a propagação W3C (traceparent/tracestate e baggage)
+//
+// exporter é "none" (apenas propaga o contexto), "stdout" (spans em JSON em stdout)
+// ou "otlp" (OTLP/HTTP; endpoint, headers e TLS pelas variáveis OTEL_EXPORTER_OTLP_*).
+// A amostragem segue OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG.
+func Setup(ctx context.Context, exporter string, stdout io.Writer) (Shutdown, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(stdout))
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s traces exporter: %w", exporter, err)
+	}
+
+	// OTEL_SERVICE_NAME e OTEL_RESOURCE_ATTRIBUTES sobrescrevem o nome padrão
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(defaultServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
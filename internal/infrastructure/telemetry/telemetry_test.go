@@ -0,0 +1,45 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_WithStdoutExporter_WritesSpansOnShutdown(t *testing.T) {
+	// Arrange
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	var out bytes.Buffer
+
+	shutdown, err := Setup(context.Background(), ExporterStdout, &out)
+	require.NoError(t, err)
+
+	// Act
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	// Assert
+	assert.Contains(t, out.String(), `"Name":"test-span"`)
+	assert.Contains(t, out.String(), defaultServiceName)
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+}
+
+func TestSetup_WithNoneExporter_OnlyConfiguresPropagation(t *testing.T) {
+	shutdown, err := Setup(context.Background(), ExporterNone, nil)
+
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+}
+
+func TestSetup_WithUnknownExporter_ReturnsError(t *testing.T) {
+	_, err := Setup(context.Background(), "zipkin", nil)
+
+	assert.ErrorContains(t, err, "unknown traces exporter")
+}
@@ -0,0 +1,134 @@
+package tokenconfig
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// watchRetryDelay é o intervalo entre tentativas de reinscrição quando o Watch falha
+const watchRetryDelay = time.Second
+
+// Cache mantém uma visão local das configurações de token guardadas no store
+//
+// As consultas (hot path do middleware) leem apenas o mapa em memória, trocado
+// atomicamente a cada recarga. O cache é recarregado quando o store notifica uma
+// alteração (pub/sub ou fsnotify) e, como fallback, a cada resyncInterval.
+type Cache struct {
+	store          repository.TokenConfigStore
+	resyncInterval time.Duration
+	logger         *slog.Logger
+	hasher         entity.TokenHasher
+	tokens         atomic.Pointer[map[string]entity.TokenConfig] // Indexado por TokenHasher.StoredToken
+}
+
+// NewCache cria o cache vazio; use Start para carregar e acompanhar alterações
+func NewCache(store repository.TokenConfigStore, resyncInterval time.Duration, logger *slog.Logger) *Cache {
+	c := &Cache{
+		store:          store,
+		resyncInterval: resyncInterval,
+		logger:         logger,
+	}
+	empty := make(map[string]entity.TokenConfig)
+	c.tokens.Store(&empty)
+	return c
+}
+
+// WithTokenHasher indexa o cache pelo digest HMAC, o mesmo que o store grava no lugar do token
+// Deve ser chamado antes de Start
+func (c *Cache) WithTokenHasher(hasher entity.TokenHasher) *Cache {
+	c.hasher = hasher
+	return c
+}
+
+// Start faz a carga inicial e inicia a escuta de alterações até o contexto ser cancelado
+func (c *Cache) Start(ctx context.Context) error {
+	if err := c.Refresh(ctx); err != nil {
+		return err
+	}
+
+	go c.watch(ctx)
+	go c.resync(ctx)
+	return nil
+}
+
+// Refresh recarrega todas as configurações do store e troca a visão local
+// Em caso de erro a visão anterior é mantida
+func (c *Cache) Refresh(ctx context.Context) error {
+	configs, err := c.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load token configs: %w", err)
+	}
+
+	tokens := make(map[string]entity.TokenConfig, len(configs))
+	for _, cfg := range configs {
+		// Stores sem hash (ou campos ainda não migrados) retornam o token em texto puro
+		tokens[c.hasher.StoredToken(cfg.Token)] = cfg
+	}
+	c.tokens.Store(&tokens)
+	return nil
+}
+
+// Lookup retorna a configuração de um token a partir da visão local
+// token é a API key enviada pelo cliente: sempre recebe o HMAC, mesmo que já pareça um digest
+func (c *Cache) Lookup(token string) (entity.TokenConfig, bool) {
+	cfg, exists := (*c.tokens.Load())[c.hasher.HashToken(token)]
+	return cfg, exists
+}
+
+// Len retorna quantos tokens estão na visão local
+func (c *Cache) Len() int {
+	return len(*c.tokens.Load())
+}
+
+// watch recarrega o cache a cada notificação do store, reinscrevendo-se após falhas
+func (c *Cache) watch(ctx context.Context) {
+	for {
+		err := c.store.Watch(ctx, func() {
+			if err := c.Refresh(ctx); err != nil {
+				c.logger.Warn("Failed to refresh token configs", "error", err)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("Token config watch interrupted, retrying", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryDelay):
+		}
+
+		// Alterações podem ter sido perdidas enquanto a inscrição estava fora
+		if err := c.Refresh(ctx); err != nil {
+			c.logger.Warn("Failed to refresh token configs", "error", err)
+		}
+	}
+}
+
+// resync recarrega o cache periodicamente, cobrindo notificações perdidas
+func (c *Cache) resync(ctx context.Context) {
+	if c.resyncInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.resyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				c.logger.Warn("Failed to resync token configs", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package tokenconfig
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// fakeStore é um TokenConfigStore em memória que notifica os watchers a cada alteração
+type fakeStore struct {
+	mu       sync.Mutex
+	configs  map[string]entity.TokenConfig
+	watchers []func()
+	listErr  error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{configs: make(map[string]entity.TokenConfig)}
+}
+
+func (s *fakeStore) Get(ctx context.Context, token string) (*entity.TokenConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg, exists := s.configs[token]
+	if !exists {
+		return nil, repository.ErrTokenConfigNotFound
+	}
+	return &cfg, nil
+}
+
+func (s *fakeStore) List(ctx context.Context) ([]entity.TokenConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	configs := make([]entity.TokenConfig, 0, len(s.configs))
+	for _, cfg := range s.configs {
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+func (s *fakeStore) Save(ctx context.Context, cfg entity.TokenConfig) error {
+	s.mu.Lock()
+	s.configs[cfg.Token] = cfg
+	watchers := append([]func(){}, s.watchers...)
+	s.mu.Unlock()
+
+	for _, notify := range watchers {
+		notify()
+	}
+	return nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, token string) error {
+	s.mu.Lock()
+	delete(s.configs, token)
+	watchers := append([]func(){}, s.watchers...)
+	s.mu.Unlock()
+
+	for _, notify := range watchers {
+		notify()
+	}
+	return nil
+}
+
+func (s *fakeStore) Watch(ctx context.Context, onChange func()) error {
+	s.mu.Lock()
+	s.watchers = append(s.watchers, onChange)
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestCache_Start_LoadsInitialConfigs(t *testing.T) {
+	// Arrange
+	store := newFakeStore()
+	store.configs["abc123"] = entity.TokenConfig{Token: "abc123", Limit: 100, Window: time.Second}
+	cache := NewCache(store, time.Minute, newTestLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Act
+	require.NoError(t, cache.Start(ctx))
+
+	// Assert
+	cfg, exists := cache.Lookup("abc123")
+	assert.True(t, exists)
+	assert.Equal(t, 100, cfg.Limit)
+
+	_, exists = cache.Lookup("unknown")
+	assert.False(t, exists)
+}
+
+func TestCache_WithTokenHasher_LooksUpByDigest(t *testing.T) {
+	// Arrange - um campo já com digest e um legado em texto puro
+	hasher := entity.NewTokenHasher("0123456789abcdef0123456789abcdef")
+	store := newFakeStore()
+	store.configs["digest"] = entity.TokenConfig{Token: hasher.Digest("abc123"), Limit: 100, Window: time.Second}
+	store.configs["legacy"] = entity.TokenConfig{Token: "xyz789", Limit: 50, Window: time.Second}
+	cache := NewCache(store, time.Minute, newTestLogger()).WithTokenHasher(hasher)
+
+	// Act
+	require.NoError(t, cache.Refresh(context.Background()))
+
+	// Assert
+	cfg, exists := cache.Lookup("abc123")
+	assert.True(t, exists)
+	assert.Equal(t, 100, cfg.Limit)
+	cfg, exists = cache.Lookup("xyz789")
+	assert.True(t, exists)
+	assert.Equal(t, 50, cfg.Limit)
+	_, exists = cache.Lookup(hasher.Digest("unknown"))
+	assert.False(t, exists)
+	_, exists = cache.Lookup(hasher.Digest("abc123"))
+	assert.False(t, exists, "o digest não funciona como credencial")
+}
+
+func TestCache_PicksUpChangesFromWatch(t *testing.T) {
+	// Arrange
+	store := newFakeStore()
+	cache := NewCache(store, time.Minute, newTestLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, cache.Start(ctx))
+
+	// Act - outra instância altera o store
+	assert.Eventually(t, func() bool {
+		require.NoError(t, store.Save(ctx, entity.TokenConfig{Token: "xyz789", Limit: 50, Window: time.Second}))
+		_, exists := cache.Lookup("xyz789")
+		return exists
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, store.Delete(ctx, "xyz789"))
+
+	// Assert
+	_, exists := cache.Lookup("xyz789")
+	assert.False(t, exists)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCache_Refresh_KeepsPreviousViewOnError(t *testing.T) {
+	// Arrange
+	store := newFakeStore()
+	store.configs["abc123"] = entity.TokenConfig{Token: "abc123", Limit: 100, Window: time.Second}
+	cache := NewCache(store, time.Minute, newTestLogger())
+	require.NoError(t, cache.Refresh(context.Background()))
+
+	// Act
+	store.listErr = errors.New("redis down")
+	err := cache.Refresh(context.Background())
+
+	// Assert
+	assert.Error(t, err)
+	_, exists := cache.Lookup("abc123")
+	assert.True(t, exists)
+}
@@ -9,10 +9,33 @@ import (
 
 // Input represents the input data for rate limit checking (DTO - Data Transfer Object)
 type Input struct {
-	Key       entity.LimiterKey
-	Limit     int
-	Window    time.Duration
+	Key    entity.LimiterKey
+	Limit  int
+	Window time.Duration
+
+	// BlockTime is how long the key stays blocked after exceeding the limit.
+	// Zero disables blocking: requests over the limit are rejected until the bucket refills.
 	BlockTime time.Duration
+
+	// Policy identifies the rule that produced the limits (PolicyIP, PolicyToken, PolicyUnknownToken or RoutePolicy).
+	// It is informational only (e.g. metrics labels) and does not affect the check.
+	Policy string
+
+	// Cost is the number of tokens the request consumes (weighted requests).
+	// Zero means a regular request, which costs one token.
+	Cost int
+}
+
+// Policy names reported in Input.Policy
+const (
+	PolicyIP           = "ip"
+	PolicyToken        = "token"
+	PolicyUnknownToken = "unknown_token" // API key that matches no configured token, limited by IP
+)
+
+// RoutePolicy returns the policy name of a route-specific limit
+func RoutePolicy(route string) string {
+	return "route:" + route
 }
 
 // Validate validates the input data following Single Responsibility Principle
@@ -29,5 +52,28 @@ func (i Input) Validate() error {
 	if i.BlockTime < 0 {
 		return errors.New("block time cannot be negative")
 	}
+	if i.Cost < 0 {
+		return errors.New("cost cannot be negative")
+	}
+	if i.Cost > i.Limit {
+		return errors.New("cost cannot exceed the limit")
+	}
 	return nil
 }
+
+// RefillDuration returns how long the bucket takes to refill the given number of tokens
+func (i Input) RefillDuration(tokens float64) time.Duration {
+	if tokens <= 0 || i.Limit <= 0 || i.Window <= 0 {
+		return 0
+	}
+	refillRate := float64(i.Limit) / i.Window.Seconds()
+	return time.Duration(tokens / refillRate * float64(time.Second))
+}
+
+// TokenCost returns the number of tokens consumed by the request (at least one)
+func (i Input) TokenCost() int {
+	if i.Cost <= 0 {
+		return 1
+	}
+	return i.Cost
+}
@@ -71,3 +71,39 @@ func TestInputValidate_WithNegativeBlockTime(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "block time cannot be negative")
 }
+
+func TestInputValidate_WithInvalidCost(t *testing.T) {
+	tests := map[string]struct {
+		cost     int
+		expected string
+	}{
+		"negative":        {cost: -1, expected: "cost cannot be negative"},
+		"above the limit": {cost: 11, expected: "cost cannot exceed the limit"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			input := Input{
+				Key:    entity.NewIPKey("192.168.1.1"),
+				Limit:  10,
+				Window: time.Second,
+				Cost:   tt.cost,
+			}
+
+			err := input.Validate()
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestInputTokenCost_DefaultsToOne(t *testing.T) {
+	assert.Equal(t, 1, Input{}.TokenCost())
+	assert.Equal(t, 4, Input{Cost: 4}.TokenCost())
+}
+
+func TestInputRefillDuration(t *testing.T) {
+	input := Input{Limit: 10, Window: 2 * time.Second}
+
+	assert.Equal(t, time.Second, input.RefillDuration(5))
+	assert.Equal(t, time.Duration(0), input.RefillDuration(-1))
+}
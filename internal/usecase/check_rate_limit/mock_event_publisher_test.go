@@ -0,0 +1,19 @@
+package check_rate_limit
+
+import (
+	"context"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEventPublisher is a mock implementation of repository.EventPublisher
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+// Publish mocks the Publish method from EventPublisher interface
+func (m *MockEventPublisher) Publish(ctx context.Context, event entity.AuditEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
@@ -15,17 +15,38 @@ type MockStorage struct {
 }
 
 // CheckAndConsume mocks the CheckAndConsume method from Storage interface
-func (m *MockStorage) CheckAndConsume(ctx context.Context, key entity.LimiterKey, limit int, window time.Duration) (*repository.CheckResult, error) {
-	args := m.Called(ctx, key, limit, window)
+func (m *MockStorage) CheckAndConsume(ctx context.Context, key entity.LimiterKey, limit int, window time.Duration, cost int) (*repository.CheckResult, error) {
+	args := m.Called(ctx, key, limit, window, cost)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*repository.CheckResult), args.Error(1)
 }
 
+// Reserve mocks the Reserve method from Storage interface
+func (m *MockStorage) Reserve(ctx context.Context, key entity.LimiterKey, limit int, window time.Duration, cost int, maxWait time.Duration) (*repository.ReserveResult, error) {
+	args := m.Called(ctx, key, limit, window, cost, maxWait)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ReserveResult), args.Error(1)
+}
+
+// CountDistinct mocks the CountDistinct method from Storage interface
+func (m *MockStorage) CountDistinct(ctx context.Context, key entity.LimiterKey, member string, window time.Duration) (int, error) {
+	args := m.Called(ctx, key, member, window)
+	return args.Int(0), args.Error(1)
+}
+
+// Refund mocks the Refund method from Storage interface
+func (m *MockStorage) Refund(ctx context.Context, key entity.LimiterKey, limit int, window time.Duration, cost int) error {
+	args := m.Called(ctx, key, limit, window, cost)
+	return args.Error(0)
+}
+
 // SetBlock mocks the SetBlock method from Storage interface
-func (m *MockStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration) error {
-	args := m.Called(ctx, key, blockTime)
+func (m *MockStorage) SetBlock(ctx context.Context, key entity.LimiterKey, blockTime time.Duration, info entity.BlockInfo) error {
+	args := m.Called(ctx, key, blockTime, info)
 	return args.Error(0)
 }
 
@@ -40,3 +61,45 @@ func (m *MockStorage) Close() error {
 	args := m.Called()
 	return args.Error(0)
 }
+
+// GetKeyState mocks the GetKeyState method from Storage interface
+func (m *MockStorage) GetKeyState(ctx context.Context, key entity.LimiterKey) (*repository.KeyState, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.KeyState), args.Error(1)
+}
+
+// ResetBucket mocks the ResetBucket method from Storage interface
+func (m *MockStorage) ResetBucket(ctx context.Context, key entity.LimiterKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// Unblock mocks the Unblock method from Storage interface
+func (m *MockStorage) Unblock(ctx context.Context, key entity.LimiterKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// ListBlocked mocks the ListBlocked method from Storage interface
+func (m *MockStorage) ListBlocked(ctx context.Context) ([]repository.BlockedKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.BlockedKey), args.Error(1)
+}
+
+// AddStrike mocks the AddStrike method from Storage interface
+func (m *MockStorage) AddStrike(ctx context.Context, key entity.LimiterKey, decay time.Duration) (int, error) {
+	args := m.Called(ctx, key, decay)
+	return args.Int(0), args.Error(1)
+}
+
+// ResetStrikes mocks the ResetStrikes method from Storage interface
+func (m *MockStorage) ResetStrikes(ctx context.Context, key entity.LimiterKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
@@ -1,5 +1,7 @@
 package check_rate_limit
 
+import "time"
+
 // Output represents the result of a rate limit check operation
 type Output struct {
 	// Allowed indicates whether the request should be permitted to proceed.
@@ -23,4 +25,35 @@ type Output struct {
 	// When rate limit is exceeded, this will contain the standardized message:
 	// "you have reached the maximum number of requests or actions allowed within a certain time frame"
 	Message string
+
+	// BlockTime is the duration of the block applied by this request (only when it was just blocked).
+	// With block escalation it grows with Strikes.
+	BlockTime time.Duration
+
+	// Strikes is the number of consecutive violations of the key, including this one.
+	// It is only reported when the key was just blocked and block escalation is enabled.
+	Strikes int
+
+	// QueueDelay is how long the request was held waiting for its token (see Queue).
+	// Zero when the token was available right away or queueing is disabled.
+	QueueDelay time.Duration
+}
+
+// Decision values returned by Output.Decision
+const (
+	DecisionAllowed  = "allowed"  // The request was allowed
+	DecisionRejected = "rejected" // The limit was exceeded by this request and the key was blocked
+	DecisionBlocked  = "blocked"  // The key was already blocked
+)
+
+// Decision classifies the result for observability (metrics labels, span attributes)
+func (o *Output) Decision() string {
+	switch {
+	case o.Allowed:
+		return DecisionAllowed
+	case o.Blocked:
+		return DecisionBlocked
+	default:
+		return DecisionRejected
+	}
 }
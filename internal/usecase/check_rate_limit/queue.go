@@ -0,0 +1,104 @@
+package check_rate_limit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Queue decorates the use case so that short bursts see latency instead of rejections.
+// A request without a token available is held for up to maxDelay when the next token arrives
+// within that time; otherwise it falls back to Execute, which rejects and blocks the key as usual.
+//
+// Fairness comes from the storage: each held request reserves its token ahead of time, so requests
+// are released in arrival order, also across instances sharing the storage. The cap on held requests
+// per key is local to the instance and bounds the goroutines a single client can park.
+type Queue struct {
+	useCase   *UseCase
+	maxDelay  time.Duration
+	maxPerKey int
+
+	mu      sync.Mutex
+	waiting map[string]int // Requests currently holding a slot, by key
+}
+
+// NewQueue creates a queue in front of the use case
+func NewQueue(useCase *UseCase, maxDelay time.Duration, maxPerKey int) *Queue {
+	return &Queue{
+		useCase:   useCase,
+		maxDelay:  maxDelay,
+		maxPerKey: maxPerKey,
+		waiting:   make(map[string]int),
+	}
+}
+
+// Execute has the same contract as UseCase.Execute, except that an allowed request may have waited
+// Output.QueueDelay for its token. When ctx is done while waiting, ctx.Err() is returned and the
+// reserved token is given back to the bucket.
+func (q *Queue) Execute(ctx context.Context, input Input) (*Output, error) {
+	key := input.Key.String()
+	if !q.acquire(key) {
+		return q.useCase.Execute(ctx, input)
+	}
+	defer q.release(key)
+
+	maxWait := q.maxDelay
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = min(maxWait, time.Until(deadline))
+	}
+
+	reservation, err := q.useCase.ReserveWithin(ctx, input, maxWait)
+	switch {
+	case errors.Is(err, ErrWaitExceedsMax):
+		// Too far ahead for the queue: reject like a regular check
+		return q.useCase.Execute(ctx, input)
+	case errors.Is(err, ErrKeyBlocked):
+		return q.useCase.createBlockedOutput(), nil
+	case err != nil:
+		return nil, err
+	}
+
+	if reservation.Delay > 0 {
+		timer := time.NewTimer(reservation.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			q.useCase.refund(ctx, input)
+			return nil, ctx.Err()
+		}
+	}
+
+	return &Output{
+		Allowed:       true,
+		CurrentTokens: reservation.CurrentTokens,
+		Limit:         reservation.Limit,
+		QueueDelay:    reservation.Delay,
+	}, nil
+}
+
+// acquire takes a slot for the key, or reports that the key already holds maxPerKey requests
+func (q *Queue) acquire(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.waiting[key] >= q.maxPerKey {
+		return false
+	}
+	q.waiting[key]++
+	return true
+}
+
+// release frees a slot taken by acquire
+func (q *Queue) release(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.waiting[key] <= 1 {
+		delete(q.waiting, key)
+		return
+	}
+	q.waiting[key]--
+}
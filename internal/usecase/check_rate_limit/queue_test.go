@@ -0,0 +1,163 @@
+package check_rate_limit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// queuedInput é uma requisição comum de um cliente por IP
+func queuedInput() Input {
+	return Input{
+		Key:       entity.NewIPKey("192.168.1.1"),
+		Limit:     10,
+		Window:    time.Second,
+		BlockTime: time.Minute,
+	}
+}
+
+func TestQueue_TokenAvailable_AllowsWithoutWaiting(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	queue := NewQueue(NewUseCase(mockStorage, discardLogger()), 200*time.Millisecond, 5)
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 10, time.Second, 1, 200*time.Millisecond).
+		Return(&repository.ReserveResult{OK: true, CurrentTokens: 9, Limit: 10}, nil)
+
+	// Act
+	output, err := queue.Execute(context.Background(), queuedInput())
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, output.Allowed)
+	assert.Zero(t, output.QueueDelay)
+	assert.Equal(t, 9.0, output.CurrentTokens)
+	mockStorage.AssertNotCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestQueue_TokenWithinMaxDelay_HoldsRequest(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	queue := NewQueue(NewUseCase(mockStorage, discardLogger()), 200*time.Millisecond, 5)
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 10, time.Second, 1, 200*time.Millisecond).
+		Return(&repository.ReserveResult{OK: true, Delay: 50 * time.Millisecond, CurrentTokens: -0.5, Limit: 10}, nil)
+
+	// Act
+	start := time.Now()
+	output, err := queue.Execute(context.Background(), queuedInput())
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, output.Allowed)
+	assert.Equal(t, 50*time.Millisecond, output.QueueDelay)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, DecisionAllowed, output.Decision())
+}
+
+func TestQueue_TokenBeyondMaxDelay_RejectsAndBlocks(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	queue := NewQueue(NewUseCase(mockStorage, discardLogger()), 200*time.Millisecond, 5)
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 10, time.Second, 1, 200*time.Millisecond).
+		Return(&repository.ReserveResult{OK: false, Delay: time.Second, CurrentTokens: -9, Limit: 10}, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, 10, time.Second, 1).
+		Return(&repository.CheckResult{Allowed: false, CurrentTokens: -9, Limit: 10}, nil)
+	mockStorage.On("SetBlock", mock.Anything, mock.Anything, time.Minute, mock.Anything).Return(nil)
+
+	// Act
+	output, err := queue.Execute(context.Background(), queuedInput())
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, output.Allowed)
+	assert.Equal(t, DecisionRejected, output.Decision())
+	mockStorage.AssertExpectations(t)
+}
+
+func TestQueue_KeyBlocked_ReturnsBlockedOutput(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	queue := NewQueue(NewUseCase(mockStorage, discardLogger()), 200*time.Millisecond, 5)
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(true, nil)
+
+	// Act
+	output, err := queue.Execute(context.Background(), queuedInput())
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, output.Blocked)
+	assert.Equal(t, RateLimitExceededMessage, output.Message)
+}
+
+func TestQueue_FullQueue_SkipsWaiting(t *testing.T) {
+	// Arrange - um único lugar na fila, ocupado por uma requisição esperando
+	mockStorage := new(MockStorage)
+	queue := NewQueue(NewUseCase(mockStorage, discardLogger()), 200*time.Millisecond, 1)
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 10, time.Second, 1, 200*time.Millisecond).
+		Return(&repository.ReserveResult{OK: true, Delay: 100 * time.Millisecond, Limit: 10}, nil).Once()
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, 10, time.Second, 1).
+		Return(&repository.CheckResult{Allowed: false, Limit: 10}, nil)
+	mockStorage.On("SetBlock", mock.Anything, mock.Anything, time.Minute, mock.Anything).Return(nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		output, err := queue.Execute(context.Background(), queuedInput())
+		assert.NoError(t, err)
+		assert.True(t, output.Allowed)
+	}()
+	assert.Eventually(t, func() bool {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+		return queue.waiting[queuedInput().Key.String()] == 1
+	}, time.Second, time.Millisecond)
+
+	// Act
+	output, err := queue.Execute(context.Background(), queuedInput())
+	wg.Wait()
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, output.Allowed)
+	mockStorage.AssertNumberOfCalls(t, "Reserve", 1)
+	assert.Empty(t, queue.waiting)
+}
+
+func TestQueue_ContextCancelledWhileWaiting_ReturnsContextError(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	queue := NewQueue(NewUseCase(mockStorage, discardLogger()), time.Hour, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 10, time.Second, 1, time.Hour).
+		Return(&repository.ReserveResult{OK: true, Delay: 30 * time.Minute, Limit: 10}, nil)
+	mockStorage.On("Refund", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }),
+		entity.NewIPKey("192.168.1.1"), 10, time.Second, 1).Return(nil)
+
+	// Act
+	time.AfterFunc(20*time.Millisecond, cancel)
+	output, err := queue.Execute(ctx, queuedInput())
+
+	// Assert - o token reservado volta para o bucket
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, output)
+	assert.Empty(t, queue.waiting)
+	mockStorage.AssertExpectations(t)
+}
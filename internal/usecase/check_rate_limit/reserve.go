@@ -0,0 +1,128 @@
+package check_rate_limit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	// ErrKeyBlocked is returned by Reserve and Wait when the key is currently blocked
+	ErrKeyBlocked = errors.New("rate limit key is blocked")
+
+	// ErrWaitExceedsDeadline is returned by Wait when the tokens would only be available after the context deadline
+	ErrWaitExceedsDeadline = errors.New("rate limit wait would exceed context deadline")
+
+	// ErrWaitExceedsMax is returned by ReserveWithin when the tokens would only be available after maxWait
+	ErrWaitExceedsMax = errors.New("rate limit wait would exceed the maximum delay")
+)
+
+// refundTimeout bounds the storage call that gives back a cancelled reservation
+const refundTimeout = time.Second
+
+// Reservation holds tokens taken ahead of time by Reserve
+type Reservation struct {
+	// Delay is how long the caller must wait before acting on the reserved tokens.
+	// Zero means the tokens were available right away.
+	Delay time.Duration
+
+	// CurrentTokens shows the tokens left in the bucket after the reservation.
+	// It is negative while the bucket is paying off reservations made in advance.
+	CurrentTokens float64
+
+	// Limit is the configured maximum number of requests allowed per time window.
+	Limit int
+}
+
+// Reserve takes the input cost from the bucket and returns how long the caller must wait before using it.
+// Unlike Execute it never rejects nor blocks the key: the tokens are always reserved, leaving the bucket
+// in debt when needed, so later callers wait their turn (the same semantics as golang.org/x/time/rate,
+// shared through the storage by every instance).
+//
+// Reserve hands the waiting to the caller, so it cannot tell a caller that gave up from one that used
+// the tokens: they stay spent. Wait performs the same reservation and refunds it when the context is
+// cancelled mid-wait.
+func (uc *UseCase) Reserve(ctx context.Context, input Input) (*Reservation, error) {
+	return uc.ReserveWithin(ctx, input, time.Duration(math.MaxInt64))
+}
+
+// ReserveWithin is Reserve bounded by maxWait: when the tokens would only be available later,
+// nothing is reserved and ErrWaitExceedsMax is returned, so the caller can reject instead.
+func (uc *UseCase) ReserveWithin(ctx context.Context, input Input, maxWait time.Duration) (*Reservation, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Blocks applied by Execute or by an admin still apply to background workers
+	blocked, err := uc.storage.IsBlocked(ctx, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrKeyBlocked
+	}
+
+	result, err := uc.storage.Reserve(ctx, input.Key, input.Limit, input.Window, input.TokenCost(), maxWait)
+	if err != nil {
+		return nil, err
+	}
+	if !result.OK {
+		return nil, ErrWaitExceedsMax
+	}
+
+	return &Reservation{
+		Delay:         result.Delay,
+		CurrentTokens: result.CurrentTokens,
+		Limit:         result.Limit,
+	}, nil
+}
+
+// Wait blocks until the input cost is available or the context is done, whichever comes first.
+// When the context deadline is earlier than the time the tokens become available it fails right away
+// with ErrWaitExceedsDeadline, without reserving anything.
+//
+// Tokens reserved before the context is cancelled mid-wait are given back to the bucket.
+func (uc *UseCase) Wait(ctx context.Context, input Input) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = time.Until(deadline)
+	}
+
+	reservation, err := uc.ReserveWithin(ctx, input, maxWait)
+	if errors.Is(err, ErrWaitExceedsMax) {
+		return ErrWaitExceedsDeadline
+	}
+	if err != nil {
+		return err
+	}
+	if reservation.Delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(reservation.Delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		uc.refund(ctx, input)
+		return ctx.Err()
+	}
+}
+
+// refund gives back the tokens of a reservation whose caller stopped waiting, so they are not lost.
+// ctx is already done when this runs, so the storage call is detached from its cancellation.
+func (uc *UseCase) refund(ctx context.Context, input Input) {
+	refundCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refundTimeout)
+	defer cancel()
+
+	if err := uc.storage.Refund(refundCtx, input.Key, input.Limit, input.Window, input.TokenCost()); err != nil {
+		uc.logger.WarnContext(ctx, "Failed to refund cancelled reservation", "key", input.Key, "error", err)
+	}
+}
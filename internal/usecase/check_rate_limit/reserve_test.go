@@ -0,0 +1,149 @@
+package check_rate_limit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// workerInput é a entrada de um worker em background (2 tokens por segundo)
+func workerInput() Input {
+	return Input{
+		Key:    entity.NewOutboundKey("api.example.com"),
+		Limit:  2,
+		Window: time.Second,
+	}
+}
+
+func TestReserve_ReturnsDelayWithoutBlocking(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 2, time.Second, 1, mock.Anything).
+		Return(&repository.ReserveResult{OK: true, Delay: 500 * time.Millisecond, CurrentTokens: -1, Limit: 2}, nil)
+
+	// Act
+	reservation, err := useCase.Reserve(context.Background(), workerInput())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, reservation.Delay)
+	assert.Equal(t, -1.0, reservation.CurrentTokens)
+	assert.Equal(t, 2, reservation.Limit)
+	mockStorage.AssertNotCalled(t, "SetBlock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReserve_WhenBlocked_ReturnsErrKeyBlocked(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(true, nil)
+
+	// Act
+	reservation, err := useCase.Reserve(context.Background(), workerInput())
+
+	// Assert
+	assert.ErrorIs(t, err, ErrKeyBlocked)
+	assert.Nil(t, reservation)
+	mockStorage.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReserve_CostAboveLimit_ReturnsError(t *testing.T) {
+	// Arrange
+	useCase := NewUseCase(new(MockStorage), discardLogger())
+	input := workerInput()
+	input.Cost = 3
+
+	// Act
+	_, err := useCase.Reserve(context.Background(), input)
+
+	// Assert
+	assert.EqualError(t, err, "cost cannot exceed the limit")
+}
+
+func TestWait_SleepsForTheReservedDelay(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 2, time.Second, 1, mock.Anything).
+		Return(&repository.ReserveResult{OK: true, Delay: 50 * time.Millisecond, Limit: 2}, nil)
+
+	// Act
+	start := time.Now()
+	err := useCase.Wait(context.Background(), workerInput())
+
+	// Assert
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestWait_PassesContextDeadlineAsMaxWait(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 2, time.Second, 1,
+		mock.MatchedBy(func(maxWait time.Duration) bool { return maxWait > 0 && maxWait <= 100*time.Millisecond })).
+		Return(&repository.ReserveResult{OK: false, Delay: time.Second, Limit: 2}, nil)
+
+	// Act
+	start := time.Now()
+	err := useCase.Wait(ctx, workerInput())
+
+	// Assert - falha na hora, sem esperar o deadline
+	assert.ErrorIs(t, err, ErrWaitExceedsDeadline)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestWait_ContextCancelled_ReturnsContextError(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 2, time.Second, 1, mock.Anything).
+		Return(&repository.ReserveResult{OK: true, Delay: time.Hour, Limit: 2}, nil)
+	mockStorage.On("Refund", mock.Anything, entity.NewOutboundKey("api.example.com"), 2, time.Second, 1).
+		Return(errors.New("connection refused"))
+
+	// Act
+	time.AfterFunc(20*time.Millisecond, cancel)
+	err := useCase.Wait(ctx, workerInput())
+
+	// Assert - falha na devolução é apenas registrada
+	assert.ErrorIs(t, err, context.Canceled)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestWait_StorageError_PropagatesError(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+	storageErr := errors.New("redis unavailable")
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("Reserve", mock.Anything, mock.Anything, 2, time.Second, 1, mock.Anything).Return(nil, storageErr)
+
+	// Act
+	err := useCase.Wait(context.Background(), workerInput())
+
+	// Assert
+	assert.ErrorIs(t, err, storageErr)
+}
@@ -2,7 +2,10 @@ package check_rate_limit
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
 	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
 )
 
@@ -11,24 +14,41 @@ const RateLimitExceededMessage = "you have reached the maximum number of request
 
 // UseCase implements the business logic for rate limit checking
 type UseCase struct {
-	storage repository.Storage
+	storage    repository.Storage
+	logger     *slog.Logger
+	escalation entity.BlockEscalation
+	publisher  repository.EventPublisher
 }
 
 // NewUseCase creates a new instance using dependency injection
-func NewUseCase(storage repository.Storage) *UseCase {
-	return &UseCase{storage: storage}
+// The logger records block transitions; per-request decisions are logged by the caller
+func NewUseCase(storage repository.Storage, logger *slog.Logger) *UseCase {
+	return &UseCase{storage: storage, logger: logger}
+}
+
+// WithBlockEscalation enables progressive blocks: repeat offenders are blocked for longer each time
+func (uc *UseCase) WithBlockEscalation(escalation entity.BlockEscalation) *UseCase {
+	uc.escalation = escalation
+	return uc
+}
+
+// WithEventPublisher notifies the publisher every time a key gets blocked
+func (uc *UseCase) WithEventPublisher(publisher repository.EventPublisher) *UseCase {
+	uc.publisher = publisher
+	return uc
 }
 
 // Execute is the main command that checks if a request should be allowed based on rate limiting rules.
 // It follows the Command Pattern and implements the business logic for rate limit verification.
 //
 // The execution flow:
-// 1. Validate input parameters
-// 2. Check if the key is currently in a blocked state
-// 3. If blocked, return immediate rejection
-// 4. Otherwise, attempt to consume a token using Token Bucket algorithm
-// 5. If consumption fails, block the key and return rejection
-// 6. If consumption succeeds, return success with current state
+//  1. Validate input parameters
+//  2. Check if the key is currently in a blocked state
+//  3. If blocked, return immediate rejection
+//  4. Otherwise, attempt to consume the request cost (one token by default) using Token Bucket algorithm
+//  5. If consumption fails, block the key (longer for repeat offenders when escalation is enabled),
+//     publish the block event and return rejection; with a zero block time the request is only rejected
+//  6. If consumption succeeds, return success with current state
 func (uc *UseCase) Execute(ctx context.Context, input Input) (*Output, error) {
 	// 1. Validate input parameters (Single Responsibility Principle)
 	if err := input.Validate(); err != nil {
@@ -46,24 +66,85 @@ func (uc *UseCase) Execute(ctx context.Context, input Input) (*Output, error) {
 	}
 
 	// 3. Attempt to consume token using Token Bucket algorithm (atomic operation)
-	result, err := uc.storage.CheckAndConsume(ctx, input.Key, input.Limit, input.Window)
+	result, err := uc.storage.CheckAndConsume(ctx, input.Key, input.Limit, input.Window, input.TokenCost())
 	if err != nil {
 		return nil, err
 	}
 
 	// 4. If token consumption failed (rate limit exceeded), block the key
 	if !result.Allowed {
-		if err := uc.storage.SetBlock(ctx, input.Key, input.BlockTime); err != nil {
+		// No block time configured: reject this request only, the next one is checked against the bucket
+		if input.BlockTime <= 0 {
+			return uc.createRateLimitExceededOutput(result), nil
+		}
+
+		blockTime, strikes, err := uc.blockTimeFor(ctx, input)
+		if err != nil {
 			return nil, err
 		}
+		info := entity.BlockInfo{
+			Reason:    entity.BlockReasonRateExceeded,
+			Source:    entity.BlockSourceRateLimiter,
+			BlockedAt: time.Now(),
+			Policy:    input.Policy,
+			Limit:     input.Limit,
+			Window:    input.Window,
+			Strikes:   strikes,
+		}
+		if err := uc.storage.SetBlock(ctx, input.Key, blockTime, info); err != nil {
+			return nil, err
+		}
+		// Logged once per block period, so it does not need sampling
+		uc.logger.InfoContext(ctx, "Key blocked after exceeding the rate limit",
+			"key", input.Key,
+			"policy", input.Policy,
+			"limit", input.Limit,
+			"window", input.Window,
+			"block_time", blockTime,
+			"strikes", strikes,
+		)
+		uc.publish(ctx, entity.AuditEvent{
+			Action:   entity.AuditActionBlock,
+			Key:      input.Key,
+			Time:     info.BlockedAt,
+			Duration: blockTime,
+			Info:     info,
+		})
 
-		return uc.createRateLimitExceededOutput(result), nil
+		output := uc.createRateLimitExceededOutput(result)
+		output.BlockTime = blockTime
+		output.Strikes = strikes
+		return output, nil
 	}
 
 	// 5. Token consumption successful - request is allowed
 	return uc.createAllowedOutput(result), nil
 }
 
+// blockTimeFor records the violation and returns the block duration for it.
+// Without escalation the base block time is used and no strike is stored.
+func (uc *UseCase) blockTimeFor(ctx context.Context, input Input) (time.Duration, int, error) {
+	if !uc.escalation.Enabled() {
+		return input.BlockTime, 0, nil
+	}
+
+	strikes, err := uc.storage.AddStrike(ctx, input.Key, uc.escalation.Decay)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uc.escalation.BlockTime(input.BlockTime, strikes), strikes, nil
+}
+
+// publish notifies the event publisher, if any. A failed notification never fails the request.
+func (uc *UseCase) publish(ctx context.Context, event entity.AuditEvent) {
+	if uc.publisher == nil {
+		return
+	}
+	if err := uc.publisher.Publish(ctx, event); err != nil {
+		uc.logger.WarnContext(ctx, "Failed to publish block event", "key", event.Key, "error", err)
+	}
+}
+
 // createBlockedOutput creates an output response when the key is already blocked
 func (uc *UseCase) createBlockedOutput() *Output {
 	return &Output{
@@ -1,8 +1,11 @@
 package check_rate_limit
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -12,10 +15,15 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// discardLogger descarta os logs do use case nos testes
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
 func TestExecute_InvalidInput_ReturnsError(t *testing.T) {
 	// Arrange
 	mockStorage := new(MockStorage)
-	useCase := NewUseCase(mockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
 
 	input := Input{
 		Key:       entity.LimiterKey{Type: entity.KeyTypeIP, Value: ""}, // Invalid key
@@ -36,7 +44,7 @@ func TestExecute_InvalidInput_ReturnsError(t *testing.T) {
 func TestExecute_WhenBlocked_ReturnsBlockedOutput(t *testing.T) {
 	// Arrange
 	mockStorage := new(MockStorage)
-	useCase := NewUseCase(mockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
 
 	input := Input{
 		Key:       entity.NewIPKey("192.168.1.1"),
@@ -63,7 +71,7 @@ func TestExecute_WhenBlocked_ReturnsBlockedOutput(t *testing.T) {
 func TestExecute_WhenAllowed_ReturnsAllowedOutput(t *testing.T) {
 	// Arrange
 	mockStorage := new(MockStorage)
-	useCase := NewUseCase(mockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
 
 	input := Input{
 		Key:       entity.NewIPKey("192.168.1.1"),
@@ -79,7 +87,7 @@ func TestExecute_WhenAllowed_ReturnsAllowedOutput(t *testing.T) {
 	}
 
 	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
-	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(checkResult, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(checkResult, nil)
 
 	// Act
 	output, err := useCase.Execute(context.Background(), input)
@@ -93,13 +101,13 @@ func TestExecute_WhenAllowed_ReturnsAllowedOutput(t *testing.T) {
 	assert.Equal(t, 10, output.Limit)
 
 	mockStorage.AssertCalled(t, "IsBlocked", mock.Anything, mock.Anything)
-	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestExecute_WhenRateLimitExceeded_BlocksKey(t *testing.T) {
 	// Arrange
 	mockStorage := new(MockStorage)
-	useCase := NewUseCase(mockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
 
 	input := Input{
 		Key:       entity.NewIPKey("192.168.1.1"),
@@ -115,8 +123,13 @@ func TestExecute_WhenRateLimitExceeded_BlocksKey(t *testing.T) {
 	}
 
 	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
-	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(checkResult, nil)
-	mockStorage.On("SetBlock", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(checkResult, nil)
+	mockStorage.On("SetBlock", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(info entity.BlockInfo) bool {
+		return info.Reason == entity.BlockReasonRateExceeded &&
+			info.Source == entity.BlockSourceRateLimiter &&
+			info.Limit == 10 && info.Window == time.Second &&
+			!info.BlockedAt.IsZero()
+	})).Return(nil)
 
 	// Act
 	output, err := useCase.Execute(context.Background(), input)
@@ -129,14 +142,150 @@ func TestExecute_WhenRateLimitExceeded_BlocksKey(t *testing.T) {
 	assert.NotEmpty(t, output.Message)
 
 	mockStorage.AssertCalled(t, "IsBlocked", mock.Anything, mock.Anything)
-	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
-	mockStorage.AssertCalled(t, "SetBlock", mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertCalled(t, "SetBlock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExecute_WhenRateLimitExceeded_WithoutBlockTime_RejectsWithoutBlocking(t *testing.T) {
+	// Arrange - sem block time (ex.: UNKNOWN_TOKEN_POLICY=limit sem UNKNOWN_TOKEN_BLOCK_TIME)
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+
+	input := Input{
+		Key:    entity.NewIPKey("192.168.1.1"),
+		Limit:  10,
+		Window: time.Second,
+	}
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&repository.CheckResult{Allowed: false, Limit: 10}, nil)
+
+	// Act
+	output, err := useCase.Execute(context.Background(), input)
+
+	// Assert - rejeita apenas esta requisição, sem bloquear a chave
+	assert.NoError(t, err)
+	assert.NotNil(t, output)
+	assert.False(t, output.Allowed)
+	assert.False(t, output.Blocked)
+	mockStorage.AssertNotCalled(t, "SetBlock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "AddStrike", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExecute_WithBlockEscalation_MultipliesBlockTimePerStrike(t *testing.T) {
+	// Arrange - terceira violação dentro do decay
+	mockStorage := new(MockStorage)
+	escalation := entity.BlockEscalation{Factor: 5, MaxBlockTime: time.Hour, Decay: 24 * time.Hour}
+	useCase := NewUseCase(mockStorage, discardLogger()).WithBlockEscalation(escalation)
+
+	key := entity.NewIPKey("192.168.1.1")
+	input := Input{
+		Key:       key,
+		Limit:     10,
+		Window:    time.Second,
+		BlockTime: time.Minute,
+	}
+
+	mockStorage.On("IsBlocked", mock.Anything, key).Return(false, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, key, 10, time.Second, 1).Return(&repository.CheckResult{Allowed: false, Limit: 10}, nil)
+	mockStorage.On("AddStrike", mock.Anything, key, 24*time.Hour).Return(3, nil)
+	mockStorage.On("SetBlock", mock.Anything, key, 25*time.Minute, mock.Anything).Return(nil)
+
+	// Act
+	output, err := useCase.Execute(context.Background(), input)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, output.Strikes)
+	assert.Equal(t, 25*time.Minute, output.BlockTime)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestExecute_WithCost_ConsumesCostTokens(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+
+	key := entity.NewTokenKey("abc123")
+	input := Input{
+		Key:    key,
+		Limit:  10,
+		Window: time.Second,
+		Cost:   4,
+	}
+
+	mockStorage.On("IsBlocked", mock.Anything, key).Return(false, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, key, 10, time.Second, 4).Return(&repository.CheckResult{Allowed: true, CurrentTokens: 6, Limit: 10}, nil)
+
+	// Act
+	output, err := useCase.Execute(context.Background(), input)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, output.Allowed)
+	assert.Equal(t, 6.0, output.CurrentTokens)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestExecute_WithoutBlockEscalation_DoesNotRecordStrikes(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
+
+	input := Input{
+		Key:       entity.NewIPKey("192.168.1.1"),
+		Limit:     10,
+		Window:    time.Second,
+		BlockTime: time.Minute,
+	}
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&repository.CheckResult{Allowed: false}, nil)
+	mockStorage.On("SetBlock", mock.Anything, mock.Anything, time.Minute, mock.Anything).Return(nil)
+
+	// Act
+	output, err := useCase.Execute(context.Background(), input)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Zero(t, output.Strikes)
+	assert.Equal(t, time.Minute, output.BlockTime)
+	mockStorage.AssertNotCalled(t, "AddStrike", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExecute_WhenRateLimitExceeded_LogsBlockWithRedactedKey(t *testing.T) {
+	// Arrange
+	var logs bytes.Buffer
+	mockStorage := new(MockStorage)
+	useCase := NewUseCase(mockStorage, slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	input := Input{
+		Key:       entity.NewTokenKey("abc123"),
+		Limit:     10,
+		Window:    time.Second,
+		BlockTime: 5 * time.Minute,
+		Policy:    PolicyToken,
+	}
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&repository.CheckResult{Allowed: false, Limit: 10}, nil)
+	mockStorage.On("SetBlock", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Act
+	_, err := useCase.Execute(context.Background(), input)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Contains(t, logs.String(), `"msg":"Key blocked after exceeding the rate limit"`)
+	assert.Contains(t, logs.String(), `"key":"token:`+entity.RedactToken("abc123")+`"`)
+	assert.NotContains(t, logs.String(), `abc123"`)
 }
 
 func TestExecute_StorageIsBlockedError_PropagatesError(t *testing.T) {
 	// Arrange
 	mockStorage := new(MockStorage)
-	useCase := NewUseCase(mockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
 
 	input := Input{
 		Key:       entity.NewIPKey("192.168.1.1"),
@@ -162,7 +311,7 @@ func TestExecute_StorageIsBlockedError_PropagatesError(t *testing.T) {
 func TestExecute_StorageCheckAndConsumeError_PropagatesError(t *testing.T) {
 	// Arrange
 	mockStorage := new(MockStorage)
-	useCase := NewUseCase(mockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
 
 	input := Input{
 		Key:       entity.NewIPKey("192.168.1.1"),
@@ -174,7 +323,7 @@ func TestExecute_StorageCheckAndConsumeError_PropagatesError(t *testing.T) {
 	expectedError := errors.New("storage check error")
 
 	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
-	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedError)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedError)
 
 	// Act
 	output, err := useCase.Execute(context.Background(), input)
@@ -185,13 +334,13 @@ func TestExecute_StorageCheckAndConsumeError_PropagatesError(t *testing.T) {
 	assert.Nil(t, output)
 
 	mockStorage.AssertCalled(t, "IsBlocked", mock.Anything, mock.Anything)
-	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestExecute_StorageSetBlockError_PropagatesError(t *testing.T) {
 	// Arrange
 	mockStorage := new(MockStorage)
-	useCase := NewUseCase(mockStorage)
+	useCase := NewUseCase(mockStorage, discardLogger())
 
 	input := Input{
 		Key:       entity.NewIPKey("192.168.1.1"),
@@ -209,8 +358,8 @@ func TestExecute_StorageSetBlockError_PropagatesError(t *testing.T) {
 	expectedError := errors.New("set block error")
 
 	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
-	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(checkResult, nil)
-	mockStorage.On("SetBlock", mock.Anything, mock.Anything, mock.Anything).Return(expectedError)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(checkResult, nil)
+	mockStorage.On("SetBlock", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedError)
 
 	// Act
 	output, err := useCase.Execute(context.Background(), input)
@@ -221,6 +370,59 @@ func TestExecute_StorageSetBlockError_PropagatesError(t *testing.T) {
 	assert.Nil(t, output)
 
 	mockStorage.AssertCalled(t, "IsBlocked", mock.Anything, mock.Anything)
-	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
-	mockStorage.AssertCalled(t, "SetBlock", mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertCalled(t, "CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertCalled(t, "SetBlock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOutput_Decision(t *testing.T) {
+	assert.Equal(t, DecisionAllowed, (&Output{Allowed: true}).Decision())
+	assert.Equal(t, DecisionRejected, (&Output{}).Decision())
+	assert.Equal(t, DecisionBlocked, (&Output{Blocked: true}).Decision())
+}
+
+func TestExecute_WithEventPublisher_PublishesBlockEvent(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	mockPublisher := new(MockEventPublisher)
+	useCase := NewUseCase(mockStorage, discardLogger()).WithEventPublisher(mockPublisher)
+
+	key := entity.NewTokenKey("partner-key")
+	input := Input{Key: key, Limit: 100, Window: time.Second, BlockTime: 10 * time.Minute, Policy: PolicyToken}
+
+	mockStorage.On("IsBlocked", mock.Anything, key).Return(false, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, key, 100, time.Second, 1).Return(&repository.CheckResult{Allowed: false, Limit: 100}, nil)
+	mockStorage.On("SetBlock", mock.Anything, key, 10*time.Minute, mock.Anything).Return(nil)
+	mockPublisher.On("Publish", mock.Anything, mock.MatchedBy(func(event entity.AuditEvent) bool {
+		return event.Action == entity.AuditActionBlock &&
+			event.Key == key &&
+			event.Duration == 10*time.Minute &&
+			event.Info.Reason == entity.BlockReasonRateExceeded &&
+			event.Info.Policy == PolicyToken
+	})).Return(errors.New("queue full"))
+
+	// Act
+	output, err := useCase.Execute(context.Background(), input)
+
+	// Assert - a falha na notificação não afeta a decisão
+	assert.NoError(t, err)
+	assert.False(t, output.Allowed)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestExecute_WithEventPublisher_AllowedRequestPublishesNothing(t *testing.T) {
+	// Arrange
+	mockStorage := new(MockStorage)
+	mockPublisher := new(MockEventPublisher)
+	useCase := NewUseCase(mockStorage, discardLogger()).WithEventPublisher(mockPublisher)
+	input := Input{Key: entity.NewIPKey("192.168.1.1"), Limit: 10, Window: time.Second, BlockTime: time.Minute}
+
+	mockStorage.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStorage.On("CheckAndConsume", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&repository.CheckResult{Allowed: true, Limit: 10}, nil)
+
+	// Act
+	_, err := useCase.Execute(context.Background(), input)
+
+	// Assert
+	assert.NoError(t, err)
+	mockPublisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
 }
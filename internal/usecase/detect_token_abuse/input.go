@@ -0,0 +1,39 @@
+package detect_token_abuse
+
+import (
+	"errors"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+// Input describes an API key that did not match any configured token (DTO)
+type Input struct {
+	IP string
+	// Token is the limiter key of the submitted API key (already hashed when TOKEN_KEY_SECRET is set)
+	Token entity.LimiterKey
+	// MaxDistinct is how many distinct invalid tokens an IP may submit within Window before being blocked
+	MaxDistinct int
+	Window      time.Duration
+	BlockTime   time.Duration
+}
+
+// Validate validates the input data
+func (i Input) Validate() error {
+	if i.IP == "" {
+		return errors.New("ip is required")
+	}
+	if !i.Token.IsValid() {
+		return errors.New("invalid token key")
+	}
+	if i.MaxDistinct <= 0 {
+		return errors.New("max distinct tokens must be positive")
+	}
+	if i.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+	if i.BlockTime <= 0 {
+		return errors.New("block time must be positive")
+	}
+	return nil
+}
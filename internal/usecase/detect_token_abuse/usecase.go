@@ -0,0 +1,109 @@
+package detect_token_abuse
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+)
+
+// Scopes of the keys used by the detector; they never share state with the client's own buckets
+const (
+	// BlockScope marks the IP key blocked after too many invalid tokens ("ip:token-abuse|1.2.3.4")
+	BlockScope = "token-abuse"
+	// counterScope counts the distinct invalid tokens of an IP
+	counterScope = "invalid-tokens"
+)
+
+// UseCase detects API key brute forcing: an IP that submits many distinct invalid tokens is blocked.
+//
+// It only relies on the Storage primitives, so the detection is shared by every instance:
+// a single distinct set per IP counts the invalid tokens of a fixed window (retries of the same
+// token count once), and expires with the window, so the state per IP stays bounded.
+type UseCase struct {
+	storage   repository.Storage
+	logger    *slog.Logger
+	publisher repository.EventPublisher
+}
+
+// NewUseCase creates a new instance using dependency injection
+func NewUseCase(storage repository.Storage, logger *slog.Logger) *UseCase {
+	return &UseCase{storage: storage, logger: logger}
+}
+
+// WithEventPublisher notifies the publisher every time an IP gets blocked
+func (uc *UseCase) WithEventPublisher(publisher repository.EventPublisher) *UseCase {
+	uc.publisher = publisher
+	return uc
+}
+
+// BlockKey returns the key blocked for an abusive IP (it can be listed and unblocked via the admin API)
+func BlockKey(ip string) entity.LimiterKey {
+	return entity.NewIPKey(ip).Scoped(BlockScope)
+}
+
+// counterKey returns the key that counts the distinct invalid tokens of an IP
+func counterKey(ip string) entity.LimiterKey {
+	return entity.NewIPKey(ip).Scoped(counterScope)
+}
+
+// IsBlocked reports whether the IP is blocked for submitting too many invalid tokens
+func (uc *UseCase) IsBlocked(ctx context.Context, ip string) (bool, error) {
+	return uc.storage.IsBlocked(ctx, BlockKey(ip))
+}
+
+// RecordInvalidToken registers an invalid token submitted by an IP.
+// It returns true when the IP is (or just became) blocked.
+func (uc *UseCase) RecordInvalidToken(ctx context.Context, input Input) (bool, error) {
+	if err := input.Validate(); err != nil {
+		return false, err
+	}
+
+	blockKey := BlockKey(input.IP)
+	blocked, err := uc.storage.IsBlocked(ctx, blockKey)
+	if err != nil || blocked {
+		return blocked, err
+	}
+
+	// Counts the distinct tokens of the window; exceeding the budget blocks the IP
+	distinct, err := uc.storage.CountDistinct(ctx, counterKey(input.IP), input.Token.Value, input.Window)
+	if err != nil {
+		return false, err
+	}
+	if distinct <= input.MaxDistinct {
+		return false, nil
+	}
+
+	info := entity.BlockInfo{
+		Reason:    entity.BlockReasonBruteForce,
+		Source:    entity.BlockSourceTokenAbuse,
+		BlockedAt: time.Now(),
+		Limit:     input.MaxDistinct,
+		Window:    input.Window,
+	}
+	if err := uc.storage.SetBlock(ctx, blockKey, input.BlockTime, info); err != nil {
+		return false, err
+	}
+	uc.logger.WarnContext(ctx, "IP blocked after submitting too many invalid API keys",
+		"ip", input.IP,
+		"max_distinct", input.MaxDistinct,
+		"window", input.Window,
+		"block_time", input.BlockTime,
+	)
+
+	if uc.publisher != nil {
+		event := entity.AuditEvent{
+			Action:   entity.AuditActionBlock,
+			Key:      blockKey,
+			Time:     info.BlockedAt,
+			Duration: input.BlockTime,
+			Info:     info,
+		}
+		if err := uc.publisher.Publish(ctx, event); err != nil {
+			uc.logger.WarnContext(ctx, "Failed to publish block event", "key", blockKey, "error", err)
+		}
+	}
+	return true, nil
+}
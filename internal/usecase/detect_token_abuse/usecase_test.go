@@ -0,0 +1,111 @@
+package detect_token_abuse
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/memory"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+func newInput(ip, token string) Input {
+	return Input{
+		IP:          ip,
+		Token:       entity.NewTokenKey(token),
+		MaxDistinct: 3,
+		Window:      time.Minute,
+		BlockTime:   10 * time.Minute,
+	}
+}
+
+func TestRecordInvalidToken_BlocksIPAfterTooManyDistinctTokens(t *testing.T) {
+	// Arrange
+	storage := memory.NewMemoryStorage()
+	useCase := NewUseCase(storage, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	// Act - 3 tokens distintos cabem no limite; o 4º bloqueia o IP
+	var results []bool
+	for _, token := range []string{"guess-1", "guess-2", "guess-3", "guess-4"} {
+		blocked, err := useCase.RecordInvalidToken(ctx, newInput("10.0.0.1", token))
+		require.NoError(t, err)
+		results = append(results, blocked)
+	}
+
+	// Assert
+	assert.Equal(t, []bool{false, false, false, true}, results)
+
+	blocked, err := useCase.IsBlocked(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	state, err := storage.GetKeyState(ctx, BlockKey("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Equal(t, entity.BlockReasonBruteForce, state.BlockInfo.Reason)
+	assert.Equal(t, entity.BlockSourceTokenAbuse, state.BlockInfo.Source)
+	assert.Equal(t, 3, state.BlockInfo.Limit)
+
+	blocked, err = useCase.IsBlocked(ctx, "10.0.0.2")
+	require.NoError(t, err)
+	assert.False(t, blocked, "outros IPs não são afetados")
+}
+
+func TestRecordInvalidToken_RetriesOfSameTokenCountOnce(t *testing.T) {
+	// Arrange - cliente com uma API key errada que insiste
+	useCase := NewUseCase(memory.NewMemoryStorage(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	// Act
+	for i := 0; i < 20; i++ {
+		blocked, err := useCase.RecordInvalidToken(ctx, newInput("10.0.0.1", "typo-key"))
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	}
+
+	// Assert
+	blocked, err := useCase.IsBlocked(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, blocked)
+}
+
+func TestRecordInvalidToken_WindowExpires_StartsCountingAgain(t *testing.T) {
+	// Arrange - janela curta: tokens espalhados por várias janelas não bloqueiam
+	useCase := NewUseCase(memory.NewMemoryStorage(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	input := func(token string) Input {
+		in := newInput("10.0.0.1", token)
+		in.Window = 50 * time.Millisecond
+		return in
+	}
+
+	// Act
+	for _, token := range []string{"guess-1", "guess-2", "guess-3"} {
+		_, err := useCase.RecordInvalidToken(ctx, input(token))
+		require.NoError(t, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	blocked, err := useCase.RecordInvalidToken(ctx, input("guess-4"))
+	require.NoError(t, err)
+
+	// Assert
+	assert.False(t, blocked)
+}
+
+func TestRecordInvalidToken_InvalidInput_ReturnsError(t *testing.T) {
+	// Arrange
+	useCase := NewUseCase(memory.NewMemoryStorage(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	input := newInput("10.0.0.1", "guess")
+	input.MaxDistinct = 0
+
+	// Act
+	_, err := useCase.RecordInvalidToken(context.Background(), input)
+
+	// Assert
+	assert.EqualError(t, err, "max distinct tokens must be positive")
+}
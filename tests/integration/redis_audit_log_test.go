@@ -0,0 +1,65 @@
+//go:build integration
+// +build integration
+
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/redis"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisAuditLog_AppendAndRecent_NewestFirst(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	auditLog := redis.NewRedisAuditLog(client, "rate_limit:audit", 1000)
+	ctx := context.Background()
+
+	now := time.UnixMilli(time.Now().UnixMilli())
+	block := entity.AuditEvent{
+		Action:   entity.AuditActionBlock,
+		Key:      entity.NewIPKey("2001:db8::1"),
+		Time:     now,
+		Duration: 10 * time.Minute,
+		Info: entity.BlockInfo{
+			Reason:    entity.BlockReasonManual,
+			Source:    "admin:alice",
+			BlockedAt: now,
+			Note:      "scraper",
+		},
+		Actor: "admin:alice",
+	}
+	unblock := entity.AuditEvent{
+		Action: entity.AuditActionUnblock,
+		Key:    entity.NewIPKey("2001:db8::1"),
+		Time:   now.Add(time.Second),
+		Actor:  "admin",
+	}
+
+	// Act
+	require.NoError(t, auditLog.Append(ctx, block))
+	require.NoError(t, auditLog.Append(ctx, unblock))
+	events, err := auditLog.Recent(ctx, 10)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, entity.AuditActionUnblock, events[0].Action)
+	assert.Equal(t, "admin", events[0].Actor)
+	assert.True(t, unblock.Time.Equal(events[0].Time))
+
+	assert.Equal(t, block.Key, events[1].Key)
+	assert.Equal(t, block.Duration, events[1].Duration)
+	assert.Equal(t, block.Info.Reason, events[1].Info.Reason)
+	assert.Equal(t, block.Info.Note, events[1].Info.Note)
+	assert.True(t, block.Info.BlockedAt.Equal(events[1].Info.BlockedAt))
+
+	length, err := client.XLen(ctx, "rate_limit:audit").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), length)
+}
@@ -0,0 +1,50 @@
+//go:build integration
+// +build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/events"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisPublisher_PublishesEventToChannel(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	ctx := context.Background()
+
+	subscription := client.Subscribe(ctx, "rate_limit:events")
+	defer subscription.Close()
+	_, err := subscription.Receive(ctx) // Confirmação da inscrição
+	require.NoError(t, err)
+
+	publisher := events.NewRedisPublisher(client, "rate_limit:events")
+	event := entity.AuditEvent{
+		Action: entity.AuditActionUnblock,
+		Key:    entity.NewIPKey("10.0.0.1"),
+		Time:   time.Now(),
+		Actor:  "admin:alice",
+	}
+
+	// Act
+	require.NoError(t, publisher.Publish(ctx, event))
+
+	// Assert
+	select {
+	case message := <-subscription.Channel():
+		var payload events.Payload
+		require.NoError(t, json.Unmarshal([]byte(message.Payload), &payload))
+		assert.Equal(t, events.TypeKeyUnblocked, payload.Type)
+		assert.Equal(t, "ip:10.0.0.1", payload.Key)
+		assert.Equal(t, "admin:alice", payload.Actor)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not received")
+	}
+}
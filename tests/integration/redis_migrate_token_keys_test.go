@@ -0,0 +1,64 @@
+//go:build integration
+// +build integration
+
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/redis"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+func TestRedisStorage_MigrateTokenKeys_MovesBucketsAndBlocks(t *testing.T) {
+	// Arrange - estado criado antes de configurar TOKEN_KEY_SECRET
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	ctx := context.Background()
+	hasher := entity.NewTokenHasher("0123456789abcdef0123456789abcdef")
+	legacy := entity.NewTokenKey("abc123")
+
+	for i := 0; i < 3; i++ {
+		_, err := redisStorage.CheckAndConsume(ctx, legacy, 5, time.Minute, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, redisStorage.SetBlock(ctx, legacy.Scoped("upload"), time.Minute, entity.BlockInfo{}))
+	_, err := redisStorage.CheckAndConsume(ctx, entity.NewIPKey("10.0.0.1"), 5, time.Minute, 1)
+	require.NoError(t, err)
+
+	// Act
+	wouldMigrate, err := redisStorage.MigrateTokenKeys(ctx, hasher, true)
+	require.NoError(t, err)
+	migrated, err := redisStorage.MigrateTokenKeys(ctx, hasher, false)
+	require.NoError(t, err)
+	again, err := redisStorage.MigrateTokenKeys(ctx, hasher, false)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 3, wouldMigrate, "tokens, last_refill e blocked")
+	assert.Equal(t, 3, migrated)
+	assert.Equal(t, 0, again, "chaves já migradas são ignoradas")
+
+	keys, err := client.Keys(ctx, "*abc123*").Result()
+	require.NoError(t, err)
+	assert.Empty(t, keys, "o token em texto puro não aparece mais nas chaves")
+
+	state, err := redisStorage.GetKeyState(ctx, hasher.Key("abc123"))
+	require.NoError(t, err)
+	assert.InDelta(t, 2, state.Tokens, 0.1, "o bucket mantém os tokens consumidos")
+
+	blocked, err := redisStorage.IsBlocked(ctx, hasher.Key("abc123").Scoped("upload"))
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	ttl, err := client.PTTL(ctx, "{"+hasher.Key("abc123").Scoped("upload").String()+"}:blocked").Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 50*time.Second, "o TTL restante é preservado")
+}
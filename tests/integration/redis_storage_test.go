@@ -42,7 +42,7 @@ func TestRedisStorage_SetBlock_CreatesBlockedKey(t *testing.T) {
 	ctx := context.Background()
 
 	// Act
-	err := redisStorage.SetBlock(ctx, key, blockTime)
+	err := redisStorage.SetBlock(ctx, key, blockTime, entity.BlockInfo{})
 	require.NoError(t, err)
 
 	blocked, err := redisStorage.IsBlocked(ctx, key)
@@ -63,7 +63,7 @@ func TestRedisStorage_SetBlock_ExpiresAfterBlockTime(t *testing.T) {
 	ctx := context.Background()
 
 	// Act
-	err := redisStorage.SetBlock(ctx, key, blockTime)
+	err := redisStorage.SetBlock(ctx, key, blockTime, entity.BlockInfo{})
 	require.NoError(t, err)
 
 	// Verify it's blocked initially
@@ -93,19 +93,122 @@ func TestRedisStorage_CheckAndConsume_AllowsFirstNRequests(t *testing.T) {
 
 	// Act & Assert - First 5 requests should be allowed
 	for i := 0; i < 5; i++ {
-		result, err := redisStorage.CheckAndConsume(ctx, key, limit, window)
+		result, err := redisStorage.CheckAndConsume(ctx, key, limit, window, 1)
 		require.NoError(t, err)
 		assert.True(t, result.Allowed, "Request %d should be allowed", i+1)
 		assert.Equal(t, limit, result.Limit)
 	}
 
 	// 6th request should be blocked
-	result, err := redisStorage.CheckAndConsume(ctx, key, limit, window)
+	result, err := redisStorage.CheckAndConsume(ctx, key, limit, window, 1)
 	require.NoError(t, err)
 	assert.False(t, result.Allowed, "6th request should be blocked")
 	assert.Equal(t, limit, result.Limit)
 }
 
+func TestRedisStorage_CheckAndConsume_ConsumesCost(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewIPKey("192.168.1.3")
+	ctx := context.Background()
+
+	// Act
+	first, err := redisStorage.CheckAndConsume(ctx, key, 5, time.Minute, 3)
+	require.NoError(t, err)
+	second, err := redisStorage.CheckAndConsume(ctx, key, 5, time.Minute, 3)
+	require.NoError(t, err)
+	third, err := redisStorage.CheckAndConsume(ctx, key, 5, time.Minute, 2)
+	require.NoError(t, err)
+
+	// Assert - a requisição negada não descarta os tokens restantes
+	assert.True(t, first.Allowed)
+	assert.InDelta(t, 2.0, first.CurrentTokens, 0.1)
+	assert.False(t, second.Allowed)
+	assert.True(t, third.Allowed)
+	assert.InDelta(t, 0.0, third.CurrentTokens, 0.1)
+}
+
+func TestRedisStorage_Reserve_QueuesCallersBehindTheDebt(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewOutboundKey("api.example.com")
+	ctx := context.Background()
+
+	// Act - 2 tokens por minuto: cada reserva além do bucket espera ~30s
+	first, err := redisStorage.Reserve(ctx, key, 2, time.Minute, 2, time.Hour)
+	require.NoError(t, err)
+	second, err := redisStorage.Reserve(ctx, key, 2, time.Minute, 1, time.Hour)
+	require.NoError(t, err)
+	third, err := redisStorage.Reserve(ctx, key, 2, time.Minute, 1, time.Second)
+	require.NoError(t, err)
+	check, err := redisStorage.CheckAndConsume(ctx, key, 2, time.Minute, 1)
+	require.NoError(t, err)
+
+	// Assert - a reserva acima do maxWait não consome nada
+	assert.True(t, first.OK)
+	assert.Zero(t, first.Delay)
+	assert.True(t, second.OK)
+	assert.InDelta(t, 30*time.Second, second.Delay, float64(2*time.Second))
+	assert.InDelta(t, -1.0, second.CurrentTokens, 0.1)
+	assert.False(t, third.OK)
+	assert.InDelta(t, 60*time.Second, third.Delay, float64(2*time.Second))
+	assert.False(t, check.Allowed, "requests wait for the reservations to be paid off")
+}
+
+func TestRedisStorage_Reserve_DelayHasSubSecondPrecision(t *testing.T) {
+	// Arrange - 10 tokens por segundo: cada token leva 100ms
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewOutboundKey("api.example.com")
+	ctx := context.Background()
+	_, err := redisStorage.Reserve(ctx, key, 10, time.Second, 10, time.Hour)
+	require.NoError(t, err)
+
+	// Act - o bucket esvaziado volta a ter tokens em menos de um segundo
+	time.Sleep(250 * time.Millisecond)
+	result, err := redisStorage.Reserve(ctx, key, 10, time.Second, 3, time.Hour)
+	require.NoError(t, err)
+
+	// Assert - ~2.5 tokens acumulados: faltam ~0.5 token (50ms), não o segundo inteiro
+	assert.True(t, result.OK)
+	assert.InDelta(t, 50*time.Millisecond, result.Delay, float64(40*time.Millisecond))
+}
+
+func TestRedisStorage_Refund_GivesBackReservedTokens(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewOutboundKey("api.example.com")
+	ctx := context.Background()
+	_, err := redisStorage.Reserve(ctx, key, 2, time.Minute, 3, time.Hour)
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, redisStorage.Refund(ctx, key, 2, time.Minute, 3))
+	require.NoError(t, redisStorage.Refund(ctx, key, 2, time.Minute, 3))
+	require.NoError(t, redisStorage.Refund(ctx, entity.NewOutboundKey("unused.example.com"), 2, time.Minute, 1))
+
+	// Assert - a devolução nunca passa da capacidade nem cria buckets
+	state, err := redisStorage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, state.Tokens, 0.01)
+	assert.WithinDuration(t, time.Now(), state.LastRefill, time.Second)
+
+	unused, err := redisStorage.GetKeyState(ctx, entity.NewOutboundKey("unused.example.com"))
+	require.NoError(t, err)
+	assert.False(t, unused.Exists)
+}
+
 func TestRedisStorage_CheckAndConsume_RefillsTokensOverTime(t *testing.T) {
 	// Arrange
 	client := setupRedis(t)
@@ -119,13 +222,13 @@ func TestRedisStorage_CheckAndConsume_RefillsTokensOverTime(t *testing.T) {
 
 	// Act - Consume all tokens
 	for i := 0; i < limit; i++ {
-		result, err := redisStorage.CheckAndConsume(ctx, key, limit, window)
+		result, err := redisStorage.CheckAndConsume(ctx, key, limit, window, 1)
 		require.NoError(t, err)
 		assert.True(t, result.Allowed, "Request %d should be allowed", i+1)
 	}
 
 	// Verify bucket is exhausted
-	result, err := redisStorage.CheckAndConsume(ctx, key, limit, window)
+	result, err := redisStorage.CheckAndConsume(ctx, key, limit, window, 1)
 	require.NoError(t, err)
 	assert.False(t, result.Allowed, "Request should be blocked after consuming all tokens")
 
@@ -133,7 +236,7 @@ func TestRedisStorage_CheckAndConsume_RefillsTokensOverTime(t *testing.T) {
 	time.Sleep(500 * time.Millisecond)
 
 	// Assert - Should be able to consume again after refill
-	result, err = redisStorage.CheckAndConsume(ctx, key, limit, window)
+	result, err = redisStorage.CheckAndConsume(ctx, key, limit, window, 1)
 	require.NoError(t, err)
 	assert.True(t, result.Allowed, "Should be able to consume after token refill")
 }
@@ -152,7 +255,7 @@ func TestRedisStorage_CheckAndConsume_DoesNotExceedCapacity(t *testing.T) {
 	// Act - Wait 2 seconds (should refill 10 tokens, but should cap at limit)
 	time.Sleep(2 * time.Second)
 
-	result, err := redisStorage.CheckAndConsume(ctx, key, limit, window)
+	result, err := redisStorage.CheckAndConsume(ctx, key, limit, window, 1)
 	require.NoError(t, err)
 
 	// Assert - Should be capped at limit, not exceeded
@@ -176,10 +279,229 @@ func TestRedisStorage_CheckAndConsume_TracksCurrentTokens(t *testing.T) {
 	expectedTokens := []float64{9.0, 8.0, 7.0, 6.0, 5.0}
 
 	for i, expected := range expectedTokens {
-		result, err := redisStorage.CheckAndConsume(ctx, key, limit, window)
+		result, err := redisStorage.CheckAndConsume(ctx, key, limit, window, 1)
 		require.NoError(t, err)
 		assert.True(t, result.Allowed, "Request %d should be allowed", i+1)
 		assert.Equal(t, expected, result.CurrentTokens, "CurrentTokens should be %.1f after %d requests", expected, i+1)
 		assert.Equal(t, limit, result.Limit)
 	}
 }
+
+func TestRedisStorage_UsesHashTaggedKeys(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	// Act
+	_, err := redisStorage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+	require.NoError(t, err)
+	require.NoError(t, redisStorage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{}))
+
+	// Assert - todas as chaves compartilham a hash tag {rate_limit:ip:192.168.1.1}
+	exists, err := client.Exists(ctx,
+		"{rate_limit:ip:192.168.1.1}:tokens",
+		"{rate_limit:ip:192.168.1.1}:last_refill",
+		"{rate_limit:ip:192.168.1.1}:blocked",
+	).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), exists)
+}
+
+func TestRedisStorage_GetKeyState_ReturnsBucketAndBlockTTL(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewIPKey("192.168.1.1")
+	ctx := context.Background()
+
+	// Act - chave ainda não usada
+	state, err := redisStorage.GetKeyState(ctx, key)
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, state.Exists)
+	assert.False(t, state.Blocked)
+
+	// Act - consome um token e bloqueia
+	_, err = redisStorage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+	require.NoError(t, err)
+	require.NoError(t, redisStorage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{}))
+	state, err = redisStorage.GetKeyState(ctx, key)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, state.Exists)
+	assert.InDelta(t, 9.0, state.Tokens, 0.01)
+	assert.False(t, state.LastRefill.IsZero())
+	assert.True(t, state.Blocked)
+	assert.InDelta(t, time.Minute.Seconds(), state.BlockTTL.Seconds(), 2)
+}
+
+func TestRedisStorage_ResetBucketAndUnblock(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewTokenKey("abc123")
+	ctx := context.Background()
+
+	_, err := redisStorage.CheckAndConsume(ctx, key, 1, time.Minute, 1)
+	require.NoError(t, err)
+	require.NoError(t, redisStorage.SetBlock(ctx, key, time.Minute, entity.BlockInfo{}))
+
+	// Act
+	require.NoError(t, redisStorage.ResetBucket(ctx, key))
+	require.NoError(t, redisStorage.Unblock(ctx, key))
+
+	// Assert
+	state, err := redisStorage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, state.Exists)
+	assert.False(t, state.Blocked)
+}
+
+func TestRedisStorage_ListBlocked_UsesScan(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	ctx := context.Background()
+	require.NoError(t, redisStorage.SetBlock(ctx, entity.NewIPKey("10.0.0.1"), time.Minute, entity.BlockInfo{}))
+	require.NoError(t, redisStorage.SetBlock(ctx, entity.NewIPKey("2001:db8::1"), time.Minute, entity.BlockInfo{}))
+	require.NoError(t, redisStorage.SetBlock(ctx, entity.NewTokenKey("abc123"), time.Minute, entity.BlockInfo{}))
+	_, err := redisStorage.CheckAndConsume(ctx, entity.NewIPKey("10.0.0.2"), 10, time.Second, 1)
+	require.NoError(t, err)
+
+	// Act
+	blocked, err := redisStorage.ListBlocked(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, blocked, 3)
+
+	keys := make([]entity.LimiterKey, 0, len(blocked))
+	for _, b := range blocked {
+		keys = append(keys, b.Key)
+		assert.Greater(t, b.TTL, time.Duration(0))
+	}
+	assert.ElementsMatch(t, []entity.LimiterKey{
+		entity.NewIPKey("10.0.0.1"),
+		entity.NewIPKey("2001:db8::1"),
+		entity.NewTokenKey("abc123"),
+	}, keys)
+}
+
+func TestRedisStorage_CountDistinct_UsesOneExpiringHyperLogLog(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	ctx := context.Background()
+	key := entity.NewIPKey("192.168.1.60").Scoped("invalid-tokens")
+
+	// Act
+	var counts []int
+	for _, member := range []string{"guess-1", "guess-2", "guess-1", "guess-3"} {
+		count, err := redisStorage.CountDistinct(ctx, key, member, time.Minute)
+		require.NoError(t, err)
+		counts = append(counts, count)
+	}
+
+	// Assert - uma única chave por IP, com o TTL da janela definido na criação
+	assert.Equal(t, []int{1, 2, 2, 3}, counts)
+
+	keys, err := client.Keys(ctx, "*invalid-tokens*").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"{" + key.String() + "}:distinct"}, keys)
+
+	ttl, err := client.PTTL(ctx, keys[0]).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 59*time.Second)
+	assert.LessOrEqual(t, ttl, time.Minute)
+}
+
+func TestRedisStorage_AddStrike_CountsAndExpiresWithDecay(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	ctx := context.Background()
+	key := entity.NewIPKey("192.168.1.50")
+
+	// Act
+	first, err := redisStorage.AddStrike(ctx, key, time.Hour)
+	require.NoError(t, err)
+	second, err := redisStorage.AddStrike(ctx, key, time.Hour)
+	require.NoError(t, err)
+	state, err := redisStorage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 2, second)
+	assert.Equal(t, 2, state.Strikes)
+
+	ttl, err := client.PTTL(ctx, "{"+key.String()+"}:strikes").Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 59*time.Minute, "cada strike renova o decay")
+
+	require.NoError(t, redisStorage.ResetStrikes(ctx, key))
+	state, err = redisStorage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+	assert.Zero(t, state.Strikes)
+}
+
+func TestRedisStorage_SetBlock_StoresBlockInfo(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewIPKey("10.0.0.1")
+	legacyKey := entity.NewIPKey("10.0.0.2")
+	ctx := context.Background()
+	info := entity.BlockInfo{
+		Reason:    entity.BlockReasonRateExceeded,
+		Source:    entity.BlockSourceRateLimiter,
+		BlockedAt: time.UnixMilli(time.Now().UnixMilli()),
+		Policy:    "ip",
+		Limit:     10,
+		Window:    time.Second,
+		Strikes:   3,
+	}
+
+	// Act - bloqueio com motivo e bloqueio no formato antigo (valor "1")
+	require.NoError(t, redisStorage.SetBlock(ctx, key, time.Minute, info))
+	require.NoError(t, client.Set(ctx, "{rate_limit:ip:10.0.0.2}:blocked", "1", time.Minute).Err())
+
+	// Assert
+	state, err := redisStorage.GetKeyState(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, info.BlockedAt.Equal(state.BlockInfo.BlockedAt))
+	state.BlockInfo.BlockedAt = info.BlockedAt
+	assert.Equal(t, info, state.BlockInfo)
+
+	legacy, err := redisStorage.GetKeyState(ctx, legacyKey)
+	require.NoError(t, err)
+	assert.True(t, legacy.Blocked)
+	assert.Equal(t, entity.BlockInfo{}, legacy.BlockInfo)
+
+	blocked, err := redisStorage.ListBlocked(ctx)
+	require.NoError(t, err)
+	require.Len(t, blocked, 2)
+	for _, b := range blocked {
+		if b.Key == key {
+			assert.Equal(t, entity.BlockReasonRateExceeded, b.Info.Reason)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+//go:build integration
+// +build integration
+
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/redis"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisTokenConfigStore_SaveGetListDelete(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	store := redis.NewRedisTokenConfigStore(client)
+	ctx := context.Background()
+
+	cfg := entity.TokenConfig{Token: "abc123", Limit: 100, Window: time.Second, BlockTime: 10 * time.Minute}
+
+	// Act
+	require.NoError(t, store.Save(ctx, cfg))
+
+	// Assert
+	got, err := store.Get(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, cfg, *got)
+
+	all, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.TokenConfig{cfg}, all)
+
+	require.NoError(t, store.Delete(ctx, "abc123"))
+	_, err = store.Get(ctx, "abc123")
+	assert.ErrorIs(t, err, repository.ErrTokenConfigNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "abc123"), repository.ErrTokenConfigNotFound)
+}
+
+func TestRedisTokenConfigStore_WithTokenHasher_NeverStoresRawToken(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	hasher := entity.NewTokenHasher("0123456789abcdef0123456789abcdef")
+	store := redis.NewRedisTokenConfigStore(client).WithTokenHasher(hasher)
+	ctx := context.Background()
+
+	// Act
+	require.NoError(t, store.Save(ctx, entity.TokenConfig{Token: "abc123", Limit: 100, Window: time.Second}))
+
+	// Assert
+	fields, err := client.HKeys(ctx, "rate_limit:token_configs").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{hasher.Digest("abc123")}, fields)
+
+	got, err := store.Get(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, hasher.Digest("abc123"), got.Token)
+	assert.Equal(t, 100, got.Limit)
+
+	require.NoError(t, store.Delete(ctx, "abc123"))
+	_, err = store.Get(ctx, hasher.Digest("abc123"))
+	assert.ErrorIs(t, err, repository.ErrTokenConfigNotFound)
+}
+
+func TestRedisTokenConfigStore_MigrateTokens_ReplacesRawFields(t *testing.T) {
+	// Arrange - configurações gravadas antes do TOKEN_KEY_SECRET
+	client := setupRedis(t)
+	ctx := context.Background()
+	legacy := redis.NewRedisTokenConfigStore(client)
+	require.NoError(t, legacy.Save(ctx, entity.TokenConfig{Token: "abc123", Limit: 100, Window: time.Second}))
+	require.NoError(t, legacy.Save(ctx, entity.TokenConfig{Token: "xyz789", Limit: 50, Window: time.Second}))
+
+	hasher := entity.NewTokenHasher("0123456789abcdef0123456789abcdef")
+	store := redis.NewRedisTokenConfigStore(client).WithTokenHasher(hasher)
+
+	// Campos legados continuam sendo lidos antes da migração
+	got, err := store.Get(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, 100, got.Limit)
+
+	// Act
+	wouldMigrate, err := store.MigrateTokens(ctx, true)
+	require.NoError(t, err)
+	migrated, err := store.MigrateTokens(ctx, false)
+	require.NoError(t, err)
+	again, err := store.MigrateTokens(ctx, false)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 2, wouldMigrate)
+	assert.Equal(t, 2, migrated)
+	assert.Equal(t, 0, again)
+
+	fields, err := client.HKeys(ctx, "rate_limit:token_configs").Result()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{hasher.Digest("abc123"), hasher.Digest("xyz789")}, fields)
+
+	got, err = store.Get(ctx, "xyz789")
+	require.NoError(t, err)
+	assert.Equal(t, 50, got.Limit)
+}
+
+func TestRedisTokenConfigStore_Watch_NotifiesOnSave(t *testing.T) {
+	// Arrange
+	client := setupRedis(t)
+	store := redis.NewRedisTokenConfigStore(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 10)
+	go store.Watch(ctx, func() { changed <- struct{}{} })
+	time.Sleep(100 * time.Millisecond) // Aguarda a inscrição no canal
+
+	// Act
+	require.NoError(t, store.Save(ctx, entity.TokenConfig{Token: "abc123", Limit: 10, Window: time.Second}))
+
+	// Assert
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected change notification")
+	}
+}
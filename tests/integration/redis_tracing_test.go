@@ -0,0 +1,58 @@
+//go:build integration
+// +build integration
+
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/storage/redis"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/adapter/tracing"
+	"github.com/EuricoCruz/rate_limiter_challeng/internal/domain/entity"
+)
+
+func TestRedisStorage_CreatesSpanPerRedisCall(t *testing.T) {
+	// Arrange
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	client := setupRedis(t)
+	redisStorage := redis.NewRedisStorage(client)
+	defer redisStorage.Close()
+
+	key := entity.NewTokenKey("abc123")
+	ctx := context.Background()
+
+	// Act
+	_, err := redisStorage.IsBlocked(ctx, key)
+	require.NoError(t, err)
+	_, err = redisStorage.CheckAndConsume(ctx, key, 10, time.Second, 1)
+	require.NoError(t, err)
+	require.NoError(t, redisStorage.SetBlock(ctx, key, time.Second, entity.BlockInfo{}))
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "redis.is_blocked", spans[0].Name())
+	assert.Equal(t, "redis.token_bucket", spans[1].Name())
+	assert.Equal(t, "redis.set_block", spans[2].Name())
+
+	attrs := make(map[string]string)
+	for _, kv := range spans[1].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, "redis", attrs["db.system"])
+	assert.Equal(t, "EVALSHA", attrs["db.operation.name"])
+	assert.Equal(t, "token", attrs[string(tracing.AttrKeyType)])
+	assert.Equal(t, "9", attrs[string(tracing.AttrTokensRemaining)])
+}